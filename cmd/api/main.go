@@ -1,13 +1,17 @@
 package main
 
 import (
+	"PattyWagon/internal/config"
 	"PattyWagon/internal/database"
 	imagecompressor "PattyWagon/internal/image_compressor"
+	"PattyWagon/internal/jobqueue"
 	"PattyWagon/internal/location"
 	"PattyWagon/internal/merchant_counter"
+	"PattyWagon/internal/model"
 	"PattyWagon/internal/repository"
 	"PattyWagon/internal/service"
 	"PattyWagon/internal/storage"
+	"PattyWagon/internal/utils"
 	"PattyWagon/logger"
 	"PattyWagon/observability"
 	"context"
@@ -50,43 +54,63 @@ func main() {
 	// Init logger
 	logger.Init()
 
-	db := database.New(
-		database.Host,
-		database.Port,
-		database.DatabaseName,
-		database.Username,
-		database.Password,
-		database.Schema,
-		&database.ConnectionPoolConfig{
-			MaxOpenConns:    int(database.MaxOpenConns),
-			MaxIdleConns:    int(database.MaxIdleConns),
-			ConnMaxIdleTime: time.Duration(database.ConnMaxIdleTime * int64(time.Second)),
-			ConnMaxLifeTime: time.Duration(database.ConnMaxLifeTime * int64(time.Second)),
-		},
-	)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jwtKeys, err := utils.LoadJWTKeys(cfg.JWT.SigningKeyFile, cfg.JWT.VerificationKeyFiles)
+	if err != nil {
+		log.Fatalf("failed to load jwt keys: %v", err)
+	}
+	utils.SetJWTKeys(jwtKeys)
+
+	db := database.New(cfg.Database)
 	defer db.Close()
 
 	repo := repository.New(db)
-	storage := storage.New(storage.S3Endpoint, storage.S3AccessKeyID, storage.S3SecretAccessKey, storage.Option{MaxConcurrent: 25})
-	imageCompressor := imagecompressor.New(imagecompressor.MaxConcurrentCompress, imagecompressor.CompressionQuality)
+	utils.SetTokenDenylist(repo)
+	storage, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("failed to set up storage: %v", err)
+	}
+	imageCompressor := imagecompressor.New(cfg.ImageCompressor)
 	locationService := location.NewService()
 	merchantCounter := merchant_counter.New(repo)
-	svc := service.New(repo, storage, imageCompressor, locationService, merchantCounter)
-	serv := server.NewServer(svc)
+	nearbySearch, err := service.NewNearbySearchStrategy(cfg.NearbySearch, repo, locationService)
+	if err != nil {
+		log.Fatalf("failed to set up nearby search: %v", err)
+	}
+	travelTime, err := service.NewTravelTimeModel(cfg.TravelTime)
+	if err != nil {
+		log.Fatalf("failed to set up travel time model: %v", err)
+	}
+	svc := service.New(cfg, repo, storage, imageCompressor, locationService, merchantCounter, nearbySearch, travelTime)
+	serv := server.NewServer(svc, cfg.Server.Port)
+
+	// Background jobs run until the API has shut down, jobs a killed process left running are claimed again
+	// once their lock times out
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	jobs := jobqueue.New(cfg.Jobs, repo, map[string]jobqueue.Handler{
+		model.JobGenerateThumbnail: svc.ThumbnailJobHandler(),
+	})
+	jobs.Start(jobCtx)
 
-	observability.SetupTracer(context.Background(), observability.OtlpEndpoint)
+	observability.SetupTracer(context.Background(), cfg.Observability.OtlpEndpoint)
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
 	// Run graceful shutdown in a separate goroutine
 	go gracefulShutdown(serv, done)
 
-	err := serv.ListenAndServe()
+	err = serv.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
 		panic(fmt.Sprintf("http server error: %s", err))
 	}
 
 	// Wait for the graceful shutdown to complete
 	<-done
+	stopJobs()
+	jobs.Wait()
 	log.Println("Graceful shutdown complete.")
 }
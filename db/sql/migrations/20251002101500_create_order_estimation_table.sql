@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE order_estimations (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  user_latitude NUMERIC(12,6) NOT NULL,
+  user_longitude NUMERIC(12,6) NOT NULL,
+  total_price NUMERIC(12,2) NOT NULL,
+  estimated_delivery_in_minutes INT NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_user
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE TABLE order_estimation_items (
+  id BIGSERIAL PRIMARY KEY,
+  estimation_id BIGINT NOT NULL,
+  merchant_id BIGINT NOT NULL,
+  item_id BIGINT NOT NULL,
+  quantity INT NOT NULL,
+  price NUMERIC(12,2) NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_estimation
+    FOREIGN KEY (estimation_id)
+    REFERENCES order_estimations(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_merchant
+    FOREIGN KEY (merchant_id)
+    REFERENCES merchants(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_item
+    FOREIGN KEY (item_id)
+    REFERENCES items(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_order_estimation_items_estimation_id ON order_estimation_items(estimation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_order_estimation_items_estimation_id;
+DROP TABLE IF EXISTS order_estimation_items;
+DROP TABLE IF EXISTS order_estimations;
+-- +goose StatementEnd
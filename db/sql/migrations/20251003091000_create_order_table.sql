@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE orders (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  estimation_id BIGINT NOT NULL,
+  total_price NUMERIC(12,2) NOT NULL,
+  estimated_delivery_in_minutes INT NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_user
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_estimation
+    FOREIGN KEY (estimation_id)
+    REFERENCES order_estimations(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_orders_estimation_id ON orders(estimation_id);
+CREATE INDEX idx_orders_user_id_created_at ON orders(user_id, created_at DESC);
+
+CREATE TABLE order_items (
+  id BIGSERIAL PRIMARY KEY,
+  order_id BIGINT NOT NULL,
+  merchant_id BIGINT NOT NULL,
+  item_id BIGINT NOT NULL,
+  quantity INT NOT NULL,
+  price NUMERIC(12,2) NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_order
+    FOREIGN KEY (order_id)
+    REFERENCES orders(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_merchant
+    FOREIGN KEY (merchant_id)
+    REFERENCES merchants(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_item
+    FOREIGN KEY (item_id)
+    REFERENCES items(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_order_items_order_id ON order_items(order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_order_items_order_id;
+DROP TABLE IF EXISTS order_items;
+DROP INDEX IF EXISTS idx_orders_user_id_created_at;
+DROP INDEX IF EXISTS idx_orders_estimation_id;
+DROP TABLE IF EXISTS orders;
+-- +goose StatementEnd
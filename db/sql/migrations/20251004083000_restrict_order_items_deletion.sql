@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Placed orders are purchase history, so merchants and items referenced by them must not be removed silently
+ALTER TABLE order_items DROP CONSTRAINT fk_merchant;
+ALTER TABLE order_items ADD CONSTRAINT fk_merchant
+  FOREIGN KEY (merchant_id)
+  REFERENCES merchants(id)
+  ON DELETE RESTRICT
+  ON UPDATE CASCADE;
+
+ALTER TABLE order_items DROP CONSTRAINT fk_item;
+ALTER TABLE order_items ADD CONSTRAINT fk_item
+  FOREIGN KEY (item_id)
+  REFERENCES items(id)
+  ON DELETE RESTRICT
+  ON UPDATE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_items DROP CONSTRAINT fk_item;
+ALTER TABLE order_items ADD CONSTRAINT fk_item
+  FOREIGN KEY (item_id)
+  REFERENCES items(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE;
+
+ALTER TABLE order_items DROP CONSTRAINT fk_merchant;
+ALTER TABLE order_items ADD CONSTRAINT fk_merchant
+  FOREIGN KEY (merchant_id)
+  REFERENCES merchants(id)
+  ON DELETE CASCADE
+  ON UPDATE CASCADE;
+-- +goose StatementEnd
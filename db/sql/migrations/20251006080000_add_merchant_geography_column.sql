@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+ALTER TABLE merchants
+  ADD COLUMN location geography(Point, 4326)
+  GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(longitude::float8, latitude::float8), 4326)::geography) STORED;
+
+CREATE INDEX idx_merchants_location ON merchants USING GIST (location);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_merchants_location;
+ALTER TABLE merchants DROP COLUMN IF EXISTS location;
+-- +goose StatementEnd
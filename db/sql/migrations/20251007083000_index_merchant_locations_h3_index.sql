@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_merchant_locations_h3_index
+  ON merchant_locations (h3_index, merchant_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_merchant_locations_h3_index;
+-- +goose StatementEnd
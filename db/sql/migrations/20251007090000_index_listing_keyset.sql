@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_merchants_user_id_created_at_id
+  ON merchants (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_items_merchant_id_created_at_id
+  ON items (merchant_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_items_merchant_id_created_at_id;
+DROP INDEX IF EXISTS idx_merchants_user_id_created_at_id;
+-- +goose StatementEnd
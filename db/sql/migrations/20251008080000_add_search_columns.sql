@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE merchants
+  ADD COLUMN search_vector tsvector
+  GENERATED ALWAYS AS (to_tsvector('simple', coalesce(name, ''))) STORED;
+
+ALTER TABLE items
+  ADD COLUMN search_vector tsvector
+  GENERATED ALWAYS AS (to_tsvector('simple', coalesce(name, ''))) STORED;
+
+CREATE INDEX idx_merchants_search_vector ON merchants USING GIN (search_vector);
+CREATE INDEX idx_merchants_name_trgm ON merchants USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_items_search_vector ON items USING GIN (search_vector);
+CREATE INDEX idx_items_name_trgm ON items USING GIN (name gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_items_name_trgm;
+DROP INDEX IF EXISTS idx_items_search_vector;
+DROP INDEX IF EXISTS idx_merchants_name_trgm;
+DROP INDEX IF EXISTS idx_merchants_search_vector;
+ALTER TABLE items DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE merchants DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
@@ -0,0 +1,82 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE merchants ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+CREATE TABLE merchant_opening_hours (
+  id BIGSERIAL PRIMARY KEY,
+  merchant_id BIGINT NOT NULL,
+  -- 0 is Sunday, as in EXTRACT(DOW)
+  weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+  opens_at TIME NOT NULL,
+  -- closing at or before opens_at runs past midnight into the next day
+  closes_at TIME NOT NULL,
+  CONSTRAINT fk_merchant
+    FOREIGN KEY (merchant_id)
+    REFERENCES merchants(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_merchant_opening_hours_merchant_id ON merchant_opening_hours (merchant_id);
+
+CREATE TABLE merchant_holidays (
+  id BIGSERIAL PRIMARY KEY,
+  merchant_id BIGINT NOT NULL,
+  date DATE NOT NULL,
+  -- without hours the merchant is closed for the whole date
+  opens_at TIME,
+  closes_at TIME,
+  CONSTRAINT fk_merchant
+    FOREIGN KEY (merchant_id)
+    REFERENCES merchants(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT uq_merchant_holidays_date UNIQUE (merchant_id, date),
+  CONSTRAINT chk_merchant_holidays_hours CHECK ((opens_at IS NULL) = (closes_at IS NULL))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- merchant_is_open tells whether a merchant is open at a moment, evaluated in the merchant's own timezone.
+-- A holiday override decides its whole local date, merchants without any opening hours are always open.
+CREATE FUNCTION merchant_is_open(p_merchant_id BIGINT, p_at TIMESTAMPTZ) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+  SELECT CASE
+    WHEN EXISTS (
+      SELECT 1 FROM merchant_holidays h WHERE h.merchant_id = p_merchant_id AND h.date = l.at::date
+    ) THEN EXISTS (
+      SELECT 1 FROM merchant_holidays h
+      WHERE h.merchant_id = p_merchant_id
+        AND h.date = l.at::date
+        AND h.opens_at <= l.at::time
+        AND (l.at::time < h.closes_at OR h.closes_at <= h.opens_at)
+    )
+    WHEN NOT EXISTS (
+      SELECT 1 FROM merchant_opening_hours oh WHERE oh.merchant_id = p_merchant_id
+    ) THEN TRUE
+    ELSE EXISTS (
+      SELECT 1 FROM merchant_opening_hours oh
+      WHERE oh.merchant_id = p_merchant_id
+        AND (
+          (oh.weekday = EXTRACT(DOW FROM l.at)
+            AND oh.opens_at <= l.at::time
+            AND (l.at::time < oh.closes_at OR oh.closes_at <= oh.opens_at))
+          OR
+          -- the tail of hours that started the day before and run past midnight
+          (oh.weekday = (EXTRACT(DOW FROM l.at)::int + 6) % 7
+            AND oh.closes_at <= oh.opens_at
+            AND l.at::time < oh.closes_at)
+        )
+    )
+  END
+  FROM (SELECT p_at AT TIME ZONE m.timezone AS at FROM merchants m WHERE m.id = p_merchant_id) l
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS merchant_is_open(BIGINT, TIMESTAMPTZ);
+DROP TABLE IF EXISTS merchant_holidays;
+DROP TABLE IF EXISTS merchant_opening_hours;
+ALTER TABLE merchants DROP COLUMN IF EXISTS timezone;
+-- +goose StatementEnd
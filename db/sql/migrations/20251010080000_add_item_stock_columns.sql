@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE items
+  ADD COLUMN is_available BOOLEAN NOT NULL DEFAULT TRUE,
+  -- NULL stock is not tracked, the item never runs out
+  ADD COLUMN stock INTEGER CHECK (stock >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE items
+  DROP COLUMN IF EXISTS stock,
+  DROP COLUMN IF EXISTS is_available;
+-- +goose StatementEnd
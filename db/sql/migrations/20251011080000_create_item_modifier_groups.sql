@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE item_modifier_groups (
+  id BIGSERIAL PRIMARY KEY,
+  item_id BIGINT NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  min_selections SMALLINT NOT NULL DEFAULT 0,
+  max_selections SMALLINT NOT NULL,
+  -- order the groups are listed in for the item
+  position SMALLINT NOT NULL,
+  CONSTRAINT fk_item
+    FOREIGN KEY (item_id)
+    REFERENCES items(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT chk_item_modifier_groups_selections CHECK (min_selections >= 0 AND max_selections >= GREATEST(min_selections, 1))
+);
+
+CREATE INDEX idx_item_modifier_groups_item_id ON item_modifier_groups (item_id);
+
+CREATE TABLE item_modifier_options (
+  id BIGSERIAL PRIMARY KEY,
+  group_id BIGINT NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  price_delta NUMERIC(12,2) NOT NULL DEFAULT 0,
+  position SMALLINT NOT NULL,
+  CONSTRAINT fk_modifier_group
+    FOREIGN KEY (group_id)
+    REFERENCES item_modifier_groups(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_item_modifier_options_group_id ON item_modifier_options (group_id);
+
+-- the options picked for an ordered item, kept as they were priced when the order was estimated
+ALTER TABLE order_estimation_items ADD COLUMN modifiers JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE order_items ADD COLUMN modifiers JSONB NOT NULL DEFAULT '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_items DROP COLUMN IF EXISTS modifiers;
+ALTER TABLE order_estimation_items DROP COLUMN IF EXISTS modifiers;
+DROP TABLE IF EXISTS item_modifier_options;
+DROP TABLE IF EXISTS item_modifier_groups;
+-- +goose StatementEnd
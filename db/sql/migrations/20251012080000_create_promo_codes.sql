@@ -0,0 +1,70 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE promo_codes (
+  id BIGSERIAL PRIMARY KEY,
+  code VARCHAR(50) NOT NULL,
+  -- percent takes discount_value percent off the subtotal, fixed takes discount_value off it
+  discount_type VARCHAR(10) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+  discount_value NUMERIC(12,2) NOT NULL CHECK (discount_value > 0),
+  -- caps a percent discount, NULL is uncapped
+  max_discount NUMERIC(12,2),
+  min_spend NUMERIC(12,2) NOT NULL DEFAULT 0,
+  -- NULL lets a user redeem the code any number of times
+  usage_limit_per_user INT CHECK (usage_limit_per_user > 0),
+  starts_at TIMESTAMPTZ,
+  expires_at TIMESTAMPTZ,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_promo_codes_code ON promo_codes (UPPER(code));
+
+CREATE TABLE promo_code_redemptions (
+  id BIGSERIAL PRIMARY KEY,
+  promo_code_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  order_id BIGINT NOT NULL UNIQUE,
+  created_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_promo_code
+    FOREIGN KEY (promo_code_id)
+    REFERENCES promo_codes(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_user
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT fk_order
+    FOREIGN KEY (order_id)
+    REFERENCES orders(id)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_promo_code_redemptions_promo_code_id_user_id ON promo_code_redemptions (promo_code_id, user_id);
+
+-- total_price stays the bare sum of item prices, grand_total is what the user pays
+ALTER TABLE order_estimations
+  ADD COLUMN distance_in_meters NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN delivery_fee NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN service_fee NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN discount NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN tax NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN grand_total NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN promo_code_id BIGINT REFERENCES promo_codes(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_estimations
+  DROP COLUMN IF EXISTS promo_code_id,
+  DROP COLUMN IF EXISTS grand_total,
+  DROP COLUMN IF EXISTS tax,
+  DROP COLUMN IF EXISTS discount,
+  DROP COLUMN IF EXISTS service_fee,
+  DROP COLUMN IF EXISTS delivery_fee,
+  DROP COLUMN IF EXISTS distance_in_meters;
+DROP TABLE IF EXISTS promo_code_redemptions;
+DROP TABLE IF EXISTS promo_codes;
+-- +goose StatementEnd
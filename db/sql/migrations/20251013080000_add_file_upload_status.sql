@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- files uploaded straight to storage stay pending until the upload is completed through the API
+ALTER TABLE files
+  ADD COLUMN status TEXT NOT NULL DEFAULT 'ready' CHECK (status IN ('pending', 'ready')),
+  ADD COLUMN object_key TEXT,
+  ADD COLUMN filename TEXT,
+  ADD COLUMN size_in_bytes BIGINT,
+  ADD COLUMN upload_expires_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files
+  DROP COLUMN IF EXISTS upload_expires_at,
+  DROP COLUMN IF EXISTS size_in_bytes,
+  DROP COLUMN IF EXISTS filename,
+  DROP COLUMN IF EXISTS object_key,
+  DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE jobs (
+  id BIGSERIAL PRIMARY KEY,
+  type TEXT NOT NULL,
+  payload JSONB NOT NULL DEFAULT '{}',
+  -- dead jobs ran out of attempts and wait for someone to look at last_error
+  status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'dead')),
+  attempts INT NOT NULL DEFAULT 0,
+  run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  locked_at TIMESTAMP,
+  last_error TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- workers only ever look for queued jobs that are due, or running ones whose worker went away
+CREATE INDEX idx_jobs_claimable ON jobs (run_at, id) WHERE status IN ('queued', 'running');
+
+-- files uploaded before the queue already have their thumbnail
+ALTER TABLE files
+  ADD COLUMN thumbnail_status TEXT NOT NULL DEFAULT 'ready' CHECK (thumbnail_status IN ('pending', 'ready', 'failed'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN IF EXISTS thumbnail_status;
+DROP TABLE IF EXISTS jobs;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- every resized copy of the image by rendition name, as {"uri", "format", "width", "height", "sizeInBytes"}
+ALTER TABLE files ADD COLUMN renditions JSONB NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN IF EXISTS renditions;
+-- +goose StatementEnd
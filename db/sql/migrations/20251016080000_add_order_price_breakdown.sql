@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- orders keep the price the user agreed to, total_price stays the bare sum of item prices like on the estimate
+ALTER TABLE orders
+  ADD COLUMN distance_in_meters NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN delivery_fee NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN service_fee NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN discount NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN tax NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN grand_total NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+UPDATE orders o
+SET distance_in_meters = e.distance_in_meters,
+    delivery_fee = e.delivery_fee,
+    service_fee = e.service_fee,
+    discount = e.discount,
+    tax = e.tax,
+    grand_total = e.grand_total
+FROM order_estimations e
+WHERE e.id = o.estimation_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+  DROP COLUMN IF EXISTS grand_total,
+  DROP COLUMN IF EXISTS tax,
+  DROP COLUMN IF EXISTS discount,
+  DROP COLUMN IF EXISTS service_fee,
+  DROP COLUMN IF EXISTS delivery_fee,
+  DROP COLUMN IF EXISTS distance_in_meters;
+-- +goose StatementEnd
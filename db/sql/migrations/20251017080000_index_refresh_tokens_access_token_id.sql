@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a bodyless logout finds the session by the access token it was issued with
+CREATE INDEX idx_refresh_tokens_access_token_id ON refresh_tokens(access_token_id) WHERE revoked_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_refresh_tokens_access_token_id;
+-- +goose StatementEnd
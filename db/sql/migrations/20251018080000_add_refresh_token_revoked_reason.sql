@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- only reusing a rotated token hints at theft, a token ended by logging out is merely stale
+ALTER TABLE refresh_tokens
+  ADD COLUMN revoked_reason VARCHAR(10) CHECK (revoked_reason IN ('rotated', 'logout'));
+
+-- tokens revoked so far keep being treated as rotated
+UPDATE refresh_tokens SET revoked_reason = 'rotated' WHERE revoked_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS revoked_reason;
+-- +goose StatementEnd
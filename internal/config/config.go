@@ -0,0 +1,377 @@
+package config
+
+import (
+	"PattyWagon/internal/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/joho/godotenv/autoload"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the optional config file, environment variables override whatever it sets
+const FileEnv = "CONFIG_FILE"
+
+// Image formats a rendition can be encoded to
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+	FormatAVIF = "avif"
+)
+
+const (
+	StorageMinio  = "minio"
+	StorageLocal  = "local"
+	StorageMemory = "memory"
+)
+
+// Config is every setting of the API, loaded once at startup and handed to the packages that need it
+type Config struct {
+	Server          Server          `json:"server" yaml:"server"`
+	Database        Database        `json:"database" yaml:"database"`
+	Storage         Storage         `json:"storage" yaml:"storage"`
+	ImageCompressor ImageCompressor `json:"imageCompressor" yaml:"imageCompressor"`
+	JWT             JWT             `json:"jwt" yaml:"jwt"`
+	Observability   Observability   `json:"observability" yaml:"observability"`
+	NearbySearch    NearbySearch    `json:"nearbySearch" yaml:"nearbySearch"`
+	Pricing         Pricing         `json:"pricing" yaml:"pricing"`
+	TravelTime      TravelTime      `json:"travelTime" yaml:"travelTime"`
+	Jobs            Jobs            `json:"jobs" yaml:"jobs"`
+}
+
+type Server struct {
+	Port int `json:"port" yaml:"port"`
+}
+
+type Database struct {
+	Host                     string `json:"host" yaml:"host"`
+	Port                     string `json:"port" yaml:"port"`
+	Name                     string `json:"name" yaml:"name"`
+	Username                 string `json:"username" yaml:"username"`
+	Password                 string `json:"password" yaml:"password"`
+	Schema                   string `json:"schema" yaml:"schema"`
+	MaxOpenConns             int    `json:"maxOpenConns" yaml:"maxOpenConns"`
+	MaxIdleConns             int    `json:"maxIdleConns" yaml:"maxIdleConns"`
+	ConnMaxIdleTimeInSeconds int    `json:"connMaxIdleTimeInSeconds" yaml:"connMaxIdleTimeInSeconds"`
+	ConnMaxLifeTimeInSeconds int    `json:"connMaxLifeTimeInSeconds" yaml:"connMaxLifeTimeInSeconds"`
+}
+
+type Storage struct {
+	// Backend is minio, local (files on disk served by the API) or memory (for tests)
+	Backend string `json:"backend" yaml:"backend"`
+	// LocalDir is where the local backend keeps its files
+	LocalDir string `json:"localDir" yaml:"localDir"`
+	// PublicBaseURL prefixes the URI of stored files, the local and memory backends require the API's own URL
+	PublicBaseURL       string `json:"publicBaseUrl" yaml:"publicBaseUrl"`
+	Endpoint            string `json:"endpoint" yaml:"endpoint"`
+	AccessKeyID         string `json:"accessKeyId" yaml:"accessKeyId"`
+	SecretAccessKey     string `json:"secretAccessKey" yaml:"secretAccessKey"`
+	Bucket              string `json:"bucket" yaml:"bucket"`
+	MaxConcurrentUpload int    `json:"maxConcurrentUpload" yaml:"maxConcurrentUpload"`
+}
+
+type ImageCompressor struct {
+	MaxConcurrentCompress int `json:"maxConcurrentCompress" yaml:"maxConcurrentCompress"`
+	// Quality is used by renditions that do not set their own
+	Quality    int         `json:"quality" yaml:"quality"`
+	Renditions []Rendition `json:"renditions" yaml:"renditions"`
+}
+
+// Rendition is one size every uploaded image is resized to, keeping its aspect ratio within SizeInPixels.
+// The rendition named thumbnail, or else the smallest one, is the file's thumbnail.
+type Rendition struct {
+	Name         string `json:"name" yaml:"name"`
+	SizeInPixels int    `json:"sizeInPixels" yaml:"sizeInPixels"`
+	// Format is jpeg, png, webp or avif
+	Format  string `json:"format" yaml:"format"`
+	Quality int    `json:"quality" yaml:"quality"`
+}
+
+type JWT struct {
+	SigningKeyFile string `json:"signingKeyFile" yaml:"signingKeyFile"`
+	// VerificationKeyFiles lists retired public keys whose tokens are still accepted
+	VerificationKeyFiles []string `json:"verificationKeyFiles" yaml:"verificationKeyFiles"`
+}
+
+type Observability struct {
+	OtlpEndpoint string `json:"otlpEndpoint" yaml:"otlpEndpoint"`
+}
+
+type NearbySearch struct {
+	// Strategy is h3 (k-ring expansion) or postgis (GiST index within RadiusInMeters)
+	Strategy          string `json:"strategy" yaml:"strategy"`
+	RadiusInMeters    int    `json:"radiusInMeters" yaml:"radiusInMeters"`
+	H3StartResolution int    `json:"h3StartResolution" yaml:"h3StartResolution"`
+}
+
+type Pricing struct {
+	DeliveryBaseFee  float64 `json:"deliveryBaseFee" yaml:"deliveryBaseFee"`
+	DeliveryFeePerKm float64 `json:"deliveryFeePerKm" yaml:"deliveryFeePerKm"`
+	ServiceFee       float64 `json:"serviceFee" yaml:"serviceFee"`
+	TaxRatePercent   float64 `json:"taxRatePercent" yaml:"taxRatePercent"`
+}
+
+type TravelTime struct {
+	// Model is speed_profile (straight line) or road_graph (A* over RoadGraphFile)
+	Model         string            `json:"model" yaml:"model"`
+	ProfileFile   string            `json:"profileFile" yaml:"profileFile"`
+	RoadGraphFile string            `json:"roadGraphFile" yaml:"roadGraphFile"`
+	VehicleType   model.VehicleType `json:"vehicleType" yaml:"vehicleType"`
+}
+
+type Jobs struct {
+	// Workers is how many background jobs run at once, 0 leaves the queue to another process
+	Workers          int `json:"workers" yaml:"workers"`
+	PollIntervalInMs int `json:"pollIntervalInMs" yaml:"pollIntervalInMs"`
+	// MaxAttempts is how many runs a job gets before it is moved to the dead letter state
+	MaxAttempts int `json:"maxAttempts" yaml:"maxAttempts"`
+	// BackoffInSeconds is the delay before the first retry, doubled on every further retry
+	BackoffInSeconds int `json:"backoffInSeconds" yaml:"backoffInSeconds"`
+	// LockTimeoutInSeconds is how long a job may run before another worker assumes its worker died
+	LockTimeoutInSeconds int `json:"lockTimeoutInSeconds" yaml:"lockTimeoutInSeconds"`
+}
+
+// Default holds the settings used when neither the file nor the environment sets them
+func Default() Config {
+	return Config{
+		Server: Server{Port: 8080},
+		Database: Database{
+			Schema:                   "public",
+			MaxOpenConns:             20,
+			MaxIdleConns:             10,
+			ConnMaxIdleTimeInSeconds: 60,
+			ConnMaxLifeTimeInSeconds: 300,
+		},
+		Storage: Storage{Backend: StorageMinio, LocalDir: "data/files", MaxConcurrentUpload: 25},
+		ImageCompressor: ImageCompressor{
+			MaxConcurrentCompress: 10,
+			Quality:               50,
+			Renditions: []Rendition{
+				{Name: "thumbnail", SizeInPixels: 150, Format: FormatJPEG},
+				{Name: "medium", SizeInPixels: 480, Format: FormatWebP, Quality: 75},
+				{Name: "large", SizeInPixels: 1080, Format: FormatWebP, Quality: 80},
+			},
+		},
+		NearbySearch: NearbySearch{Strategy: "h3", RadiusInMeters: 20000, H3StartResolution: 8},
+		Pricing: Pricing{
+			DeliveryBaseFee:  5000,
+			DeliveryFeePerKm: 2500,
+			ServiceFee:       2000,
+			TaxRatePercent:   11,
+		},
+		TravelTime: TravelTime{Model: "speed_profile", VehicleType: model.VehicleMotorbike},
+		Jobs: Jobs{
+			Workers:              2,
+			PollIntervalInMs:     1000,
+			MaxAttempts:          5,
+			BackoffInSeconds:     5,
+			LockTimeoutInSeconds: 300,
+		},
+	}
+}
+
+// Load builds the config from the defaults, then the file named by CONFIG_FILE if any, then the environment.
+// Every malformed or missing setting is reported in the returned error, not only the first one.
+func Load() (Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv(FileEnv); path != "" {
+		if err := decodeFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	env := &envReader{}
+	env.int("PORT", &cfg.Server.Port)
+
+	env.string("DB_HOST", &cfg.Database.Host)
+	env.string("DB_PORT", &cfg.Database.Port)
+	env.string("DB_DATABASE", &cfg.Database.Name)
+	env.string("DB_USERNAME", &cfg.Database.Username)
+	env.string("DB_PASSWORD", &cfg.Database.Password)
+	env.string("DB_SCHEMA", &cfg.Database.Schema)
+	env.int("DB_MAX_OPEN_CONNS", &cfg.Database.MaxOpenConns)
+	env.int("DB_MAX_IDLE_CONNS", &cfg.Database.MaxIdleConns)
+	env.int("DB_CONN_MAX_IDLE_TIME_IN_SECONDS", &cfg.Database.ConnMaxIdleTimeInSeconds)
+	env.int("DB_CONN_MAX_LIFE_TIME_IN_SECONDS", &cfg.Database.ConnMaxLifeTimeInSeconds)
+
+	env.string("STORAGE_BACKEND", &cfg.Storage.Backend)
+	env.string("STORAGE_LOCAL_DIR", &cfg.Storage.LocalDir)
+	env.string("STORAGE_PUBLIC_BASE_URL", &cfg.Storage.PublicBaseURL)
+	env.string("S3_ENDPOINT", &cfg.Storage.Endpoint)
+	env.string("S3_ACCESS_KEY_ID", &cfg.Storage.AccessKeyID)
+	env.string("S3_SECRET_ACCESS_KEY", &cfg.Storage.SecretAccessKey)
+	env.string("S3_BUCKET", &cfg.Storage.Bucket)
+	env.int("S3_MAX_CONCURRENT_UPLOAD", &cfg.Storage.MaxConcurrentUpload)
+
+	env.int("MAX_CONCURRENT_COMPRESS", &cfg.ImageCompressor.MaxConcurrentCompress)
+	env.int("COMPRESSION_QUALITY", &cfg.ImageCompressor.Quality)
+	env.renditions("IMAGE_RENDITIONS", &cfg.ImageCompressor.Renditions)
+
+	env.string("JWT_SIGNING_KEY_FILE", &cfg.JWT.SigningKeyFile)
+	env.list("JWT_VERIFICATION_KEY_FILES", &cfg.JWT.VerificationKeyFiles)
+
+	env.string("OTLP_ENDPOINT", &cfg.Observability.OtlpEndpoint)
+
+	env.string("NEARBY_SEARCH_STRATEGY", &cfg.NearbySearch.Strategy)
+	env.int("NEARBY_SEARCH_RADIUS_IN_METERS", &cfg.NearbySearch.RadiusInMeters)
+	env.int("H3_START_RESOLUTION", &cfg.NearbySearch.H3StartResolution)
+
+	env.float("DELIVERY_BASE_FEE", &cfg.Pricing.DeliveryBaseFee)
+	env.float("DELIVERY_FEE_PER_KM", &cfg.Pricing.DeliveryFeePerKm)
+	env.float("SERVICE_FEE", &cfg.Pricing.ServiceFee)
+	env.float("TAX_RATE_PERCENT", &cfg.Pricing.TaxRatePercent)
+
+	env.string("TRAVEL_TIME_MODEL", &cfg.TravelTime.Model)
+	env.string("TRAVEL_TIME_PROFILE_FILE", &cfg.TravelTime.ProfileFile)
+	env.string("ROAD_GRAPH_FILE", &cfg.TravelTime.RoadGraphFile)
+	vehicleType := string(cfg.TravelTime.VehicleType)
+	env.string("DELIVERY_VEHICLE_TYPE", &vehicleType)
+	cfg.TravelTime.VehicleType = model.VehicleType(vehicleType)
+
+	env.int("JOB_WORKERS", &cfg.Jobs.Workers)
+	env.int("JOB_POLL_INTERVAL_IN_MS", &cfg.Jobs.PollIntervalInMs)
+	env.int("JOB_MAX_ATTEMPTS", &cfg.Jobs.MaxAttempts)
+	env.int("JOB_BACKOFF_IN_SECONDS", &cfg.Jobs.BackoffInSeconds)
+	env.int("JOB_LOCK_TIMEOUT_IN_SECONDS", &cfg.Jobs.LockTimeoutInSeconds)
+
+	if err := errors.Join(append(env.errs, cfg.Validate())...); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Validate reports every setting that is missing or out of range
+func (c Config) Validate() error {
+	var errs []error
+	required := func(name, value string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", name))
+		}
+	}
+	positive := func(name string, value int) {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %d", name, value))
+		}
+	}
+	oneOf := func(name, value string, allowed ...string) {
+		for _, a := range allowed {
+			if strings.EqualFold(value, a) {
+				return
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s must be one of %s, got %q", name, strings.Join(allowed, ", "), value))
+	}
+
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be between 1 and 65535, got %d", c.Server.Port))
+	}
+
+	required("DB_HOST", c.Database.Host)
+	required("DB_PORT", c.Database.Port)
+	required("DB_DATABASE", c.Database.Name)
+	required("DB_USERNAME", c.Database.Username)
+	required("DB_SCHEMA", c.Database.Schema)
+	positive("DB_MAX_OPEN_CONNS", c.Database.MaxOpenConns)
+	positive("DB_MAX_IDLE_CONNS", c.Database.MaxIdleConns)
+	positive("DB_CONN_MAX_IDLE_TIME_IN_SECONDS", c.Database.ConnMaxIdleTimeInSeconds)
+	positive("DB_CONN_MAX_LIFE_TIME_IN_SECONDS", c.Database.ConnMaxLifeTimeInSeconds)
+
+	oneOf("STORAGE_BACKEND", c.Storage.Backend, StorageMinio, StorageLocal, StorageMemory)
+	switch strings.ToLower(c.Storage.Backend) {
+	case StorageMinio:
+		required("S3_ENDPOINT", c.Storage.Endpoint)
+		required("S3_ACCESS_KEY_ID", c.Storage.AccessKeyID)
+		required("S3_SECRET_ACCESS_KEY", c.Storage.SecretAccessKey)
+		positive("S3_MAX_CONCURRENT_UPLOAD", c.Storage.MaxConcurrentUpload)
+	case StorageLocal:
+		required("STORAGE_LOCAL_DIR", c.Storage.LocalDir)
+		required("STORAGE_PUBLIC_BASE_URL", c.Storage.PublicBaseURL)
+	case StorageMemory:
+		required("STORAGE_PUBLIC_BASE_URL", c.Storage.PublicBaseURL)
+	}
+	required("S3_BUCKET", c.Storage.Bucket)
+
+	positive("MAX_CONCURRENT_COMPRESS", c.ImageCompressor.MaxConcurrentCompress)
+	if c.ImageCompressor.Quality < 1 || c.ImageCompressor.Quality > 100 {
+		errs = append(errs, fmt.Errorf("COMPRESSION_QUALITY must be between 1 and 100, got %d", c.ImageCompressor.Quality))
+	}
+	if len(c.ImageCompressor.Renditions) == 0 {
+		errs = append(errs, errors.New("IMAGE_RENDITIONS is required"))
+	}
+	renditionNames := map[string]bool{}
+	for _, r := range c.ImageCompressor.Renditions {
+		if strings.TrimSpace(r.Name) == "" || renditionNames[r.Name] {
+			errs = append(errs, fmt.Errorf("IMAGE_RENDITIONS names must be unique and not empty, got %q", r.Name))
+		}
+		renditionNames[r.Name] = true
+		positive("IMAGE_RENDITIONS "+r.Name+" size", r.SizeInPixels)
+		oneOf("IMAGE_RENDITIONS "+r.Name+" format", r.Format, FormatJPEG, FormatPNG, FormatWebP, FormatAVIF)
+		// 0 falls back to COMPRESSION_QUALITY
+		if r.Quality < 0 || r.Quality > 100 {
+			errs = append(errs, fmt.Errorf("IMAGE_RENDITIONS %s quality must be between 1 and 100, got %d", r.Name, r.Quality))
+		}
+	}
+
+	required("JWT_SIGNING_KEY_FILE", c.JWT.SigningKeyFile)
+
+	oneOf("NEARBY_SEARCH_STRATEGY", c.NearbySearch.Strategy, "h3", "postgis")
+	positive("NEARBY_SEARCH_RADIUS_IN_METERS", c.NearbySearch.RadiusInMeters)
+	if c.NearbySearch.H3StartResolution < 0 || c.NearbySearch.H3StartResolution > 15 {
+		errs = append(errs, fmt.Errorf("H3_START_RESOLUTION must be between 0 and 15, got %d", c.NearbySearch.H3StartResolution))
+	}
+
+	for name, value := range map[string]float64{
+		"DELIVERY_BASE_FEE":   c.Pricing.DeliveryBaseFee,
+		"DELIVERY_FEE_PER_KM": c.Pricing.DeliveryFeePerKm,
+		"SERVICE_FEE":         c.Pricing.ServiceFee,
+		"TAX_RATE_PERCENT":    c.Pricing.TaxRatePercent,
+	} {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %g", name, value))
+		}
+	}
+
+	oneOf("TRAVEL_TIME_MODEL", c.TravelTime.Model, "speed_profile", "road_graph")
+	if strings.EqualFold(c.TravelTime.Model, "road_graph") {
+		required("ROAD_GRAPH_FILE", c.TravelTime.RoadGraphFile)
+	}
+	oneOf("DELIVERY_VEHICLE_TYPE", string(c.TravelTime.VehicleType),
+		string(model.VehicleBike), string(model.VehicleMotorbike), string(model.VehicleCar))
+
+	if c.Jobs.Workers < 0 {
+		errs = append(errs, fmt.Errorf("JOB_WORKERS must not be negative, got %d", c.Jobs.Workers))
+	}
+	positive("JOB_POLL_INTERVAL_IN_MS", c.Jobs.PollIntervalInMs)
+	positive("JOB_MAX_ATTEMPTS", c.Jobs.MaxAttempts)
+	positive("JOB_BACKOFF_IN_SECONDS", c.Jobs.BackoffInSeconds)
+	positive("JOB_LOCK_TIMEOUT_IN_SECONDS", c.Jobs.LockTimeoutInSeconds)
+
+	return errors.Join(errs...)
+}
+
+// decodeFile unmarshals a YAML (.yaml, .yml) or JSON (.json) config file
+func decodeFile(path string, v any) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, v)
+	case ".json":
+		err = json.Unmarshal(content, v)
+	default:
+		return fmt.Errorf("unsupported config file format %q, use yaml or json", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("error decoding config file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv(FileEnv, "")
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_PORT", "5432")
+	t.Setenv("DB_DATABASE", "patty-wagon-dev")
+	t.Setenv("DB_USERNAME", "postgres")
+	t.Setenv("S3_ENDPOINT", "localhost:9000")
+	t.Setenv("S3_ACCESS_KEY_ID", "team-solid")
+	t.Setenv("S3_SECRET_ACCESS_KEY", "@team-solid")
+	t.Setenv("S3_BUCKET", "images")
+	t.Setenv("JWT_SIGNING_KEY_FILE", "keys/jwt-signing.pem")
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("environment", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("DB_MAX_OPEN_CONNS", "42")
+		t.Setenv("JWT_VERIFICATION_KEY_FILES", "keys/old-1.pem, ,keys/old-2.pem")
+		t.Setenv("TAX_RATE_PERCENT", "12.5")
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, "localhost", cfg.Database.Host)
+		assert.Equal(t, 42, cfg.Database.MaxOpenConns)
+		// defaults fill whatever is not set
+		assert.Equal(t, 10, cfg.Database.MaxIdleConns)
+		assert.Equal(t, []string{"keys/old-1.pem", "keys/old-2.pem"}, cfg.JWT.VerificationKeyFiles)
+		assert.Equal(t, 12.5, cfg.Pricing.TaxRatePercent)
+	})
+
+	t.Run("renditions", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("IMAGE_RENDITIONS", "thumbnail:150:jpeg, large:1080:WEBP:80")
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, []Rendition{
+			{Name: "thumbnail", SizeInPixels: 150, Format: FormatJPEG},
+			{Name: "large", SizeInPixels: 1080, Format: FormatWebP, Quality: 80},
+		}, cfg.ImageCompressor.Renditions)
+
+		t.Setenv("IMAGE_RENDITIONS", "thumbnail:150:gif,thumbnail:small:jpeg")
+		_, err = Load()
+		assert.ErrorContains(t, err, "IMAGE_RENDITIONS size must be an integer")
+
+		t.Setenv("IMAGE_RENDITIONS", "thumbnail:150:gif,thumbnail:480:jpeg")
+		_, err = Load()
+		assert.ErrorContains(t, err, "IMAGE_RENDITIONS thumbnail format must be one of")
+		assert.ErrorContains(t, err, "IMAGE_RENDITIONS names must be unique")
+	})
+
+	t.Run("environment overrides the file", func(t *testing.T) {
+		setRequiredEnv(t)
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := "database:\n  maxOpenConns: 7\n  maxIdleConns: 3\nnearbySearch:\n  strategy: postgis\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		t.Setenv(FileEnv, path)
+		t.Setenv("DB_MAX_OPEN_CONNS", "30")
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, 30, cfg.Database.MaxOpenConns)
+		assert.Equal(t, 3, cfg.Database.MaxIdleConns)
+		assert.Equal(t, "postgis", cfg.NearbySearch.Strategy)
+	})
+
+	t.Run("every problem is reported at once", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("DB_HOST", "")
+		t.Setenv("S3_BUCKET", "")
+		t.Setenv("DB_MAX_IDLE_CONNS", "ten")
+		t.Setenv("COMPRESSION_QUALITY", "150")
+		t.Setenv("TRAVEL_TIME_MODEL", "road_graph")
+
+		_, err := Load()
+
+		require.Error(t, err)
+		for _, want := range []string{
+			"DB_HOST is required",
+			"S3_BUCKET is required",
+			`DB_MAX_IDLE_CONNS must be an integer, got "ten"`,
+			"COMPRESSION_QUALITY must be between 1 and 100",
+			"ROAD_GRAPH_FILE is required",
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv(FileEnv, filepath.Join(t.TempDir(), "missing.yaml"))
+
+		_, err := Load()
+
+		assert.Error(t, err)
+	})
+}
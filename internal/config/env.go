@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envReader overrides settings with the environment variables that are set, collecting every parse error
+type envReader struct {
+	errs []error
+}
+
+func (r *envReader) string(key string, dst *string) {
+	if val, ok := os.LookupEnv(key); ok && val != "" {
+		*dst = val
+	}
+}
+
+func (r *envReader) int(key string, dst *int) {
+	val, ok := os.LookupEnv(key)
+	if !ok || val == "" {
+		return
+	}
+	parsed, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be an integer, got %q", key, val))
+		return
+	}
+	*dst = parsed
+}
+
+func (r *envReader) float(key string, dst *float64) {
+	val, ok := os.LookupEnv(key)
+	if !ok || val == "" {
+		return
+	}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a number, got %q", key, val))
+		return
+	}
+	*dst = parsed
+}
+
+// list splits a comma separated variable, dropping empty entries
+func (r *envReader) list(key string, dst *[]string) {
+	val, ok := os.LookupEnv(key)
+	if !ok || val == "" {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+// renditions parses a comma separated list of name:size:format[:quality] renditions
+func (r *envReader) renditions(key string, dst *[]Rendition) {
+	var entries []string
+	r.list(key, &entries)
+	if entries == nil {
+		return
+	}
+
+	renditions := make([]Rendition, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 && len(parts) != 4 {
+			r.errs = append(r.errs, fmt.Errorf("%s entries must be name:size:format[:quality], got %q", key, entry))
+			return
+		}
+
+		rendition := Rendition{Name: strings.TrimSpace(parts[0]), Format: strings.ToLower(strings.TrimSpace(parts[2]))}
+		var err error
+		if rendition.SizeInPixels, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s size must be an integer, got %q", key, entry))
+			return
+		}
+		if len(parts) == 4 {
+			if rendition.Quality, err = strconv.Atoi(strings.TrimSpace(parts[3])); err != nil {
+				r.errs = append(r.errs, fmt.Errorf("%s quality must be an integer, got %q", key, entry))
+				return
+			}
+		}
+		renditions = append(renditions, rendition)
+	}
+	*dst = renditions
+}
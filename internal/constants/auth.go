@@ -1,7 +1,28 @@
 package constants
 
+import (
+	"errors"
+	"time"
+)
+
 type ctxKey string
 
 const (
-	UserIDCtxKey ctxKey = "userID"
+	UserIDCtxKey  ctxKey = "userID"
+	TokenIDCtxKey ctxKey = "tokenID"
+)
+
+const (
+	RoleAdmin int16 = 0
+	RoleUser  int16 = 1
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrTokenRevoked        = errors.New("token has been revoked")
 )
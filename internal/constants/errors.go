@@ -12,12 +12,19 @@ var (
 	ErrInvalidFileType = errors.New("invalid file type")
 	ErrMaximumFileSize = errors.New("size exceeds the maximum allowed file size")
 	ErrFileNotFound    = errors.New("file not found")
-	ErrInternalServer  = errors.New("internal server error")
+
+	ErrFileNotUploaded         = errors.New("file has not been uploaded yet")
+	ErrFileAlreadyCompleted    = errors.New("file upload is already completed")
+	ErrDirectUploadUnsupported = errors.New("storage backend does not support direct uploads")
+	ErrInvalidUploadSignature  = errors.New("upload url is invalid or expired")
+
+	ErrInternalServer = errors.New("internal server error")
 
 	ErrFileIDNotValid                 = errors.New("fileId is not valid / exists")
 	ErrDuplicateSKU                   = errors.New("duplicate sku")
 	ErrProductNotFound                = errors.New("productId is not found")
 	ErrInvalidRequest                 = errors.New("invalid request")
+	ErrInvalidCursor                  = errors.New("invalid cursor")
 	ErrInvalidFileID                  = errors.New("invalid file ID")
 	ErrEmailOrPhoneMustBeProvided     = errors.New("either email or phone must be provided")
 	ErrCannotUpdateEmailAndPhone      = errors.New("cannot update both email and phone simultaneously")
@@ -26,4 +33,6 @@ var (
 	ErrNotEqualAvailableSellersInCart = errors.New("not equal to the available sellers in the cart")
 	ErrPurchaseNotFound               = errors.New("purchase not found")
 	ErrMerchantNotFound               = errors.New("merchant not found")
+	ErrMerchantHasOrders              = errors.New("merchant has placed orders")
+	ErrInvalidModifierGroup           = errors.New("invalid modifier group")
 )
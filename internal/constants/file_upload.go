@@ -1,7 +1,12 @@
 package constants
 
+import "time"
+
+// UploadURLExpiry is how long a presigned upload url stays valid
+const UploadURLExpiry = 15 * time.Minute
+
 var (
-	AllowedExtensions []string = []string{".jpg", ".jpeg", ".png"}
+	AllowedExtensions []string = []string{".jpg", ".jpeg", ".png", ".webp"}
 
 	MaxUploadSizeInBytes int64 = 102400
 )
@@ -2,8 +2,30 @@ package constants
 
 import "errors"
 
+const (
+	// MaxMerchantDistanceInMeters is the farthest a merchant may be from the user location
+	MaxMerchantDistanceInMeters float64 = 3000
+	// MaxRouteLengthInMeters is the longest route a courier may ride from the starting merchant to the user
+	MaxRouteLengthInMeters float64 = 10000
+	// DeliverySpeedInKmPerHour is the assumed courier speed used for delivery estimation
+	DeliverySpeedInKmPerHour float64 = 40
+)
+
 var (
 	ErrInvalidStartingPoint = errors.New("invalid starting point")
 	ErrMerchantTooFar       = errors.New("merchant is too far")
+	ErrMerchantClosed       = errors.New("merchant is closed")
 	ErrItemNotFound         = errors.New("item is not found")
+	ErrItemHasOrders        = errors.New("item has placed orders")
+	ErrItemUnavailable      = errors.New("item is unavailable")
+	ErrItemOutOfStock       = errors.New("item is out of stock")
+	ErrStockNotTracked      = errors.New("item stock is not tracked")
+	ErrInvalidModifiers     = errors.New("invalid modifier selection")
+
+	ErrPromoCodeNotFound     = errors.New("promo code is not found")
+	ErrPromoCodeExpired      = errors.New("promo code is not active")
+	ErrPromoCodeMinSpend     = errors.New("order does not reach the promo code minimum spend")
+	ErrPromoCodeLimitReached = errors.New("promo code usage limit reached")
+	ErrEstimationNotFound    = errors.New("calculated estimate is not found")
+	ErrOrderAlreadyPlaced    = errors.New("order has already been placed for this estimate")
 )
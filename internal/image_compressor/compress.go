@@ -1,68 +1,73 @@
 package imagecompressor
 
 import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/model"
 	"PattyWagon/observability"
 	"bytes"
 	"context"
 	"fmt"
 	"image"
 	"image/jpeg"
-	_ "image/jpeg"
+	"image/png"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/gen2brain/avif"
+	"github.com/gen2brain/webp"
 	"github.com/nfnt/resize"
 	_ "golang.org/x/image/webp" // For decoding
 )
 
-var (
-	MaxConcurrentCompress, _ = strconv.Atoi(os.Getenv("MAX_CONCURRENT_COMPRESS"))
-	CompressionQuality, _    = strconv.Atoi(os.Getenv("COMPRESSION_QUALITY"))
-)
-
 type ImageCompressor struct {
 	semaphore  chan struct{}
 	quality    int
+	renditions []config.Rendition
 	bufferPool sync.Pool
 }
 
-func New(
-	maxConcurrentCompress int,
-	quality int,
-) *ImageCompressor {
+// New builds a compressor producing the configured renditions, or the default ones when none are configured
+func New(cfg config.ImageCompressor) *ImageCompressor {
+	renditions := cfg.Renditions
+	if len(renditions) == 0 {
+		renditions = config.Default().ImageCompressor.Renditions
+	}
+
 	return &ImageCompressor{
-		semaphore: make(chan struct{}, maxConcurrentCompress),
-		quality:   quality,
+		semaphore:  make(chan struct{}, cfg.MaxConcurrentCompress),
+		quality:    cfg.Quality,
+		renditions: renditions,
 		bufferPool: sync.Pool{New: func() any {
 			return make([]byte, 0, 64*1024)
 		}},
 	}
 }
 
-// func (cmp *ImageCompressor) compressPNG(ctx context.Context, img image.Image) ([]byte, error) {
-// 	var buf bytes.Buffer
-// 	encoder := png.Encoder{
-// 		CompressionLevel: png.BestCompression,
-// 	}
+func (cmp *ImageCompressor) compressPNG(ctx context.Context, img image.Image) ([]byte, error) {
+	_, span := observability.Tracer.Start(ctx, "image_compressor.compress_png")
+	defer span.End()
+	var buf bytes.Buffer
+	encoder := png.Encoder{
+		CompressionLevel: png.BestCompression,
+	}
 
-// 	if err := encoder.Encode(&buf, img); err != nil {
-// 		return nil, fmt.Errorf("error encoding image: %w", err)
-// 	}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("error encoding image: %w", err)
+	}
 
-// 	return buf.Bytes(), nil
-// }
+	return buf.Bytes(), nil
+}
 
-func (cmp *ImageCompressor) compressJPEG(ctx context.Context, img image.Image) ([]byte, error) {
+func (cmp *ImageCompressor) compressJPEG(ctx context.Context, img image.Image, quality int) ([]byte, error) {
 	_, span := observability.Tracer.Start(ctx, "image_compressor.compress_jpg")
 	defer span.End()
 	var buf bytes.Buffer
 
 	err := jpeg.Encode(&buf, img, &jpeg.Options{
-		Quality: cmp.quality,
+		Quality: quality,
 	})
 
 	if err != nil {
@@ -72,6 +77,31 @@ func (cmp *ImageCompressor) compressJPEG(ctx context.Context, img image.Image) (
 	return buf.Bytes(), nil
 }
 
+func (cmp *ImageCompressor) compressWebP(ctx context.Context, img image.Image, quality int) ([]byte, error) {
+	_, span := observability.Tracer.Start(ctx, "image_compressor.compress_webp")
+	defer span.End()
+	var buf bytes.Buffer
+
+	if err := webp.Encode(&buf, img, webp.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("error encoding image: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (cmp *ImageCompressor) compressAVIF(ctx context.Context, img image.Image, quality int) ([]byte, error) {
+	_, span := observability.Tracer.Start(ctx, "image_compressor.compress_avif")
+	defer span.End()
+	var buf bytes.Buffer
+
+	// the fastest speed, encoding avif is otherwise several seconds per image
+	if err := avif.Encode(&buf, img, avif.Options{Quality: quality, Speed: 10}); err != nil {
+		return nil, fmt.Errorf("error encoding image: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
 func (cmp *ImageCompressor) thumbnail(ctx context.Context, img image.Image, sizeInPixels int) image.Image {
 	_, span := observability.Tracer.Start(ctx, "image_compressor.thumbnail")
 	defer span.End()
@@ -80,7 +110,9 @@ func (cmp *ImageCompressor) thumbnail(ctx context.Context, img image.Image, size
 	return thumbnail
 }
 
-func (cmp *ImageCompressor) Compress(ctx context.Context, src string) (string, error) {
+// Compress resizes the image at src to every rendition, each written next to src as <name>_<rendition>.<format>.
+// Images are never enlarged, a rendition bigger than the image keeps its size.
+func (cmp *ImageCompressor) Compress(ctx context.Context, src string) (map[string]model.Rendition, error) {
 	ctx, span := observability.Tracer.Start(ctx, "image_compressor.compress")
 	defer span.End()
 
@@ -88,44 +120,69 @@ func (cmp *ImageCompressor) Compress(ctx context.Context, src string) (string, e
 	case cmp.semaphore <- struct{}{}:
 		defer func() { <-cmp.semaphore }()
 	case <-time.After(30 * time.Second):
-		return "", fmt.Errorf("compression queue timeout")
+		return nil, fmt.Errorf("compression queue timeout")
 	}
 
-	img, format, err := cmp.loadImage(ctx, src)
+	img, _, err := cmp.loadImage(ctx, src)
 	if err != nil {
-		return "", fmt.Errorf("error decoding file: %w", err)
+		return nil, fmt.Errorf("error decoding file: %w", err)
 	}
 
-	thumbnail := cmp.thumbnail(ctx, img, 150)
-	var result []byte
-	switch format {
-	case "jpeg":
-		result, err = cmp.compressJPEG(ctx, thumbnail)
-	case "png":
-		result, err = cmp.compressJPEG(ctx, thumbnail)
-	default:
-		return "", fmt.Errorf("unknown format: %s", format)
+	nameWithoutExt := strings.TrimSuffix(src, filepath.Ext(src))
+	results := make(map[string]model.Rendition, len(cmp.renditions))
+	for _, r := range cmp.renditions {
+		rendition, err := cmp.render(ctx, img, r, fmt.Sprintf("%s_%s.%s", nameWithoutExt, r.Name, r.Format))
+		if err != nil {
+			for _, written := range results {
+				os.Remove(written.Path)
+			}
+			return nil, fmt.Errorf("error in compressing image to %s: %w", r.Name, err)
+		}
+		results[r.Name] = rendition
 	}
 
-	if err != nil {
-		return "", fmt.Errorf("error in compressing image: %w", err)
-	}
+	return results, nil
+}
 
-	ext := filepath.Ext(src)
-	nameWithoutExt := strings.TrimSuffix(src, ext)
-	resultFilename := fmt.Sprintf("%s_compressed.%s", nameWithoutExt, "jpeg")
+func (cmp *ImageCompressor) render(ctx context.Context, img image.Image, r config.Rendition, dst string) (model.Rendition, error) {
+	quality := r.Quality
+	if quality == 0 {
+		quality = cmp.quality
+	}
 
-	resultFile, err := os.Create(resultFilename)
+	resized := cmp.thumbnail(ctx, img, r.SizeInPixels)
+	var (
+		result []byte
+		err    error
+	)
+	switch strings.ToLower(r.Format) {
+	case config.FormatJPEG:
+		result, err = cmp.compressJPEG(ctx, resized, quality)
+	case config.FormatPNG:
+		result, err = cmp.compressPNG(ctx, resized)
+	case config.FormatWebP:
+		result, err = cmp.compressWebP(ctx, resized, quality)
+	case config.FormatAVIF:
+		result, err = cmp.compressAVIF(ctx, resized, quality)
+	default:
+		return model.Rendition{}, fmt.Errorf("unknown format: %s", r.Format)
+	}
 	if err != nil {
-		return "", fmt.Errorf("error creating file: %w", err)
+		return model.Rendition{}, err
 	}
-	defer resultFile.Close()
 
-	_, err = resultFile.Write(result)
-	if err != nil {
-		return "", fmt.Errorf("error writing data: %w", err)
+	if err := os.WriteFile(dst, result, 0644); err != nil {
+		return model.Rendition{}, fmt.Errorf("error writing data: %w", err)
 	}
-	return resultFilename, nil
+
+	bounds := resized.Bounds()
+	return model.Rendition{
+		Format:      strings.ToLower(r.Format),
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		SizeInBytes: int64(len(result)),
+		Path:        dst,
+	}, nil
 }
 
 func (cmp *ImageCompressor) loadImage(ctx context.Context, src string) (image.Image, string, error) {
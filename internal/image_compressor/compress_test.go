@@ -1,46 +1,90 @@
 package imagecompressor
 
 import (
+	"PattyWagon/internal/config"
 	"PattyWagon/internal/utils"
 	"context"
-	"fmt"
+	"image"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// copyToTemp copies a testdata image to a temporary directory, the renditions are written next to it
+func copyToTemp(t *testing.T, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, content, 0o644))
+	return path
+}
+
 func TestCompress(t *testing.T) {
-	testdataDir := "testdata"
-	compressor := New(5, 50)
-
-	// t.Run("PNG", func(t *testing.T) {
-	// 	input := fmt.Sprintf("%s/sample-png.png", testdataDir)
-	// 	result, err := compressor.Compress(context.TODO(), input)
-	// 	assert.Nil(t, err)
-	// 	assert.NotEmpty(t, result)
-
-	// 	originalSize, _ := utils.GetFileSizeInBytes(input)
-	// 	thumbnailSize, _ := utils.GetFileSizeInBytes(result)
-	// 	ratio := 100 * (float64(thumbnailSize) / float64(originalSize))
-	// 	fmt.Printf("original: %d | thumbnail: %d (%.2f %%)\n", originalSize, thumbnailSize, ratio)
-	// })
-
-	t.Run("JPEG", func(t *testing.T) {
-		input := fmt.Sprintf("%s/sample.jpeg", testdataDir)
-		result, err := compressor.Compress(context.TODO(), input)
-		assert.Nil(t, err)
-		assert.NotEmpty(t, result)
-
-		originalSize, _ := utils.GetFileSizeInBytes(input)
-		thumbnailSize, _ := utils.GetFileSizeInBytes(result)
-		ratio := 100 * (float64(thumbnailSize) / float64(originalSize))
-		fmt.Printf("original: %d | thumbnail: %d (%.2f %%) \n", originalSize, thumbnailSize, ratio)
+	compressor := New(config.ImageCompressor{
+		MaxConcurrentCompress: 5,
+		Quality:               50,
+		Renditions: []config.Rendition{
+			{Name: "thumbnail", SizeInPixels: 150, Format: config.FormatJPEG},
+			{Name: "medium", SizeInPixels: 480, Format: config.FormatWebP, Quality: 75},
+			{Name: "large", SizeInPixels: 1080, Format: config.FormatPNG},
+		},
 	})
 
-	// t.Run("JPG", func(t *testing.T) {
-	// 	input := fmt.Sprintf("%s/sample.jpg", testdataDir)
-	// 	result, err := compressor.Compress(context.TODO(), input)
-	// 	assert.Nil(t, err)
-	// 	assert.NotEmpty(t, result)
-	// })
+	for _, name := range []string{"sample.jpeg", "sample-png.png"} {
+		t.Run(name, func(t *testing.T) {
+			input := copyToTemp(t, name)
+			original, _, err := compressor.loadImage(context.TODO(), input)
+			require.NoError(t, err)
+
+			renditions, err := compressor.Compress(context.TODO(), input)
+
+			require.NoError(t, err)
+			require.Len(t, renditions, 3)
+			for name, want := range map[string]struct {
+				format  string
+				maxSide int
+			}{
+				"thumbnail": {"jpeg", 150},
+				"medium":    {"webp", 480},
+				"large":     {"png", 1080},
+			} {
+				rendition := renditions[name]
+				assert.Equal(t, want.format, rendition.Format)
+				assert.LessOrEqual(t, max(rendition.Width, rendition.Height), want.maxSide)
+				// images are never enlarged
+				assert.LessOrEqual(t, rendition.Width, original.Bounds().Dx())
+
+				file, err := os.Open(rendition.Path)
+				require.NoError(t, err)
+				cfg, format, err := image.DecodeConfig(file)
+				file.Close()
+				require.NoError(t, err)
+				assert.Equal(t, want.format, format)
+				assert.Equal(t, rendition.Width, cfg.Width)
+				assert.Equal(t, rendition.Height, cfg.Height)
+			}
+
+			originalSize, _ := utils.GetFileSizeInBytes(input)
+			ratio := 100 * (float64(renditions["thumbnail"].SizeInBytes) / float64(originalSize))
+			t.Logf("original: %d | thumbnail: %d (%.2f %%)", originalSize, renditions["thumbnail"].SizeInBytes, ratio)
+		})
+	}
+
+	t.Run("AVIF", func(t *testing.T) {
+		compressor := New(config.ImageCompressor{
+			MaxConcurrentCompress: 1,
+			Quality:               50,
+			Renditions:            []config.Rendition{{Name: "small", SizeInPixels: 150, Format: config.FormatAVIF}},
+		})
+
+		renditions, err := compressor.Compress(context.TODO(), copyToTemp(t, "sample.jpeg"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "avif", renditions["small"].Format)
+		assert.NotZero(t, renditions["small"].SizeInBytes)
+	})
 }
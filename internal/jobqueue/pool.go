@@ -0,0 +1,161 @@
+package jobqueue
+
+import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/model"
+	"PattyWagon/logger"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxBackoff caps the delay between retries however many attempts a job has used
+const maxBackoff = time.Hour
+
+// Queue is the durable store jobs are claimed from and settled in
+type Queue interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	ClaimJob(ctx context.Context, types []string, lockTimeout time.Duration) (model.Job, bool, error)
+	CompleteJob(ctx context.Context, id int64) error
+	RetryJob(ctx context.Context, id int64, delay time.Duration, lastError string) error
+	BuryJob(ctx context.Context, id int64, lastError string) error
+}
+
+// Handler runs the jobs of one type. Dead, if set, is called with the error of the last attempt when a job
+// is moved to the dead letter state, in the same transaction, so a failing Dead leaves the job to be retried.
+type Handler struct {
+	Handle func(ctx context.Context, job model.Job) error
+	Dead   func(ctx context.Context, job model.Job, err error) error
+}
+
+// permanentError marks a failure retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error a handler returns to dead letter the job right away instead of retrying it
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Pool runs a fixed number of workers, each claiming one job at a time from the queue
+type Pool struct {
+	queue        Queue
+	handlers     map[string]Handler
+	types        []string
+	workers      int
+	pollInterval time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	lockTimeout  time.Duration
+	wg           sync.WaitGroup
+}
+
+func New(cfg config.Jobs, queue Queue, handlers map[string]Handler) *Pool {
+	types := make([]string, 0, len(handlers))
+	for jobType := range handlers {
+		types = append(types, jobType)
+	}
+	slices.Sort(types)
+
+	return &Pool{
+		queue:        queue,
+		handlers:     handlers,
+		types:        types,
+		workers:      cfg.Workers,
+		pollInterval: time.Duration(cfg.PollIntervalInMs) * time.Millisecond,
+		maxAttempts:  cfg.MaxAttempts,
+		backoff:      time.Duration(cfg.BackoffInSeconds) * time.Second,
+		lockTimeout:  time.Duration(cfg.LockTimeoutInSeconds) * time.Second,
+	}
+}
+
+// Start launches the workers, they stop once ctx is cancelled
+func (p *Pool) Start(ctx context.Context) {
+	for range p.workers {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.work(ctx)
+		}()
+	}
+}
+
+// Wait blocks until every worker finished its current job after ctx was cancelled
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+// work keeps claiming jobs while there are due ones and sleeps for the poll interval once the queue is drained
+func (p *Pool) work(ctx context.Context) {
+	log := logger.GetLoggerFromContext(ctx)
+
+	for {
+		claimed, err := p.RunNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("job queue")
+		}
+		if claimed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+// RunNext claims and runs a single due job, reporting whether there was one
+func (p *Pool) RunNext(ctx context.Context) (bool, error) {
+	job, ok, err := p.queue.ClaimJob(ctx, p.types, p.lockTimeout)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	// a claimed job is finished and settled even when the pool is shutting down meanwhile
+	ctx = context.WithoutCancel(ctx)
+	handler := p.handlers[job.Type]
+	runErr := handler.Handle(ctx, job)
+	if runErr == nil {
+		return true, p.queue.CompleteJob(ctx, job.ID)
+	}
+
+	var permanent permanentError
+	if job.Attempts < p.maxAttempts && !errors.As(runErr, &permanent) {
+		return true, p.queue.RetryJob(ctx, job.ID, Backoff(p.backoff, job.Attempts), runErr.Error())
+	}
+
+	err = p.queue.WithTx(ctx, func(ctx context.Context) error {
+		if err := p.queue.BuryJob(ctx, job.ID, runErr.Error()); err != nil {
+			return err
+		}
+		if handler.Dead == nil {
+			return nil
+		}
+		if err := handler.Dead(ctx, job, runErr); err != nil {
+			return fmt.Errorf("error handling dead job %d: %w", job.ID, err)
+		}
+		return nil
+	})
+	return true, err
+}
+
+// Backoff is the delay before retrying a job that failed its attempts-th run, doubling from base up to an hour
+func Backoff(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,166 @@
+package jobqueue
+
+import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQueue holds jobs in memory, a claimed job is due again once it is retried
+type fakeQueue struct {
+	jobs map[int64]*model.Job
+}
+
+// WithTx restores every job when fn fails
+func (q *fakeQueue) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	snapshot := make(map[int64]model.Job, len(q.jobs))
+	for id, job := range q.jobs {
+		snapshot[id] = *job
+	}
+
+	err := fn(ctx)
+	if err != nil {
+		for id, job := range snapshot {
+			*q.jobs[id] = job
+		}
+	}
+	return err
+}
+
+func (q *fakeQueue) ClaimJob(ctx context.Context, types []string, lockTimeout time.Duration) (model.Job, bool, error) {
+	for _, job := range q.jobs {
+		if job.Status == model.JobStatusQueued {
+			job.Status = model.JobStatusRunning
+			job.Attempts++
+			return *job, true, nil
+		}
+	}
+	return model.Job{}, false, nil
+}
+
+func (q *fakeQueue) CompleteJob(ctx context.Context, id int64) error {
+	q.jobs[id].Status = model.JobStatusDone
+	return nil
+}
+
+func (q *fakeQueue) RetryJob(ctx context.Context, id int64, delay time.Duration, lastError string) error {
+	q.jobs[id].Status = model.JobStatusQueued
+	q.jobs[id].RunAt = time.Now().Add(delay)
+	q.jobs[id].LastError = lastError
+	return nil
+}
+
+func (q *fakeQueue) BuryJob(ctx context.Context, id int64, lastError string) error {
+	q.jobs[id].Status = model.JobStatusDead
+	q.jobs[id].LastError = lastError
+	return nil
+}
+
+func newFakeQueue() *fakeQueue {
+	return &fakeQueue{jobs: map[int64]*model.Job{
+		1: {ID: 1, Type: "test", Status: model.JobStatusQueued},
+	}}
+}
+
+func testConfig() config.Jobs {
+	cfg := config.Default().Jobs
+	cfg.MaxAttempts = 3
+	return cfg
+}
+
+func TestPoolRunNext(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("completes a job", func(t *testing.T) {
+		queue := newFakeQueue()
+		pool := New(testConfig(), queue, map[string]Handler{
+			"test": {Handle: func(ctx context.Context, job model.Job) error { return nil }},
+		})
+
+		claimed, err := pool.RunNext(ctx)
+
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		assert.Equal(t, model.JobStatusDone, queue.jobs[1].Status)
+
+		claimed, err = pool.RunNext(ctx)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+	})
+
+	t.Run("retries with backoff then dead letters", func(t *testing.T) {
+		queue := newFakeQueue()
+		var deadErr error
+		pool := New(testConfig(), queue, map[string]Handler{
+			"test": {
+				Handle: func(ctx context.Context, job model.Job) error { return errors.New("boom") },
+				Dead: func(ctx context.Context, job model.Job, err error) error {
+					deadErr = err
+					return nil
+				},
+			},
+		})
+
+		before := time.Now()
+		_, err := pool.RunNext(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, model.JobStatusQueued, queue.jobs[1].Status)
+		assert.Equal(t, "boom", queue.jobs[1].LastError)
+		assert.WithinDuration(t, before.Add(5*time.Second), queue.jobs[1].RunAt, time.Second)
+		assert.Nil(t, deadErr)
+
+		for range 2 {
+			_, err = pool.RunNext(ctx)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, model.JobStatusDead, queue.jobs[1].Status)
+		assert.Equal(t, 3, queue.jobs[1].Attempts)
+		assert.EqualError(t, deadErr, "boom")
+	})
+
+	t.Run("a failing dead letter handler leaves the job running", func(t *testing.T) {
+		queue := newFakeQueue()
+		pool := New(testConfig(), queue, map[string]Handler{
+			"test": {
+				Handle: func(ctx context.Context, job model.Job) error { return Permanent(errors.New("bad payload")) },
+				Dead:   func(ctx context.Context, job model.Job, err error) error { return errors.New("database down") },
+			},
+		})
+
+		_, err := pool.RunNext(ctx)
+
+		assert.ErrorContains(t, err, "database down")
+		// claimed again once its lock times out
+		assert.Equal(t, model.JobStatusRunning, queue.jobs[1].Status)
+		assert.Empty(t, queue.jobs[1].LastError)
+	})
+
+	t.Run("permanent failures are not retried", func(t *testing.T) {
+		queue := newFakeQueue()
+		pool := New(testConfig(), queue, map[string]Handler{
+			"test": {Handle: func(ctx context.Context, job model.Job) error { return Permanent(errors.New("bad payload")) }},
+		})
+
+		_, err := pool.RunNext(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, model.JobStatusDead, queue.jobs[1].Status)
+		assert.Equal(t, 1, queue.jobs[1].Attempts)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	base := 5 * time.Second
+
+	assert.Equal(t, 5*time.Second, Backoff(base, 1))
+	assert.Equal(t, 10*time.Second, Backoff(base, 2))
+	assert.Equal(t, 40*time.Second, Backoff(base, 4))
+	assert.Equal(t, time.Hour, Backoff(base, 40))
+}
@@ -3,6 +3,7 @@ package location
 import (
 	"PattyWagon/internal/model"
 	"context"
+	"math"
 
 	"github.com/uber/h3-go/v4"
 )
@@ -27,18 +28,6 @@ func (s *Service) GetAllCellIDs(ctx context.Context, location model.Location) ([
 	return result, nil
 }
 
-func (s *Service) FindCellIDByResolution(ctx context.Context, location model.Location, resolution int) (model.Cell, error) {
-	latLng := h3.NewLatLng(location.Lat, location.Long)
-	cell, err := h3.LatLngToCell(latLng, resolution)
-	if err != nil {
-		return model.Cell{}, err
-	}
-	return model.Cell{
-		CellID:     int64(cell),
-		Resolution: cell.Resolution(),
-	}, nil
-}
-
 func (s *Service) FindKRingCellIDs(ctx context.Context, location model.Location, resolution, k int) ([]model.Cell, error) {
 	latLng := h3.NewLatLng(location.Lat, location.Long)
 	centerCell, err := h3.LatLngToCell(latLng, resolution)
@@ -61,3 +50,40 @@ func (s *Service) FindKRingCellIDs(ctx context.Context, location model.Location,
 
 	return result, nil
 }
+
+// kRingDistortionFactor shrinks KRingRadiusInMeters to absorb cells of the ring being smaller than the center cell
+const kRingDistortionFactor = 0.9
+
+// KRingRadiusInMeters returns how far from the location the k-ring around its cell reaches in every direction:
+// any point closer than that lies inside the ring.
+func (s *Service) KRingRadiusInMeters(ctx context.Context, location model.Location, resolution, k int) (float64, error) {
+	latLng := h3.NewLatLng(location.Lat, location.Long)
+	centerCell, err := h3.LatLngToCell(latLng, resolution)
+	if err != nil {
+		return 0, err
+	}
+
+	edges, err := centerCell.DirectedEdges()
+	if err != nil {
+		return 0, err
+	}
+
+	shortestEdge := math.MaxFloat64
+	for _, edge := range edges {
+		if !edge.IsValid() { // pentagons only have five edges
+			continue
+		}
+
+		length, err := h3.EdgeLengthM(edge)
+		if err != nil {
+			return 0, err
+		}
+		shortestEdge = min(shortestEdge, length)
+	}
+
+	// A point outside the ring lies in a cell at least k+1 steps away. Cell centers n steps apart are at least
+	// 1.5 * n edges apart, and both points may sit up to one edge away from the center of their cell.
+	radius := (1.5*float64(k+1) - 2) * shortestEdge * kRingDistortionFactor
+
+	return max(radius, 0), nil
+}
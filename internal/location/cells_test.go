@@ -3,6 +3,7 @@ package location
 import (
 	"PattyWagon/internal/model"
 	"context"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -83,68 +84,6 @@ func TestService_GetAllCellIDs(t *testing.T) {
 	})
 }
 
-func TestService_FindCellIDByResolution(t *testing.T) {
-	s := &Service{}
-	ctx := context.Background()
-
-	t.Run("valid resolution returns correct cell", func(t *testing.T) {
-		location := model.Location{Lat: -6.2088, Long: 106.8456}
-		resolution := 8
-
-		cell, err := s.FindCellIDByResolution(ctx, location, resolution)
-
-		require.NoError(t, err)
-		assert.Equal(t, resolution, cell.Resolution)
-		assert.NotZero(t, cell.CellID)
-	})
-
-	t.Run("resolution 0 returns largest cell", func(t *testing.T) {
-		location := model.Location{Lat: 0.0, Long: 0.0}
-
-		cell, err := s.FindCellIDByResolution(ctx, location, 0)
-
-		require.NoError(t, err)
-		assert.Equal(t, 0, cell.Resolution)
-		assert.NotZero(t, cell.CellID)
-	})
-
-	t.Run("resolution 15 returns smallest cell", func(t *testing.T) {
-		location := model.Location{Lat: 37.7749, Long: -122.4194}
-
-		cell, err := s.FindCellIDByResolution(ctx, location, 15)
-
-		require.NoError(t, err)
-		assert.Equal(t, 15, cell.Resolution)
-		assert.NotZero(t, cell.CellID)
-	})
-
-	t.Run("same location and resolution returns same cell", func(t *testing.T) {
-		location := model.Location{Lat: 40.7128, Long: -74.0060}
-		resolution := 10
-
-		cell1, err1 := s.FindCellIDByResolution(ctx, location, resolution)
-		cell2, err2 := s.FindCellIDByResolution(ctx, location, resolution)
-
-		require.NoError(t, err1)
-		require.NoError(t, err2)
-		assert.Equal(t, cell1.CellID, cell2.CellID)
-		assert.Equal(t, cell1.Resolution, cell2.Resolution)
-	})
-
-	t.Run("different resolutions return different cells", func(t *testing.T) {
-		location := model.Location{Lat: 51.5074, Long: -0.1278}
-
-		cell5, err5 := s.FindCellIDByResolution(ctx, location, 5)
-		cell10, err10 := s.FindCellIDByResolution(ctx, location, 10)
-
-		require.NoError(t, err5)
-		require.NoError(t, err10)
-		assert.NotEqual(t, cell5.CellID, cell10.CellID)
-		assert.Equal(t, 5, cell5.Resolution)
-		assert.Equal(t, 10, cell10.Resolution)
-	})
-}
-
 func TestService_FindKRingCellIDs(t *testing.T) {
 	s := &Service{}
 	ctx := context.Background()
@@ -349,3 +288,49 @@ func TestService_FindKRingCellIDs(t *testing.T) {
 		assert.Len(t, cells, expectedCount)
 	})
 }
+
+func TestService_KRingRadiusInMeters(t *testing.T) {
+	s := &Service{}
+	ctx := context.Background()
+	location := model.Location{Lat: -6.2088, Long: 106.8456}
+	resolution := 8
+
+	t.Run("radius grows with k", func(t *testing.T) {
+		prev := -1.0
+		for _, k := range []int{1, 2, 4, 8, 16} {
+			radius, err := s.KRingRadiusInMeters(ctx, location, resolution, k)
+			require.NoError(t, err)
+			assert.Greater(t, radius, prev)
+			prev = radius
+		}
+	})
+
+	t.Run("points within the radius lie inside the ring", func(t *testing.T) {
+		for _, k := range []int{1, 2, 4, 8} {
+			radius, err := s.KRingRadiusInMeters(ctx, location, resolution, k)
+			require.NoError(t, err)
+
+			ring, err := s.FindKRingCellIDs(ctx, location, resolution, k)
+			require.NoError(t, err)
+			inRing := make(map[int64]bool, len(ring))
+			for _, cell := range ring {
+				inRing[cell.CellID] = true
+			}
+
+			origin := h3.NewLatLng(location.Lat, location.Long)
+			for bearing := 0; bearing < 360; bearing += 5 {
+				// step slightly inside the radius along the bearing
+				dLat := radius * 0.999 * math.Cos(float64(bearing)*math.Pi/180) / 111320
+				dLong := radius * 0.999 * math.Sin(float64(bearing)*math.Pi/180) / (111320 * math.Cos(location.Lat*math.Pi/180))
+				point := h3.NewLatLng(location.Lat+dLat, location.Long+dLong)
+				if h3.GreatCircleDistanceM(origin, point) > radius {
+					continue
+				}
+
+				cell, err := h3.LatLngToCell(point, resolution)
+				require.NoError(t, err)
+				assert.True(t, inRing[int64(cell)], "k=%d bearing=%d lies outside the ring", k, bearing)
+			}
+		}
+	})
+}
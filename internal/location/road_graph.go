@@ -0,0 +1,186 @@
+package location
+
+import (
+	"PattyWagon/internal/model"
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// RoadGraph is a local road network, edges run both ways unless they are one way
+type RoadGraph struct {
+	Nodes []RoadNode `json:"nodes" yaml:"nodes"`
+	Edges []RoadEdge `json:"edges" yaml:"edges"`
+}
+
+type RoadNode struct {
+	ID   int64   `json:"id" yaml:"id"`
+	Lat  float64 `json:"lat" yaml:"lat"`
+	Long float64 `json:"long" yaml:"long"`
+}
+
+type RoadEdge struct {
+	From int64 `json:"from" yaml:"from"`
+	To   int64 `json:"to" yaml:"to"`
+	// DistanceInMeters defaults to the straight line between both nodes
+	DistanceInMeters float64 `json:"distanceInMeters" yaml:"distanceInMeters"`
+	// SpeedLimitInKmPerHour caps the vehicle speed on the road, zero leaves it uncapped
+	SpeedLimitInKmPerHour float64 `json:"speedLimitInKmPerHour" yaml:"speedLimitInKmPerHour"`
+	OneWay                bool    `json:"oneWay" yaml:"oneWay"`
+}
+
+// LoadRoadGraph reads the road graph from a YAML or JSON file, picked by its extension
+func LoadRoadGraph(path string) (RoadGraph, error) {
+	var graph RoadGraph
+	if err := decodeFile(path, &graph); err != nil {
+		return RoadGraph{}, err
+	}
+	if len(graph.Nodes) == 0 {
+		return RoadGraph{}, fmt.Errorf("road graph %s has no nodes", path)
+	}
+	return graph, nil
+}
+
+type roadArc struct {
+	to         int
+	distance   float64
+	speedLimit float64
+}
+
+// RoadGraphModel routes trips over the road graph with A*, riding the straight line to and from the nearest nodes.
+// Trips the graph cannot connect fall back to the straight line speed profile estimate.
+type RoadGraphModel struct {
+	profiles SpeedProfiles
+	nodes    []model.Location
+	arcs     [][]roadArc
+	fallback *SpeedProfileModel
+}
+
+func NewRoadGraphModel(graph RoadGraph, profiles SpeedProfiles) (*RoadGraphModel, error) {
+	m := &RoadGraphModel{
+		profiles: profiles,
+		nodes:    make([]model.Location, len(graph.Nodes)),
+		arcs:     make([][]roadArc, len(graph.Nodes)),
+		fallback: NewSpeedProfileModel(profiles),
+	}
+
+	index := make(map[int64]int, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		if _, exists := index[node.ID]; exists {
+			return nil, fmt.Errorf("road node %d is duplicated", node.ID)
+		}
+		index[node.ID] = i
+		m.nodes[i] = model.Location{Lat: node.Lat, Long: node.Long}
+	}
+
+	var errs []error
+	for _, edge := range graph.Edges {
+		from, fromOk := index[edge.From]
+		to, toOk := index[edge.To]
+		if !fromOk || !toOk {
+			errs = append(errs, fmt.Errorf("road edge %d-%d references an unknown node", edge.From, edge.To))
+			continue
+		}
+
+		// the A* heuristic rides the straight line, a shorter road would make it overestimate
+		distance := math.Max(edge.DistanceInMeters, distanceBetween(m.nodes[from], m.nodes[to]))
+		speedLimit := edge.SpeedLimitInKmPerHour * 1000 / 60
+		m.arcs[from] = append(m.arcs[from], roadArc{to: to, distance: distance, speedLimit: speedLimit})
+		if !edge.OneWay {
+			m.arcs[to] = append(m.arcs[to], roadArc{to: from, distance: distance, speedLimit: speedLimit})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return m, nil
+}
+
+func (m *RoadGraphModel) TravelTimeInMinutes(ctx context.Context, from, to model.Location, vehicle model.VehicleType, departAt time.Time) (float64, error) {
+	speed, err := m.profiles.speed(vehicle)
+	if err != nil {
+		return 0, err
+	}
+
+	source, target := m.nearestNode(from), m.nearestNode(to)
+	minutes, ok := m.shortestTime(source, target, speed)
+	if !ok {
+		return m.fallback.TravelTimeInMinutes(ctx, from, to, vehicle, departAt)
+	}
+
+	minutes += distanceBetween(from, m.nodes[source])/speed + distanceBetween(m.nodes[target], to)/speed
+	return minutes * m.profiles.congestion(departAt), nil
+}
+
+func (m *RoadGraphModel) nearestNode(location model.Location) int {
+	nearest, best := 0, math.Inf(1)
+	for i, node := range m.nodes {
+		if distance := distanceBetween(location, node); distance < best {
+			nearest, best = i, distance
+		}
+	}
+	return nearest
+}
+
+// shortestTime runs A* from source to target, speed is the free flowing vehicle speed in meters per minute
+func (m *RoadGraphModel) shortestTime(source, target int, speed float64) (float64, bool) {
+	minutes := make([]float64, len(m.nodes))
+	for i := range minutes {
+		minutes[i] = math.Inf(1)
+	}
+	minutes[source] = 0
+
+	heuristic := func(node int) float64 {
+		return distanceBetween(m.nodes[node], m.nodes[target]) / speed
+	}
+
+	open := &roadQueue{{node: source, priority: heuristic(source)}}
+	for open.Len() > 0 {
+		current := heap.Pop(open).(roadQueueItem)
+		if current.node == target {
+			return minutes[target], true
+		}
+		// a stale entry left behind when the node was reached faster
+		if current.priority > minutes[current.node]+heuristic(current.node) {
+			continue
+		}
+
+		for _, arc := range m.arcs[current.node] {
+			arcSpeed := speed
+			if arc.speedLimit > 0 && arc.speedLimit < arcSpeed {
+				arcSpeed = arc.speedLimit
+			}
+
+			candidate := minutes[current.node] + arc.distance/arcSpeed
+			if candidate < minutes[arc.to] {
+				minutes[arc.to] = candidate
+				heap.Push(open, roadQueueItem{node: arc.to, priority: candidate + heuristic(arc.to)})
+			}
+		}
+	}
+
+	return 0, false
+}
+
+type roadQueueItem struct {
+	node     int
+	priority float64
+}
+
+// roadQueue is a min-heap of nodes by their A* priority
+type roadQueue []roadQueueItem
+
+func (q roadQueue) Len() int           { return len(q) }
+func (q roadQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q roadQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *roadQueue) Push(x any)        { *q = append(*q, x.(roadQueueItem)) }
+func (q *roadQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
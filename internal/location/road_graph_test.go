@@ -0,0 +1,68 @@
+package location
+
+import (
+	"PattyWagon/internal/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoadGraphModel_TravelTimeInMinutes(t *testing.T) {
+	graph, err := LoadRoadGraph("testdata/road_graph.json")
+	require.NoError(t, err)
+	m, err := NewRoadGraphModel(graph, DefaultSpeedProfiles())
+	require.NoError(t, err)
+	ctx := context.Background()
+	departAt := time.Date(2025, 10, 13, 11, 0, 0, 0, time.UTC)
+
+	t.Run("takes the faster road over the shorter one", func(t *testing.T) {
+		// both ways around the block are ~2.2 km, through node 4 is capped at 6 km/h
+		minutes, err := m.TravelTimeInMinutes(ctx,
+			model.Location{Lat: -6.2000, Long: 106.8456},
+			model.Location{Lat: -6.2100, Long: 106.8556},
+			model.VehicleMotorbike, departAt)
+
+		require.NoError(t, err)
+		assert.InDelta(t, 3.33, minutes, 0.05)
+	})
+
+	t.Run("road is longer than the straight line", func(t *testing.T) {
+		straight, err := NewSpeedProfileModel(DefaultSpeedProfiles()).TravelTimeInMinutes(ctx,
+			model.Location{Lat: -6.2000, Long: 106.8456},
+			model.Location{Lat: -6.2100, Long: 106.8556},
+			model.VehicleMotorbike, departAt)
+		require.NoError(t, err)
+
+		road, err := m.TravelTimeInMinutes(ctx,
+			model.Location{Lat: -6.2000, Long: 106.8456},
+			model.Location{Lat: -6.2100, Long: 106.8556},
+			model.VehicleMotorbike, departAt)
+		require.NoError(t, err)
+
+		assert.Greater(t, road, straight)
+	})
+
+	t.Run("disconnected nodes fall back to the straight line", func(t *testing.T) {
+		from := model.Location{Lat: -6.2000, Long: 106.8456}
+		to := model.Location{Lat: -6.3000, Long: 106.9000}
+
+		road, err := m.TravelTimeInMinutes(ctx, from, to, model.VehicleMotorbike, departAt)
+		require.NoError(t, err)
+		straight, err := NewSpeedProfileModel(DefaultSpeedProfiles()).TravelTimeInMinutes(ctx, from, to, model.VehicleMotorbike, departAt)
+		require.NoError(t, err)
+
+		assert.InDelta(t, straight, road, 1e-9)
+	})
+
+	t.Run("edges must reference known nodes", func(t *testing.T) {
+		_, err := NewRoadGraphModel(RoadGraph{
+			Nodes: []RoadNode{{ID: 1}},
+			Edges: []RoadEdge{{From: 1, To: 9}},
+		}, DefaultSpeedProfiles())
+
+		assert.Error(t, err)
+	})
+}
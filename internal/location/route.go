@@ -0,0 +1,192 @@
+package location
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"PattyWagon/internal/utils"
+	"context"
+	"fmt"
+	"math"
+)
+
+// exactRouteMaxWaypoints is the most waypoints, besides the starting point, solved exactly.
+// Held-Karp runs in O(n^2 * 2^n), past this the planner falls back to nearest neighbour and 2-opt.
+const exactRouteMaxWaypoints = 10
+
+// PlanRoute finds the shortest order to visit every waypoint, leaving from waypoints[start] and ending at the destination.
+// Every waypoint has to lie within MaxMerchantDistanceInMeters of the destination and the whole route within
+// MaxRouteLengthInMeters, otherwise ErrMerchantTooFar is returned.
+func (s *Service) PlanRoute(ctx context.Context, start int, waypoints []model.Location, destination model.Location) (model.Route, error) {
+	if start < 0 || start >= len(waypoints) {
+		return model.Route{}, constants.ErrInvalidStartingPoint
+	}
+
+	for _, waypoint := range waypoints {
+		if distanceBetween(waypoint, destination) > constants.MaxMerchantDistanceInMeters {
+			return model.Route{}, constants.ErrMerchantTooFar
+		}
+	}
+
+	// the destination is the last node of the distance matrix
+	nodes := append(append(make([]model.Location, 0, len(waypoints)+1), waypoints...), destination)
+	distances := make([][]float64, len(nodes))
+	for i := range nodes {
+		distances[i] = make([]float64, len(nodes))
+		for j := range nodes {
+			distances[i][j] = distanceBetween(nodes[i], nodes[j])
+		}
+	}
+
+	rest := make([]int, 0, len(waypoints)-1)
+	for i := range waypoints {
+		if i != start {
+			rest = append(rest, i)
+		}
+	}
+
+	var order []int
+	if len(rest) <= exactRouteMaxWaypoints {
+		order = shortestPathExact(distances, start, rest, len(waypoints))
+	} else {
+		order = shortestPathHeuristic(distances, start, rest, len(waypoints))
+	}
+
+	route := buildRoute(nodes, distances, order)
+	if route.DistanceInMeters > constants.MaxRouteLengthInMeters {
+		return model.Route{}, fmt.Errorf("%w: route of %.0f meters is longer than %.0f meters",
+			constants.ErrMerchantTooFar, route.DistanceInMeters, constants.MaxRouteLengthInMeters)
+	}
+
+	return route, nil
+}
+
+// shortestPathExact solves the path with Held-Karp, returning the node order from start to end
+func shortestPathExact(distances [][]float64, start int, rest []int, end int) []int {
+	n := len(rest)
+	if n == 0 {
+		return []int{start, end}
+	}
+
+	full := 1<<n - 1
+	// cost[mask][i] is the shortest path from start through the rest in mask, finishing at rest[i]
+	cost := make([][]float64, full+1)
+	parent := make([][]int, full+1)
+	for mask := range cost {
+		cost[mask] = make([]float64, n)
+		parent[mask] = make([]int, n)
+		for i := range cost[mask] {
+			cost[mask][i] = math.Inf(1)
+			parent[mask][i] = -1
+		}
+	}
+	for i := range rest {
+		cost[1<<i][i] = distances[start][rest[i]]
+	}
+
+	for mask := 1; mask <= full; mask++ {
+		for last := range rest {
+			if mask&(1<<last) == 0 || math.IsInf(cost[mask][last], 1) {
+				continue
+			}
+			for next := range rest {
+				if mask&(1<<next) != 0 {
+					continue
+				}
+				nextMask := mask | 1<<next
+				candidate := cost[mask][last] + distances[rest[last]][rest[next]]
+				if candidate < cost[nextMask][next] {
+					cost[nextMask][next] = candidate
+					parent[nextMask][next] = last
+				}
+			}
+		}
+	}
+
+	last, best := 0, math.Inf(1)
+	for i := range rest {
+		if candidate := cost[full][i] + distances[rest[i]][end]; candidate < best {
+			last, best = i, candidate
+		}
+	}
+
+	order := make([]int, n+2)
+	order[0], order[n+1] = start, end
+	for mask, pos := full, n; last != -1; pos-- {
+		order[pos] = rest[last]
+		mask, last = mask&^(1<<last), parent[mask][last]
+	}
+	return order
+}
+
+// shortestPathHeuristic builds the path greedily by nearest neighbour, then untangles it with 2-opt keeping both ends fixed
+func shortestPathHeuristic(distances [][]float64, start int, rest []int, end int) []int {
+	order := make([]int, 0, len(rest)+2)
+	order = append(order, start)
+
+	visited := make(map[int]bool, len(rest))
+	for range rest {
+		current := order[len(order)-1]
+		nearest := -1
+		for _, candidate := range rest {
+			if visited[candidate] {
+				continue
+			}
+			if nearest == -1 || distances[current][candidate] < distances[current][nearest] {
+				nearest = candidate
+			}
+		}
+		visited[nearest] = true
+		order = append(order, nearest)
+	}
+	order = append(order, end)
+
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < len(order)-2; i++ {
+			for k := i + 1; k < len(order)-1; k++ {
+				before := distances[order[i-1]][order[i]] + distances[order[k]][order[k+1]]
+				after := distances[order[i-1]][order[k]] + distances[order[i]][order[k+1]]
+				// the epsilon keeps floating point noise from swapping forever
+				if after < before-1e-9 {
+					for l, r := i, k; l < r; l, r = l+1, r-1 {
+						order[l], order[r] = order[r], order[l]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+
+	return order
+}
+
+// buildRoute lays the stops out in the given order with the distance of every leg, timing them is left to the travel time model
+func buildRoute(nodes []model.Location, distances [][]float64, order []int) model.Route {
+	end := len(nodes) - 1
+	stops := make([]model.RouteStop, 0, len(order))
+
+	var total float64
+	for i, node := range order {
+		stop := model.RouteStop{
+			Waypoint: node,
+			Location: nodes[node],
+		}
+		if node == end {
+			stop.Waypoint = -1
+		}
+		if i > 0 {
+			stop.LegDistanceInMeters = distances[order[i-1]][node]
+			total += stop.LegDistanceInMeters
+		}
+		stops = append(stops, stop)
+	}
+
+	return model.Route{
+		Stops:            stops,
+		DistanceInMeters: total,
+	}
+}
+
+func distanceBetween(from, to model.Location) float64 {
+	return utils.CalculateDistance(from.Lat, from.Long, to.Lat, to.Long)
+}
@@ -0,0 +1,146 @@
+package location
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_PlanRoute(t *testing.T) {
+	s := &Service{}
+	ctx := context.Background()
+	user := model.Location{Lat: -6.2000, Long: 106.8456}
+
+	t.Run("starting point is kept and the closest merchants are visited last", func(t *testing.T) {
+		// merchants lie north of the user along the meridian, roughly 1.1 km apart
+		waypoints := []model.Location{
+			{Lat: -6.1900, Long: 106.8456},
+			{Lat: -6.1800, Long: 106.8456},
+			{Lat: -6.1950, Long: 106.8456},
+		}
+
+		route, err := s.PlanRoute(ctx, 1, waypoints, user)
+
+		require.NoError(t, err)
+		require.Len(t, route.Stops, 4)
+		assert.Equal(t, []int{1, 0, 2, -1}, waypointOrder(route))
+		assert.Zero(t, route.Stops[0].LegDistanceInMeters)
+		assert.InDelta(t, 2220, route.DistanceInMeters, 10)
+	})
+
+	t.Run("single merchant rides straight to the user", func(t *testing.T) {
+		route, err := s.PlanRoute(ctx, 0, []model.Location{{Lat: -6.1900, Long: 106.8456}}, user)
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, -1}, waypointOrder(route))
+		assert.InDelta(t, 1110, route.Stops[1].LegDistanceInMeters, 10)
+	})
+
+	t.Run("merchant outside the radius is too far", func(t *testing.T) {
+		waypoints := []model.Location{
+			{Lat: -6.1900, Long: 106.8456},
+			{Lat: -6.1500, Long: 106.8456},
+		}
+
+		_, err := s.PlanRoute(ctx, 0, waypoints, user)
+
+		assert.ErrorIs(t, err, constants.ErrMerchantTooFar)
+	})
+
+	t.Run("route longer than the limit is too far", func(t *testing.T) {
+		// every merchant is within the radius, zigzagging between them is not
+		waypoints := []model.Location{
+			{Lat: -6.1750, Long: 106.8456},
+			{Lat: -6.2250, Long: 106.8456},
+			{Lat: -6.2000, Long: 106.8706},
+			{Lat: -6.2000, Long: 106.8206},
+		}
+
+		_, err := s.PlanRoute(ctx, 0, waypoints, user)
+
+		assert.ErrorIs(t, err, constants.ErrMerchantTooFar)
+	})
+
+	t.Run("invalid starting point", func(t *testing.T) {
+		_, err := s.PlanRoute(ctx, 2, []model.Location{user}, user)
+
+		assert.ErrorIs(t, err, constants.ErrInvalidStartingPoint)
+	})
+}
+
+func TestShortestPath(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for range 20 {
+		nodes := make([]model.Location, 9)
+		for i := range nodes {
+			nodes[i] = model.Location{
+				Lat:  -6.2 + (rnd.Float64()*2-1)*0.02,
+				Long: 106.8456 + (rnd.Float64()*2-1)*0.02,
+			}
+		}
+		distances := make([][]float64, len(nodes))
+		for i := range nodes {
+			distances[i] = make([]float64, len(nodes))
+			for j := range nodes {
+				distances[i][j] = distanceBetween(nodes[i], nodes[j])
+			}
+		}
+		rest := []int{1, 2, 3, 4, 5, 6, 7}
+		end := len(nodes) - 1
+
+		exact := shortestPathExact(distances, 0, rest, end)
+		heuristic := shortestPathHeuristic(distances, 0, rest, end)
+
+		for _, order := range [][]int{exact, heuristic} {
+			assert.Equal(t, 0, order[0])
+			assert.Equal(t, end, order[len(order)-1])
+			assert.ElementsMatch(t, rest, order[1:len(order)-1])
+		}
+		// the heuristic can only match the optimum, never beat it
+		assert.LessOrEqual(t, pathLength(distances, exact), pathLength(distances, heuristic)+1e-6)
+		assert.LessOrEqual(t, pathLength(distances, exact), pathLength(distances, bruteForcePath(distances, 0, rest, end))+1e-6)
+	}
+}
+
+func waypointOrder(route model.Route) []int {
+	order := make([]int, 0, len(route.Stops))
+	for _, stop := range route.Stops {
+		order = append(order, stop.Waypoint)
+	}
+	return order
+}
+
+func pathLength(distances [][]float64, order []int) float64 {
+	var length float64
+	for i := 1; i < len(order); i++ {
+		length += distances[order[i-1]][order[i]]
+	}
+	return length
+}
+
+// bruteForcePath tries every permutation of the rest
+func bruteForcePath(distances [][]float64, start int, rest []int, end int) []int {
+	var best []int
+	var permute func(prefix, remaining []int)
+	permute = func(prefix, remaining []int) {
+		if len(remaining) == 0 {
+			order := append(append([]int{start}, prefix...), end)
+			if best == nil || pathLength(distances, order) < pathLength(distances, best) {
+				best = order
+			}
+			return
+		}
+		for i := range remaining {
+			next := append(append([]int{}, remaining[:i]...), remaining[i+1:]...)
+			permute(append(append([]int{}, prefix...), remaining[i]), next)
+		}
+	}
+	permute(nil, rest)
+	return best
+}
@@ -0,0 +1,147 @@
+package location
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"PattyWagon/internal/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SpeedProfiles configures the speed of every vehicle type and how much slower traffic gets by hour of day
+type SpeedProfiles struct {
+	// Timezone the congestion hours are read in, empty uses the server local time
+	Timezone string                               `json:"timezone" yaml:"timezone"`
+	Vehicles map[model.VehicleType]VehicleProfile `json:"vehicles" yaml:"vehicles"`
+	// CongestionByHour multiplies the travel time of a trip departing in that hour, hours left out are free flowing
+	CongestionByHour map[int]float64 `json:"congestionByHour" yaml:"congestionByHour"`
+}
+
+type VehicleProfile struct {
+	SpeedInKmPerHour float64 `json:"speedInKmPerHour" yaml:"speedInKmPerHour"`
+}
+
+// DefaultSpeedProfiles keeps the motorbike at the historical courier speed without any congestion
+func DefaultSpeedProfiles() SpeedProfiles {
+	return SpeedProfiles{
+		Vehicles: map[model.VehicleType]VehicleProfile{
+			model.VehicleBike:      {SpeedInKmPerHour: 15},
+			model.VehicleMotorbike: {SpeedInKmPerHour: constants.DeliverySpeedInKmPerHour},
+			model.VehicleCar:       {SpeedInKmPerHour: 30},
+		},
+	}
+}
+
+// LoadSpeedProfiles reads the profiles from a YAML or JSON file, picked by its extension
+func LoadSpeedProfiles(path string) (SpeedProfiles, error) {
+	var profiles SpeedProfiles
+	if err := decodeFile(path, &profiles); err != nil {
+		return SpeedProfiles{}, err
+	}
+	if err := profiles.Validate(); err != nil {
+		return SpeedProfiles{}, fmt.Errorf("invalid speed profiles in %s: %w", path, err)
+	}
+	return profiles, nil
+}
+
+// Validate requires a profile for every vehicle type, a request may pick any of them
+func (p SpeedProfiles) Validate() error {
+	var errs []error
+	for _, vehicle := range model.VehicleTypes {
+		if _, ok := p.Vehicles[vehicle]; !ok {
+			errs = append(errs, fmt.Errorf("no speed profile for vehicle %s", vehicle))
+		}
+	}
+	for vehicle, profile := range p.Vehicles {
+		if profile.SpeedInKmPerHour <= 0 {
+			errs = append(errs, fmt.Errorf("speed of %s must be positive", vehicle))
+		}
+	}
+	for hour, multiplier := range p.CongestionByHour {
+		if hour < 0 || hour > 23 {
+			errs = append(errs, fmt.Errorf("congestion hour %d is not between 0 and 23", hour))
+		}
+		if multiplier <= 0 {
+			errs = append(errs, fmt.Errorf("congestion multiplier of hour %d must be positive", hour))
+		}
+	}
+	if _, err := p.location(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// speed returns the free flowing speed of a vehicle in meters per minute
+func (p SpeedProfiles) speed(vehicle model.VehicleType) (float64, error) {
+	profile, ok := p.Vehicles[vehicle]
+	if !ok {
+		return 0, fmt.Errorf("no speed profile for vehicle %q", vehicle)
+	}
+	return profile.SpeedInKmPerHour * 1000 / 60, nil
+}
+
+func (p SpeedProfiles) congestion(departAt time.Time) float64 {
+	loc, err := p.location()
+	if err != nil {
+		return 1
+	}
+	if multiplier, ok := p.CongestionByHour[departAt.In(loc).Hour()]; ok {
+		return multiplier
+	}
+	return 1
+}
+
+func (p SpeedProfiles) location() (*time.Location, error) {
+	if p.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(p.Timezone)
+}
+
+// SpeedProfileModel estimates travel time over the straight line between two locations
+type SpeedProfileModel struct {
+	profiles SpeedProfiles
+}
+
+func NewSpeedProfileModel(profiles SpeedProfiles) *SpeedProfileModel {
+	return &SpeedProfileModel{profiles: profiles}
+}
+
+func (m *SpeedProfileModel) TravelTimeInMinutes(ctx context.Context, from, to model.Location, vehicle model.VehicleType, departAt time.Time) (float64, error) {
+	speed, err := m.profiles.speed(vehicle)
+	if err != nil {
+		return 0, err
+	}
+
+	distance := utils.CalculateDistance(from.Lat, from.Long, to.Lat, to.Long)
+	return distance / speed * m.profiles.congestion(departAt), nil
+}
+
+// decodeFile unmarshals a YAML (.yaml, .yml) or JSON (.json) file
+func decodeFile(path string, v any) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, v)
+	case ".json":
+		err = json.Unmarshal(content, v)
+	default:
+		return fmt.Errorf("unsupported file format %q, use yaml or json", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("error decoding %s: %w", path, err)
+	}
+	return nil
+}
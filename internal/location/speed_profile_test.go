@@ -0,0 +1,86 @@
+package location
+
+import (
+	"PattyWagon/internal/model"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSpeedProfiles(t *testing.T) {
+	t.Run("yaml file", func(t *testing.T) {
+		profiles, err := LoadSpeedProfiles("testdata/speed_profiles.yaml")
+
+		require.NoError(t, err)
+		assert.Equal(t, 15.0, profiles.Vehicles[model.VehicleBike].SpeedInKmPerHour)
+		assert.Equal(t, 1.5, profiles.CongestionByHour[17])
+	})
+
+	t.Run("json file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "profiles.json")
+		content := `{"vehicles": {"bike": {"speedInKmPerHour": 15}, "motorbike": {"speedInKmPerHour": 40}, "car": {"speedInKmPerHour": 30}}, "congestionByHour": {"8": 2}}`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		profiles, err := LoadSpeedProfiles(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, 30.0, profiles.Vehicles[model.VehicleCar].SpeedInKmPerHour)
+		assert.Equal(t, 2.0, profiles.CongestionByHour[8])
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "profiles.yaml")
+		content := "vehicles:\n  bike:\n    speedInKmPerHour: 0\ncongestionByHour:\n  24: 1.2\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		_, err := LoadSpeedProfiles(path)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "speed of bike must be positive")
+		assert.Contains(t, err.Error(), "hour 24")
+		assert.Contains(t, err.Error(), "no speed profile for vehicle motorbike")
+		assert.Contains(t, err.Error(), "no speed profile for vehicle car")
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := LoadSpeedProfiles("testdata/speed_profiles.toml")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSpeedProfileModel_TravelTimeInMinutes(t *testing.T) {
+	profiles, err := LoadSpeedProfiles("testdata/speed_profiles.yaml")
+	require.NoError(t, err)
+	m := NewSpeedProfileModel(profiles)
+	ctx := context.Background()
+
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	require.NoError(t, err)
+	offPeak := time.Date(2025, 10, 13, 11, 0, 0, 0, jakarta)
+	rushHour := time.Date(2025, 10, 13, 17, 30, 0, 0, jakarta)
+
+	// ~1.1 km along the meridian
+	from := model.Location{Lat: -6.2000, Long: 106.8456}
+	to := model.Location{Lat: -6.2100, Long: 106.8456}
+
+	motorbike, err := m.TravelTimeInMinutes(ctx, from, to, model.VehicleMotorbike, offPeak)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.67, motorbike, 0.01)
+
+	bike, err := m.TravelTimeInMinutes(ctx, from, to, model.VehicleBike, offPeak)
+	require.NoError(t, err)
+	assert.Greater(t, bike, motorbike)
+
+	congested, err := m.TravelTimeInMinutes(ctx, from, to, model.VehicleMotorbike, rushHour)
+	require.NoError(t, err)
+	assert.InDelta(t, motorbike*1.5, congested, 1e-9)
+
+	_, err = m.TravelTimeInMinutes(ctx, from, to, model.VehicleType("truck"), offPeak)
+	assert.Error(t, err)
+}
@@ -32,3 +32,11 @@ func (rc *Counter) Increment() {
 	defer rc.mu.Unlock()
 	rc.merchantCount++
 }
+
+func (rc *Counter) Decrement() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.merchantCount > 0 {
+		rc.merchantCount--
+	}
+}
@@ -11,9 +11,9 @@ type MockLocationService struct {
 	mock.Mock
 }
 
-func (m *MockLocationService) EstimateDeliveryTimeInMinutes(ctx context.Context, locations []model.Location) (int64, error) {
-	args := m.Called(ctx, locations)
-	return args.Get(0).(int64), args.Error(1)
+func (m *MockLocationService) PlanRoute(ctx context.Context, start int, waypoints []model.Location, destination model.Location) (model.Route, error) {
+	args := m.Called(ctx, start, waypoints, destination)
+	return args.Get(0).(model.Route), args.Error(1)
 }
 
 func (m *MockLocationService) FindNearby(ctx context.Context, location model.Location, searchingLevel int) ([]model.Cell, error) {
@@ -26,12 +26,12 @@ func (m *MockLocationService) GetAllCellIDs(ctx context.Context, location model.
 	return args.Get(0).([]model.Cell), args.Error(1)
 }
 
-func (m *MockLocationService) FindCellIDByResolution(ctx context.Context, location model.Location, resolution int) (model.Cell, error) {
-	args := m.Called(ctx, location, resolution)
-	return args.Get(0).(model.Cell), args.Error(1)
-}
-
 func (m *MockLocationService) FindKRingCellIDs(ctx context.Context, location model.Location, resolution, k int) ([]model.Cell, error) {
 	args := m.Called(ctx, location, resolution, k)
 	return args.Get(0).([]model.Cell), args.Error(1)
 }
+
+func (m *MockLocationService) KRingRadiusInMeters(ctx context.Context, location model.Location, resolution, k int) (float64, error) {
+	args := m.Called(ctx, location, resolution, k)
+	return args.Get(0).(float64), args.Error(1)
+}
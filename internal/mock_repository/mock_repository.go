@@ -61,7 +61,7 @@ func (r *TestRepositoryMock) GetItemByID(ctx context.Context, id int64) (model.I
 	return args.Get(0).(model.Item), args.Error(1)
 }
 
-func (r *TestRepositoryMock) ListMerchantWithItems(ctx context.Context, params model.ListMerchantWithItemParams) ([]model.MerchantItem, error) {
+func (r *TestRepositoryMock) ListMerchantWithItems(ctx context.Context, params model.ListMerchantWithItemParams) (model.NearbyMerchantPage, error) {
 	args := r.Called(ctx, params)
-	return args.Get(0).([]model.MerchantItem), args.Error(1)
+	return args.Get(0).(model.NearbyMerchantPage), args.Error(1)
 }
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -9,3 +11,43 @@ type Claims struct {
 	UserID int64 `json:"userID"`
 	Role   int16 `json:"role"`
 }
+
+// AuthToken is the token pair handed out on login, register and refresh
+type AuthToken struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+type RefreshToken struct {
+	ID            int64      `db:"id"`
+	UserID        int64      `db:"user_id"`
+	Role          int16      `db:"role"`
+	TokenHash     string     `db:"token_hash"`
+	AccessTokenID string     `db:"access_token_id"`
+	ExpiresAt     time.Time  `db:"expires_at"`
+	RevokedAt     *time.Time `db:"revoked_at"`
+	RevokedReason *string    `db:"revoked_reason"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
+
+// Why a refresh token was revoked, only presenting a rotated one again is treated as theft
+const (
+	RefreshTokenRotated = "rotated"
+	RefreshTokenLogout  = "logout"
+)
+
+// JWK is the public half of a token signing key as published in the JWKS document
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
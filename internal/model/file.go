@@ -2,11 +2,55 @@ package model
 
 import "database/sql"
 
+const (
+	FileStatusPending = "pending"
+	FileStatusReady   = "ready"
+)
+
+const (
+	ThumbnailStatusPending = "pending"
+	ThumbnailStatusReady   = "ready"
+	ThumbnailStatusFailed  = "failed"
+)
+
+// File is ready once its original is stored, files uploaded straight to storage stay pending, without uris,
+// until the upload is completed. The renditions, keyed by name, are generated in the background and tracked by
+// ThumbnailStatus, ThumbnailUri is the uri of one of them.
 type File struct {
-	ID           int64        `db:"id"`
-	Uri          string       `db:"uri"`
-	ThumbnailUri string       `db:"thumbnail_uri"`
-	SizeInBytes  int64        `db:"size_in_bytes"`
-	CreatedAt    sql.NullTime `db:"created_at"`
-	UpdatedAt    sql.NullTime `db:"updated_at"`
+	ID              int64                `db:"id"`
+	Uri             string               `db:"uri"`
+	ThumbnailUri    string               `db:"thumbnail_uri"`
+	SizeInBytes     int64                `db:"size_in_bytes"`
+	Status          string               `db:"status"`
+	ThumbnailStatus string               `db:"thumbnail_status"`
+	ObjectKey       string               `db:"object_key"`
+	Filename        string               `db:"filename"`
+	UploadExpiresAt sql.NullTime         `db:"upload_expires_at"`
+	Renditions      map[string]Rendition `db:"renditions"`
+	CreatedAt       sql.NullTime         `db:"created_at"`
+	UpdatedAt       sql.NullTime         `db:"updated_at"`
+}
+
+// FileUpload is a pending file together with the presigned URL the client uploads it to
+type FileUpload struct {
+	File      File
+	UploadURL string
+	Method    string
+}
+
+// StoredObject describes an object already in storage
+type StoredObject struct {
+	SizeInBytes int64
+	ContentType string
+}
+
+// Rendition is a resized copy of an uploaded image. Path is where the image compressor wrote it locally,
+// it is not kept once the rendition is stored.
+type Rendition struct {
+	Uri         string `json:"uri"`
+	Format      string `json:"format"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	SizeInBytes int64  `json:"sizeInBytes"`
+	Path        string `json:"-"`
 }
@@ -2,22 +2,76 @@ package model
 
 import "time"
 
+// Item is sold while it is available and, when its stock is tracked, has stock left. A nil Stock is not tracked.
 type Item struct {
-	ID         int64     `db:"id" json:"id"`
-	MerchantID int64     `db:"merchant_id" json:"merchant_id"`
-	Name       string    `db:"name" json:"name"`
-	Category   string    `db:"category" json:"category"`
-	Price      float64   `db:"price" json:"price"`
-	ImageURL   string    `db:"image_url" json:"image_url"`
-	CreatedAt  time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	ID          int64     `db:"id" json:"id"`
+	MerchantID  int64     `db:"merchant_id" json:"merchant_id"`
+	Name        string    `db:"name" json:"name"`
+	Category    string    `db:"category" json:"category"`
+	Price       float64   `db:"price" json:"price"`
+	ImageURL    string    `db:"image_url" json:"image_url"`
+	IsAvailable bool      `db:"is_available" json:"is_available"`
+	Stock       *int      `db:"stock" json:"stock"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+
+	ModifierGroups []ModifierGroup `json:"modifier_groups"`
+	// Highlights are the names matching a search query with the matched words wrapped in <mark>
+	Highlights []string `json:"-"`
+}
+
+// ModifierGroup is a choice made when ordering an item, such as its size or toppings. Between MinSelections and
+// MaxSelections of its options are picked, each adding its price delta to the item price.
+type ModifierGroup struct {
+	ID            int64            `json:"id"`
+	Name          string           `json:"name"`
+	MinSelections int              `json:"min_selections"`
+	MaxSelections int              `json:"max_selections"`
+	Options       []ModifierOption `json:"options"`
+}
+
+type ModifierOption struct {
+	ID         int64   `json:"id"`
+	Name       string  `json:"name"`
+	PriceDelta float64 `json:"price_delta"`
+}
+
+// SelectedModifier is an option picked for an ordered item, priced as it was when the order was estimated
+type SelectedModifier struct {
+	OptionID   int64   `json:"optionId"`
+	Group      string  `json:"group"`
+	Name       string  `json:"name"`
+	PriceDelta float64 `json:"priceDelta"`
+}
+
+// CanSell tells whether quantity of the item can be ordered right now
+func (i Item) CanSell(quantity int) bool {
+	return i.IsAvailable && (i.Stock == nil || *i.Stock >= quantity)
 }
 
 type FilterItem struct {
+	UserID          int64
+	MerchantID      int64
 	ItemID          int64
 	Limit           int
 	Offset          int
 	Name            string
 	ProductCategory string
 	CreatedAt       string
+	Cursor          *Cursor
+	// Query searches item names, ranking the items by relevance instead of creation time
+	Query string
+}
+
+type UpdateItemParams struct {
+	ID          int64
+	UserID      int64
+	MerchantID  int64
+	Name        *string
+	Category    *string
+	Price       *float64
+	ImageURL    *string
+	IsAvailable *bool
+	// ModifierGroups replace the current ones unless nil, an empty slice clears them
+	ModifierGroups []ModifierGroup
 }
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	JobStatusQueued  = "queued"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusDead    = "dead"
+)
+
+// JobGenerateThumbnail builds the thumbnail of an uploaded file, its payload is a ThumbnailJob
+const JobGenerateThumbnail = "generate_thumbnail"
+
+// Job is a unit of background work. Attempts counts the runs so far, including the one in progress.
+type Job struct {
+	ID        int64
+	Type      string
+	Payload   json.RawMessage
+	Status    string
+	Attempts  int
+	RunAt     time.Time
+	LastError string
+	CreatedAt time.Time
+}
+
+type ThumbnailJob struct {
+	FileID int64 `json:"fileId"`
+}
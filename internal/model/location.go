@@ -20,3 +20,33 @@ type MerchantLocation struct {
 	CreatedAt  time.Time `db:"created_at"`
 	UpdatedAt  time.Time `db:"updated_at"`
 }
+
+// Route is a planned delivery route, stops are listed in visiting order starting from the fixed starting point
+type Route struct {
+	Stops             []RouteStop
+	DistanceInMeters  float64
+	DurationInMinutes int64
+}
+
+// RouteStop is reached through the leg from the previous stop, the starting point has an empty leg.
+// Waypoint indexes the planned waypoints and is -1 for the destination.
+type RouteStop struct {
+	Waypoint             int
+	MerchantID           int64
+	Location             Location
+	LegDistanceInMeters  float64
+	LegDurationInMinutes int64
+	ETAInMinutes         int64
+}
+
+// VehicleType is what the courier rides, travel time models keep a speed per vehicle type
+type VehicleType string
+
+const (
+	VehicleBike      VehicleType = "bike"
+	VehicleMotorbike VehicleType = "motorbike"
+	VehicleCar       VehicleType = "car"
+)
+
+// VehicleTypes lists every vehicle a request may pick
+var VehicleTypes = []VehicleType{VehicleBike, VehicleMotorbike, VehicleCar}
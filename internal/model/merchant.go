@@ -10,15 +10,53 @@ type Merchant struct {
 	ImageURL  string    `db:"image_url"`
 	Latitude  float64   `db:"latitude"`
 	Longitude float64   `db:"longitude"`
+	Timezone  string    `db:"timezone"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
+
+	OpeningHours []OpeningHours
+	Holidays     []HolidayOverride
+	// Highlights are the names matching a search query with the matched words wrapped in <mark>
+	Highlights []string
+}
+
+// OpeningHours is a daily range a merchant is open, in its own timezone. A range closing at or before it opens
+// runs past midnight into the next day.
+type OpeningHours struct {
+	Weekday  time.Weekday `json:"weekday"`
+	OpensAt  string       `json:"opensAt"`
+	ClosesAt string       `json:"closesAt"`
+}
+
+// HolidayOverride replaces the opening hours of a single local date. Without hours the merchant is closed all day.
+type HolidayOverride struct {
+	Date     string  `json:"date"`
+	OpensAt  *string `json:"opensAt"`
+	ClosesAt *string `json:"closesAt"`
 }
 
 type FilterMerchant struct {
+	UserID           int64
 	MerchantID       int64
 	Limit            int
 	Offset           int
 	Name             string
 	MerchantCategory string
 	CreatedAt        string
+	Cursor           *Cursor
+	// Query searches merchant and item names, ranking the merchants by relevance instead of creation time
+	Query string
+}
+
+type UpdateMerchantParams struct {
+	ID       int64
+	UserID   int64
+	Name     *string
+	Category *string
+	ImageURL *string
+	Location *Location
+	Timezone *string
+	// OpeningHours and Holidays replace the current ones unless nil, an empty slice clears them
+	OpeningHours []OpeningHours
+	Holidays     []HolidayOverride
 }
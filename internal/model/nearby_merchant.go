@@ -1,17 +1,38 @@
 package model
 
 type ListMerchantWithItemParams struct {
-	Cell *Cell
+	Location Location
+	// Cells restricts the page to merchants located in any of the cells, all cells share one resolution
+	Cells []Cell
+	MerchantParams
+}
+
+type ListNearestMerchantParams struct {
+	Location       Location
+	RadiusInMeters float64
 	MerchantParams
 }
 
 type MerchantParams struct {
-	MerchantID       *int64
-	Limit            int
-	Offset           int
-	Name             *string
+	MerchantID *int64
+	Limit      int
+	Offset     int
+	Name       *string
+	// Query searches merchant and item names, ranking the merchants by relevance before distance
+	Query            *string
 	MerchantCategory *string
-	SortingOrder     *string
+	// OpenNow keeps only the merchants open at the time of the search
+	OpenNow      bool
+	SortingOrder *string
+}
+
+// NearbyMerchantPage is a page of merchants ordered by distance from the searched location
+type NearbyMerchantPage struct {
+	Merchants []MerchantItem
+	// Matched counts the merchants matching the filters inside the searched cells
+	Matched int
+	// Total counts every merchant the search pages through
+	Total int
 }
 
 type MerchantCategory string
@@ -1,8 +1,13 @@
 package model
 
+import "time"
+
 type OrderEstimation struct {
+	UserID       int64
 	UserLocation Location
 	Orders       []Order
+	PromoCode    string
+	VehicleType  VehicleType
 }
 
 type Order struct {
@@ -21,14 +26,33 @@ type Order struct {
 // }
 
 type OrderItem struct {
-	ItemID   int64
-	Quantity int
+	ItemID            int64
+	Quantity          int
+	ModifierOptionIDs []int64
 }
 
 type EstimationPrice struct {
 	ID                         int64
+	UserID                     int64
+	UserLocation               Location
 	EstimatedDeliveryInMinutes int64
 	TotalPrice                 float64
+	Breakdown                  PriceBreakdown
+	PromoCodeID                *int64
+	Route                      Route
+	Items                      []EstimationItem
+	CreatedAt                  time.Time
+}
+
+// EstimationItem is priced per unit, including the price deltas of its modifiers
+type EstimationItem struct {
+	ID           int64              `db:"id"`
+	EstimationID int64              `db:"estimation_id"`
+	MerchantID   int64              `db:"merchant_id"`
+	ItemID       int64              `db:"item_id"`
+	Quantity     int                `db:"quantity"`
+	Price        float64            `db:"price"`
+	Modifiers    []SelectedModifier `db:"modifiers"`
 }
 
 type FindNerbyMerchantParams struct {
@@ -37,6 +61,58 @@ type FindNerbyMerchantParams struct {
 }
 
 type MerchantItem struct {
+	Merchant         Merchant
+	Items            []Item
+	DistanceInMeters float64
+	IsOpen           bool
+	// Highlights are the names matching a search query with the matched words wrapped in <mark>
+	Highlights []string
+}
+
+type UserOrder struct {
+	ID                         int64
+	UserID                     int64
+	EstimationID               int64
+	TotalPrice                 float64
+	Breakdown                  PriceBreakdown
+	EstimatedDeliveryInMinutes int64
+	Items                      []UserOrderItem
+	CreatedAt                  time.Time
+}
+
+type UserOrderItem struct {
+	ID         int64              `db:"id"`
+	OrderID    int64              `db:"order_id"`
+	MerchantID int64              `db:"merchant_id"`
+	ItemID     int64              `db:"item_id"`
+	Quantity   int                `db:"quantity"`
+	Price      float64            `db:"price"`
+	Modifiers  []SelectedModifier `db:"modifiers"`
+}
+
+type FilterOrder struct {
+	UserID           int64
+	Limit            int
+	Offset           int
+	Name             string
+	MerchantCategory string
+}
+
+type OrderHistory struct {
+	ID         int64
+	TotalPrice float64
+	Breakdown  PriceBreakdown
+	CreatedAt  time.Time
+	Merchants  []MerchantOrder
+}
+
+type MerchantOrder struct {
 	Merchant Merchant
-	Items    []Item
+	Items    []OrderedItem
+}
+
+type OrderedItem struct {
+	Item
+	Quantity  int
+	Modifiers []SelectedModifier
 }
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Cursor is a position in a listing ordered by (created_at, id)
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+	// Backward selects the rows before the position instead of the rows after it
+	Backward bool
+	// Descending is the order of the listing the position was taken from, it is only valid in that order
+	Descending bool
+}
+
+// SortsDescending reports whether a createdAt sort lists the newest rows first, anything but asc does
+func SortsDescending(createdAt string) bool {
+	return !strings.EqualFold(createdAt, "asc")
+}
+
+// PageInfo locates a page within its listing. A nil cursor means there is nothing more in that direction.
+type PageInfo struct {
+	NextCursor *Cursor
+	PrevCursor *Cursor
+	Total      int
+}
+
+type MerchantPage struct {
+	Merchants []Merchant
+	PageInfo
+}
+
+type ItemPage struct {
+	Items []Item
+	PageInfo
+}
+
+// OrderPage is a page of the offset paginated order history
+type OrderPage struct {
+	Orders []OrderHistory
+	Total  int
+}
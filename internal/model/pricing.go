@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+const (
+	PromoDiscountPercent = "percent"
+	PromoDiscountFixed   = "fixed"
+)
+
+// PromoCode discounts the item subtotal of an order, either by a percentage or by a fixed amount
+type PromoCode struct {
+	ID                int64      `db:"id"`
+	Code              string     `db:"code"`
+	DiscountType      string     `db:"discount_type"`
+	DiscountValue     float64    `db:"discount_value"`
+	MaxDiscount       *float64   `db:"max_discount"`
+	MinSpend          float64    `db:"min_spend"`
+	UsageLimitPerUser *int       `db:"usage_limit_per_user"`
+	StartsAt          *time.Time `db:"starts_at"`
+	ExpiresAt         *time.Time `db:"expires_at"`
+}
+
+type PromoCodeRedemption struct {
+	PromoCodeID int64
+	UserID      int64
+	OrderID     int64
+}
+
+// PriceBreakdown itemizes what a user pays for an order. Subtotal is the bare sum of item prices.
+type PriceBreakdown struct {
+	Subtotal         float64
+	DistanceInMeters float64
+	DeliveryFee      float64
+	ServiceFee       float64
+	Discount         float64
+	Tax              float64
+	GrandTotal       float64
+	Lines            []PriceLine
+}
+
+const (
+	PriceLineItem        = "item"
+	PriceLineDeliveryFee = "deliveryFee"
+	PriceLineServiceFee  = "serviceFee"
+	PriceLineDiscount    = "discount"
+	PriceLineTax         = "tax"
+)
+
+// PriceLine is a single line of a price breakdown, discounts have a negative amount
+type PriceLine struct {
+	Type        string
+	Description string
+	Quantity    int
+	UnitPrice   float64
+	Amount      float64
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 )
 
 type DBTX interface {
@@ -13,6 +14,16 @@ type DBTX interface {
 	BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
 }
 
+// querier is the subset of DBTX shared by *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+type txCtxKey struct{}
+
 func New(db DBTX) *Queries {
 	return &Queries{db: db}
 }
@@ -21,12 +32,44 @@ type Queries struct {
 	db DBTX
 }
 
-// func (q *Queries) WithTx(tx *sql.Tx) *Queries {
-// 	return &Queries{
-// 		db: tx,
-// 	}
-// }
+// WithTx runs fn as a single unit of work. Every repository call made with the ctx handed to fn
+// joins the same transaction, which commits when fn returns nil and rolls back otherwise.
+// Calling WithTx again inside fn reuses the outer transaction.
+func (q *Queries) WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txCtxKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
 
-// func (q *Queries) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
-// 	return q.db.BeginTx(ctx, opts)
-// }
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txCtxKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// conn returns the transaction bound to ctx by WithTx, or the shared connection pool
+func (q *Queries) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txCtxKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return q.db
+}
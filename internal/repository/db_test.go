@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTx(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, _, _ := seedMerchantWithItem(t, repo)
+
+	t.Run("Commit", func(t *testing.T) {
+		var merchantID int64
+		err := repo.WithTx(context.TODO(), func(ctx context.Context) error {
+			var err error
+			merchantID, err = repo.InsertMerchant(ctx, model.Merchant{
+				UserID:    merchant.UserID,
+				Name:      "Committed Merchant",
+				Category:  merchant.Category,
+				ImageURL:  merchant.ImageURL,
+				Latitude:  merchant.Latitude,
+				Longitude: merchant.Longitude,
+			})
+			return err
+		})
+		assert.Nil(t, err)
+
+		result, err := repo.GetMerchantByID(context.TODO(), merchantID)
+		assert.Nil(t, err)
+		assert.Equal(t, "Committed Merchant", result.Name)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		errAbort := errors.New("abort")
+
+		var merchantID int64
+		err := repo.WithTx(context.TODO(), func(ctx context.Context) error {
+			var err error
+			merchantID, err = repo.InsertMerchant(ctx, model.Merchant{
+				UserID:    merchant.UserID,
+				Name:      "Rolled Back Merchant",
+				Category:  merchant.Category,
+				ImageURL:  merchant.ImageURL,
+				Latitude:  merchant.Latitude,
+				Longitude: merchant.Longitude,
+			})
+			if err != nil {
+				return err
+			}
+			return errAbort
+		})
+		assert.ErrorIs(t, err, errAbort)
+
+		_, err = repo.GetMerchantByID(context.TODO(), merchantID)
+		assert.ErrorIs(t, err, constants.ErrMerchantNotFound)
+	})
+}
@@ -1,31 +1,36 @@
 package repository
 
 import (
+	"PattyWagon/internal/constants"
 	"PattyWagon/internal/model"
 	"PattyWagon/observability"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 )
 
+// InsertFile records an uploaded original whose thumbnail is still to be generated in the background
 func (q *Queries) InsertFile(ctx context.Context, data model.File) (res model.File, err error) {
 	ctx, span := observability.Tracer.Start(ctx, "repository.insert_file")
 	defer span.End()
 
 	query := `
 		INSERT INTO files (
-			uri, thumbnail_uri, created_at, updated_at
+			uri, object_key, size_in_bytes, filename, status, thumbnail_status, created_at, updated_at
 		) VALUES (
-			$1, $2, NOW(), NOW()
+			$1, $2, $3, NULLIF($4, ''), 'ready', 'pending', NOW(), NOW()
 		)
-		RETURNING id, created_at, updated_at
+		RETURNING id, status, thumbnail_status, created_at, updated_at
 	`
 
-	err = q.db.QueryRowContext(ctx, query,
+	err = q.conn(ctx).QueryRowContext(ctx, query,
 		data.Uri,
-		data.ThumbnailUri,
-	).Scan(&data.ID, &data.CreatedAt, &data.UpdatedAt)
+		data.ObjectKey,
+		data.SizeInBytes,
+		data.Filename,
+	).Scan(&data.ID, &data.Status, &data.ThumbnailStatus, &data.CreatedAt, &data.UpdatedAt)
 
 	if err != nil {
 		return model.File{}, fmt.Errorf("error inserting file: %w", err)
@@ -34,30 +39,135 @@ func (q *Queries) InsertFile(ctx context.Context, data model.File) (res model.Fi
 	return data, nil
 }
 
+// InsertPendingFile records a file the client is about to upload straight to storage under its object key
+func (q *Queries) InsertPendingFile(ctx context.Context, data model.File) (model.File, error) {
+	ctx, span := observability.Tracer.Start(ctx, "repository.insert_pending_file")
+	defer span.End()
+
+	query := `
+		INSERT INTO files (
+			object_key, filename, upload_expires_at, status, thumbnail_status, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, 'pending', 'pending', NOW(), NOW()
+		)
+		RETURNING id, status, thumbnail_status, created_at, updated_at
+	`
+
+	err := q.conn(ctx).QueryRowContext(ctx, query,
+		data.ObjectKey,
+		data.Filename,
+		data.UploadExpiresAt,
+	).Scan(&data.ID, &data.Status, &data.ThumbnailStatus, &data.CreatedAt, &data.UpdatedAt)
+	if err != nil {
+		return model.File{}, fmt.Errorf("error inserting pending file: %w", err)
+	}
+
+	return data, nil
+}
+
+// CompleteFile marks a pending file ready with the uri of its original, its thumbnail is generated afterwards.
+// A file that is not pending anymore is left untouched and reported as ErrFileAlreadyCompleted.
+func (q *Queries) CompleteFile(ctx context.Context, data model.File) (model.File, error) {
+	ctx, span := observability.Tracer.Start(ctx, "repository.complete_file")
+	defer span.End()
+
+	query := `
+		UPDATE files
+		SET uri = $2, size_in_bytes = $3, status = 'ready', thumbnail_status = 'pending', updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+		RETURNING status, thumbnail_status, updated_at
+	`
+
+	err := q.conn(ctx).QueryRowContext(ctx, query,
+		data.ID,
+		data.Uri,
+		data.SizeInBytes,
+	).Scan(&data.Status, &data.ThumbnailStatus, &data.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.File{}, constants.ErrFileAlreadyCompleted
+		}
+		return model.File{}, fmt.Errorf("error completing file: %w", err)
+	}
+
+	return data, nil
+}
+
 func (q *Queries) GetFileUpload(ctx context.Context, id int64) (model.File, error) {
 	query := `
-		SELECT id, uri, thumbnail_uri, created_at, updated_at FROM files
+		SELECT
+			id, COALESCE(uri, ''), COALESCE(thumbnail_uri, ''), COALESCE(size_in_bytes, 0), status, thumbnail_status,
+			COALESCE(object_key, ''), COALESCE(filename, ''), upload_expires_at, renditions, created_at, updated_at
+		FROM files
 		WHERE id = $1
 	`
 
-	row := q.db.QueryRowContext(ctx, query, id)
-	var f model.File
+	row := q.conn(ctx).QueryRowContext(ctx, query, id)
+	var (
+		f          model.File
+		renditions []byte
+	)
 	if err := row.Scan(
 		&f.ID,
 		&f.Uri,
 		&f.ThumbnailUri,
+		&f.SizeInBytes,
+		&f.Status,
+		&f.ThumbnailStatus,
+		&f.ObjectKey,
+		&f.Filename,
+		&f.UploadExpiresAt,
+		&renditions,
 		&f.CreatedAt,
 		&f.UpdatedAt,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return model.File{}, errors.New("file not found")
+			return model.File{}, constants.ErrFileNotFound
 		}
 		return model.File{}, err
 	}
+	if err := json.Unmarshal(renditions, &f.Renditions); err != nil {
+		return model.File{}, err
+	}
 
 	return f, nil
 }
 
+// SetFileRenditions stores the generated renditions of a file and its thumbnail uri, marking them ready
+func (q *Queries) SetFileRenditions(ctx context.Context, id int64, thumbnailUri string, renditions map[string]model.Rendition) error {
+	ctx, span := observability.Tracer.Start(ctx, "repository.set_file_renditions")
+	defer span.End()
+
+	encoded, err := json.Marshal(renditions)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE files
+		SET thumbnail_uri = $2, renditions = $3::jsonb, thumbnail_status = 'ready', updated_at = NOW()
+		WHERE id = $1
+	`
+
+	res, err := q.conn(ctx).ExecContext(ctx, query, id, thumbnailUri, string(encoded))
+	if err != nil {
+		return fmt.Errorf("error setting file renditions: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return constants.ErrFileNotFound
+	}
+	return nil
+}
+
+// MarkThumbnailFailed records that the thumbnail of a file could not be generated
+func (q *Queries) MarkThumbnailFailed(ctx context.Context, id int64) error {
+	query := `UPDATE files SET thumbnail_status = 'failed', updated_at = NOW() WHERE id = $1`
+	if _, err := q.conn(ctx).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("error marking thumbnail failed: %w", err)
+	}
+	return nil
+}
+
 func (q *Queries) FileExists(ctx context.Context, fileID string) (bool, error) {
 	query := `
 		SELECT 1 FROM files
@@ -65,7 +175,7 @@ func (q *Queries) FileExists(ctx context.Context, fileID string) (bool, error) {
 	`
 
 	var exists int
-	err := q.db.QueryRowContext(ctx, query, fileID).Scan(&exists)
+	err := q.conn(ctx).QueryRowContext(ctx, query, fileID).Scan(&exists)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return false, nil
@@ -76,9 +186,31 @@ func (q *Queries) FileExists(ctx context.Context, fileID string) (bool, error) {
 	return true, nil
 }
 
+// FileURIExists checks whether the uri points to an uploaded file, its thumbnail or another of its renditions
+func (q *Queries) FileURIExists(ctx context.Context, uri string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM files
+			WHERE status = 'ready'
+				AND (
+					uri = $1 OR thumbnail_uri = $1
+					OR EXISTS (SELECT 1 FROM jsonb_each(renditions) r WHERE r.value->>'uri' = $1)
+				)
+		)
+	`
+
+	var exists bool
+	err := q.conn(ctx).QueryRowContext(ctx, query, uri).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
 func (q *Queries) GetFileByFileID(ctx context.Context, fileID string) (res model.File, err error) {
-	query := `SELECT id, uri, thumbnail_uri, created_at, updated_at FROM files WHERE id = $1`
-	err = q.db.QueryRowContext(ctx, query, fileID).Scan(&res.ID, &res.Uri, &res.ThumbnailUri, &res.CreatedAt, &res.UpdatedAt)
+	query := `SELECT id, COALESCE(uri, ''), COALESCE(thumbnail_uri, ''), created_at, updated_at FROM files WHERE id = $1`
+	err = q.conn(ctx).QueryRowContext(ctx, query, fileID).Scan(&res.ID, &res.Uri, &res.ThumbnailUri, &res.CreatedAt, &res.UpdatedAt)
 	if err != nil {
 		return model.File{}, err
 	}
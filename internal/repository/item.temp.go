@@ -1,10 +0,0 @@
-package repository
-
-import (
-	"PattyWagon/internal/model"
-	"context"
-)
-
-func (q *Queries) GetItemByID(ctx context.Context, id int64) (model.Item, error) {
-	panic("unimplemented")
-}
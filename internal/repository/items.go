@@ -1,25 +1,52 @@
 package repository
 
 import (
+	"PattyWagon/internal/constants"
 	"PattyWagon/internal/model"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// itemModifierGroups selects the modifier groups of items i with their options as JSON
+const itemModifierGroups = `(
+      SELECT json_agg(json_build_object(
+        'id', g.id,
+        'name', g.name,
+        'min_selections', g.min_selections,
+        'max_selections', g.max_selections,
+        'options', (
+          SELECT json_agg(json_build_object(
+            'id', o.id,
+            'name', o.name,
+            'price_delta', o.price_delta
+          ) ORDER BY o.position)
+          FROM item_modifier_options o
+          WHERE o.group_id = g.id
+        )
+      ) ORDER BY g.position)
+      FROM item_modifier_groups g
+      WHERE g.item_id = i.id
+    )`
+
 func (q *Queries) CreateItems(ctx context.Context, item model.Item) (int64, error) {
 	query := `
-		INSERT INTO items (merchant_id, name, category, price, image_url)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO items (merchant_id, name, category, price, image_url, is_available, stock)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id;
 	`
 	var id int64
-	err := q.db.QueryRowContext(ctx, query,
+	err := q.conn(ctx).QueryRowContext(ctx, query,
 		item.MerchantID,
 		item.Name,
 		item.Category,
 		item.Price,
 		item.ImageURL,
+		item.IsAvailable,
+		item.Stock,
 	).Scan(&id)
 
 	if err != nil {
@@ -29,88 +56,384 @@ func (q *Queries) CreateItems(ctx context.Context, item model.Item) (int64, erro
 	return id, nil
 }
 
-func (q *Queries) GetItems(ctx context.Context, filter model.FilterItem) (res []model.Item, err error) {
+// GetItems returns a page of items ordered by creation time, or by relevance when searching, reporting whether
+// more rows follow in the direction of the page
+func (q *Queries) GetItems(ctx context.Context, filter model.FilterItem) (res []model.Item, hasMore bool, err error) {
+	conds, args, search := itemListConds(filter)
 	query := `
-		SELECT id, merchant_id, name, category, price, image_url, created_at
-		FROM items
+		SELECT id, merchant_id, name, category, price, image_url, is_available, stock, created_at,
+			` + itemModifierGroups + `,
+			` + search.highlights + `
+		FROM items i
 	`
+
+	// pagination, newest first unless sorted ascending
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 5
+	}
+	offset := filter.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	var pageClause string
+	if filter.Query != "" {
+		pageClause = searchPage(search.relevance, limit, offset)
+		filter.Cursor = nil
+	} else {
+		conds, args, pageClause = keysetPage(filter.Cursor, model.SortsDescending(filter.CreatedAt), limit, offset, conds, args)
+	}
+
+	// conditions
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += pageClause
+
+	rows, err := q.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var items []model.Item
+	for rows.Next() {
+		var (
+			i                          model.Item
+			modifierGroups, highlights []byte
+		)
+		if err := rows.Scan(
+			&i.ID,
+			&i.MerchantID,
+			&i.Name,
+			&i.Category,
+			&i.Price,
+			&i.ImageURL,
+			&i.IsAvailable,
+			&i.Stock,
+			&i.CreatedAt,
+			&modifierGroups,
+			&highlights,
+		); err != nil {
+			return nil, false, err
+		}
+		if err := unmarshalModifierGroups(&i, modifierGroups); err != nil {
+			return nil, false, err
+		}
+		if highlights != nil {
+			if err := json.Unmarshal(highlights, &i.Highlights); err != nil {
+				return nil, false, fmt.Errorf("error decoding highlights of item %d: %w", i.ID, err)
+			}
+		}
+		items = append(items, i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	res, hasMore = trimKeysetPage(items, limit, filter.Cursor)
+	return res, hasMore, nil
+}
+
+// CountItems counts every item matching the filter, regardless of the page
+func (q *Queries) CountItems(ctx context.Context, filter model.FilterItem) (int, error) {
+	query := "SELECT COUNT(*) FROM items i"
+
+	conds, args, _ := itemListConds(filter)
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var count int
+	if err := q.conn(ctx).QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// itemListConds translates the filter into conditions on items i
+func itemListConds(filter model.FilterItem) ([]string, []interface{}, nameSearch) {
 	conds := []string{}
 	args := []interface{}{}
-	argIdx := 1
+	search := noSearch
 
 	// filter by MerchantID
+	if filter.MerchantID != 0 {
+		conds = append(conds, fmt.Sprintf("merchant_id = $%d", len(args)+1))
+		args = append(args, filter.MerchantID)
+	}
+
+	// filter by ItemID
 	if filter.ItemID != 0 {
-		conds = append(conds, fmt.Sprintf("id = $%d", argIdx))
+		conds = append(conds, fmt.Sprintf("id = $%d", len(args)+1))
 		args = append(args, filter.ItemID)
-		argIdx++
 	}
 
 	// filter by Name
 	if filter.Name != "" {
-		conds = append(conds, fmt.Sprintf("name ILIKE $%d", argIdx))
+		conds = append(conds, fmt.Sprintf("name ILIKE $%d", len(args)+1))
 		args = append(args, "%"+filter.Name+"%")
-		argIdx++
 	}
 
 	// filter by category
 	if filter.ProductCategory != "" {
-		conds = append(conds, fmt.Sprintf("LOWER(category) = LOWER($%d)", argIdx))
+		conds = append(conds, fmt.Sprintf("LOWER(category) = LOWER($%d)", len(args)+1))
 		args = append(args, filter.ProductCategory)
-		argIdx++
 	}
 
-	// conditions
-	if len(conds) > 0 {
-		query += " WHERE " + strings.Join(conds, " AND ")
+	// search by name
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+
+		var cond string
+		cond, search = itemSearchConds(len(args))
+		conds = append(conds, cond)
 	}
 
-	// sorting createdAt
-	if strings.ToLower(filter.CreatedAt) == "asc" {
-		query += " ORDER BY created_at ASC"
-	} else if strings.ToLower(filter.CreatedAt) == "desc" {
-		query += " ORDER BY created_at DESC"
-	} else {
-		query += " ORDER BY created_at DESC"
+	return conds, args, search
+}
+
+func (q *Queries) GetItemByID(ctx context.Context, id int64) (model.Item, error) {
+	query := `
+		SELECT id, merchant_id, name, category, price, image_url, is_available, stock, created_at, updated_at,
+			` + itemModifierGroups + `
+		FROM items i
+		WHERE id = $1
+	`
+
+	var (
+		item           model.Item
+		modifierGroups []byte
+	)
+	err := q.conn(ctx).QueryRowContext(ctx, query, id).Scan(
+		&item.ID,
+		&item.MerchantID,
+		&item.Name,
+		&item.Category,
+		&item.Price,
+		&item.ImageURL,
+		&item.IsAvailable,
+		&item.Stock,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&modifierGroups,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Item{}, constants.ErrItemNotFound
+		}
+		return model.Item{}, err
 	}
 
-	// pagination
-	limit := filter.Limit
-	if limit <= 0 {
-		limit = 5
+	if err := unmarshalModifierGroups(&item, modifierGroups); err != nil {
+		return model.Item{}, err
 	}
-	offset := filter.Offset
-	if offset < 0 {
-		offset = 0
+
+	return item, nil
+}
+
+func (q *Queries) UpdateItem(ctx context.Context, item model.Item) (res model.Item, err error) {
+	query := `
+		UPDATE items
+		SET name = $3, category = $4, price = $5, image_url = $6, is_available = $7, updated_at = NOW()
+		WHERE id = $1 AND merchant_id = $2
+		RETURNING id, merchant_id, name, category, price, image_url, is_available, stock, created_at, updated_at
+	`
+
+	err = q.conn(ctx).QueryRowContext(ctx, query,
+		item.ID,
+		item.MerchantID,
+		item.Name,
+		item.Category,
+		item.Price,
+		item.ImageURL,
+		item.IsAvailable,
+	).Scan(
+		&res.ID,
+		&res.MerchantID,
+		&res.Name,
+		&res.Category,
+		&res.Price,
+		&res.ImageURL,
+		&res.IsAvailable,
+		&res.Stock,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Item{}, constants.ErrItemNotFound
+		}
+		return model.Item{}, fmt.Errorf("error updating item: %w", err)
 	}
-	query += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
 
-	rows, err := q.db.QueryContext(ctx, query, args...)
+	return res, nil
+}
+
+func (q *Queries) DeleteItem(ctx context.Context, merchantID, itemID int64) error {
+	result, err := q.conn(ctx).ExecContext(ctx, "DELETE FROM items WHERE id = $1 AND merchant_id = $2", itemID, merchantID)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("error deleting item: %w", err)
 	}
-	defer rows.Close()
 
-	var items []model.Item
-	for rows.Next() {
-		var i model.Item
-		if err := rows.Scan(
-			&i.ID,
-			&i.MerchantID,
-			&i.Name,
-			&i.Category,
-			&i.Price,
-			&i.ImageURL,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return constants.ErrItemNotFound
+	}
+
+	return nil
+}
+
+// ReplaceItemModifierGroups swaps every modifier group of an item for the given ones, run it in a transaction
+func (q *Queries) ReplaceItemModifierGroups(ctx context.Context, itemID int64, groups []model.ModifierGroup) error {
+	_, err := q.conn(ctx).ExecContext(ctx, "DELETE FROM item_modifier_groups WHERE item_id = $1", itemID)
+	if err != nil {
+		return fmt.Errorf("error replacing item modifier groups: %w", err)
+	}
+
+	for position, group := range groups {
+		var groupID int64
+		err := q.conn(ctx).QueryRowContext(ctx, `
+			INSERT INTO item_modifier_groups (item_id, name, min_selections, max_selections, position)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, itemID, group.Name, group.MinSelections, group.MaxSelections, position).Scan(&groupID)
+		if err != nil {
+			return fmt.Errorf("error inserting item modifier group: %w", err)
+		}
+
+		if len(group.Options) == 0 {
+			continue
+		}
+
+		values := []interface{}{groupID}
+		placeholders := []string{}
+
+		for i, option := range group.Options {
+			placeholders = append(placeholders,
+				fmt.Sprintf("($1, $%d, $%d, $%d)", i*3+2, i*3+3, i*3+4))
+			values = append(values, option.Name, option.PriceDelta, i)
+		}
+
+		query := `INSERT INTO item_modifier_options (group_id, name, price_delta, position) VALUES ` +
+			strings.Join(placeholders, ", ")
+
+		if _, err := q.conn(ctx).ExecContext(ctx, query, values...); err != nil {
+			return fmt.Errorf("error inserting item modifier options: %w", err)
 		}
-		items = append(items, i)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return nil
+}
+
+// unmarshalModifierGroups decodes the modifier groups selected by itemModifierGroups
+func unmarshalModifierGroups(item *model.Item, modifierGroups []byte) error {
+	if modifierGroups == nil {
+		return nil
+	}
+	return json.Unmarshal(modifierGroups, &item.ModifierGroups)
+}
+
+// SetItemStock overwrites the stock of an item, a nil stock stops tracking it
+func (q *Queries) SetItemStock(ctx context.Context, merchantID, itemID int64, stock *int) (model.Item, error) {
+	query := `
+		UPDATE items
+		SET stock = $3, updated_at = NOW()
+		WHERE id = $1 AND merchant_id = $2
+		RETURNING id, merchant_id, name, category, price, image_url, is_available, stock, created_at, updated_at
+	`
+
+	return q.updateItemStock(ctx, query, itemID, merchantID, stock)
+}
+
+// AdjustItemStock adds delta to the tracked stock of an item in a single conditional update, so concurrent
+// adjustments never take the stock below zero
+func (q *Queries) AdjustItemStock(ctx context.Context, merchantID, itemID int64, delta int) (model.Item, error) {
+	query := `
+		UPDATE items
+		SET stock = stock + $3, updated_at = NOW()
+		WHERE id = $1 AND merchant_id = $2 AND stock IS NOT NULL AND stock + $3 >= 0
+		RETURNING id, merchant_id, name, category, price, image_url, is_available, stock, created_at, updated_at
+	`
+
+	item, err := q.updateItemStock(ctx, query, itemID, merchantID, delta)
+	if !errors.Is(err, constants.ErrItemNotFound) {
+		return item, err
+	}
+
+	// tell apart why no row was updated
+	item, err = q.GetItemByID(ctx, itemID)
+	switch {
+	case err != nil:
+		return model.Item{}, err
+	case item.MerchantID != merchantID:
+		return model.Item{}, constants.ErrItemNotFound
+	case item.Stock == nil:
+		return model.Item{}, constants.ErrStockNotTracked
+	default:
+		return model.Item{}, constants.ErrItemOutOfStock
 	}
+}
+
+func (q *Queries) updateItemStock(ctx context.Context, query string, itemID, merchantID int64, value interface{}) (res model.Item, err error) {
+	err = q.conn(ctx).QueryRowContext(ctx, query, itemID, merchantID, value).Scan(
+		&res.ID,
+		&res.MerchantID,
+		&res.Name,
+		&res.Category,
+		&res.Price,
+		&res.ImageURL,
+		&res.IsAvailable,
+		&res.Stock,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+	)
 
-	res = items
-	return
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Item{}, constants.ErrItemNotFound
+		}
+		return model.Item{}, fmt.Errorf("error updating item stock: %w", err)
+	}
+
+	return res, nil
+}
+
+// ReserveItemStock takes quantity of an item for an order. The conditional update locks the item row, so a
+// concurrent reservation waits for it and re-checks the stock left rather than overselling.
+func (q *Queries) ReserveItemStock(ctx context.Context, itemID int64, quantity int) error {
+	query := `
+		UPDATE items
+		SET stock = stock - $2, updated_at = NOW()
+		WHERE id = $1 AND is_available AND (stock IS NULL OR stock >= $2)
+	`
+
+	result, err := q.conn(ctx).ExecContext(ctx, query, itemID, quantity)
+	if err != nil {
+		return fmt.Errorf("error reserving item stock: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	item, err := q.GetItemByID(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	if !item.IsAvailable {
+		return constants.ErrItemUnavailable
+	}
+	return constants.ErrItemOutOfStock
 }
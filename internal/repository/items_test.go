@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetItemByID(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, item, _ := seedMerchantWithItem(t, repo)
+
+	t.Run("Valid", func(t *testing.T) {
+		result, err := repo.GetItemByID(context.TODO(), item.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, item.ID, result.ID)
+		assert.Equal(t, merchant.ID, result.MerchantID)
+		assert.Equal(t, item.Name, result.Name)
+		assert.Equal(t, item.Category, result.Category)
+		assert.Equal(t, item.Price, result.Price)
+		assert.Equal(t, item.ImageURL, result.ImageURL)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := repo.GetItemByID(context.TODO(), -1)
+		assert.ErrorIs(t, err, constants.ErrItemNotFound)
+	})
+}
+
+func TestReserveItemStock(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.TODO()
+	merchant, item, _ := seedMerchantWithItem(t, repo)
+
+	t.Run("NotTracked", func(t *testing.T) {
+		assert.NoError(t, repo.ReserveItemStock(ctx, item.ID, 100))
+
+		_, err := repo.AdjustItemStock(ctx, merchant.ID, item.ID, 1)
+		assert.ErrorIs(t, err, constants.ErrStockNotTracked)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		stock := 5
+		_, err := repo.SetItemStock(ctx, merchant.ID, item.ID, &stock)
+		require.NoError(t, err)
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			reserved int
+		)
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := repo.WithTx(ctx, func(ctx context.Context) error {
+					return repo.ReserveItemStock(ctx, item.ID, 1)
+				})
+				if err == nil {
+					mu.Lock()
+					reserved++
+					mu.Unlock()
+					return
+				}
+				assert.ErrorIs(t, err, constants.ErrItemOutOfStock)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, stock, reserved)
+		result, err := repo.GetItemByID(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, *result.Stock)
+	})
+
+	t.Run("Adjust", func(t *testing.T) {
+		_, err := repo.AdjustItemStock(ctx, merchant.ID, item.ID, -1)
+		assert.ErrorIs(t, err, constants.ErrItemOutOfStock)
+
+		result, err := repo.AdjustItemStock(ctx, merchant.ID, item.ID, 3)
+		require.NoError(t, err)
+		assert.Equal(t, 3, *result.Stock)
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		result, err := repo.GetItemByID(ctx, item.ID)
+		require.NoError(t, err)
+		result.IsAvailable = false
+		_, err = repo.UpdateItem(ctx, result)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, repo.ReserveItemStock(ctx, item.ID, 1), constants.ErrItemUnavailable)
+	})
+}
+
+func TestReplaceItemModifierGroups(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.TODO()
+	merchant, item, _ := seedMerchantWithItem(t, repo)
+
+	groups := []model.ModifierGroup{
+		{Name: "Size", MinSelections: 1, MaxSelections: 1, Options: []model.ModifierOption{
+			{Name: "S", PriceDelta: -2000},
+			{Name: "L", PriceDelta: 5000},
+		}},
+		{Name: "Toppings", MaxSelections: 3, Options: []model.ModifierOption{
+			{Name: "Cheese", PriceDelta: 3000},
+		}},
+	}
+	err := repo.WithTx(ctx, func(ctx context.Context) error {
+		return repo.ReplaceItemModifierGroups(ctx, item.ID, groups)
+	})
+	require.NoError(t, err)
+
+	result, err := repo.GetItemByID(ctx, item.ID)
+	require.NoError(t, err)
+	require.Len(t, result.ModifierGroups, 2)
+	assert.Equal(t, "Size", result.ModifierGroups[0].Name)
+	require.Len(t, result.ModifierGroups[0].Options, 2)
+	assert.Equal(t, "L", result.ModifierGroups[0].Options[1].Name)
+	assert.Equal(t, 5000.0, result.ModifierGroups[0].Options[1].PriceDelta)
+
+	merchantItem, err := repo.GetMerchantWithItems(ctx, merchant.ID)
+	require.NoError(t, err)
+	require.Len(t, merchantItem.Items, 1)
+	assert.Equal(t, result.ModifierGroups, merchantItem.Items[0].ModifierGroups)
+
+	err = repo.ReplaceItemModifierGroups(ctx, item.ID, nil)
+	require.NoError(t, err)
+	result, err = repo.GetItemByID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Empty(t, result.ModifierGroups)
+}
+
+func TestGetItemsSearch(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, item, _ := seedMerchantWithItem(t, repo)
+	identifier := item.Name[len("Seed Item "):]
+
+	search := func(t *testing.T, query string) []model.Item {
+		t.Helper()
+		filter := model.FilterItem{MerchantID: merchant.ID, Query: query, Limit: 5}
+
+		result, _, err := repo.GetItems(context.TODO(), filter)
+		require.NoError(t, err)
+
+		total, err := repo.CountItems(context.TODO(), filter)
+		require.NoError(t, err)
+		assert.Equal(t, len(result), total)
+		return result
+	}
+
+	t.Run("Words", func(t *testing.T) {
+		result := search(t, item.Name)
+		require.Len(t, result, 1)
+		assert.Equal(t, item.ID, result[0].ID)
+		assert.Equal(t, []string{"<mark>Seed</mark> <mark>Item</mark> <mark>" + identifier + "</mark>"}, result[0].Highlights)
+	})
+
+	t.Run("Typo", func(t *testing.T) {
+		result := search(t, "Seed Itm "+identifier)
+		require.Len(t, result, 1)
+		assert.Equal(t, item.ID, result[0].ID)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		assert.Empty(t, search(t, "zzqx"+identifier))
+	})
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"PattyWagon/internal/model"
+	"PattyWagon/observability"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EnqueueJob queues a job of jobType to run as soon as a worker is free
+func (q *Queries) EnqueueJob(ctx context.Context, jobType string, payload any) (int64, error) {
+	ctx, span := observability.Tracer.Start(ctx, "repository.enqueue_job")
+	defer span.End()
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO jobs (type, payload, status, run_at, created_at, updated_at)
+		VALUES ($1, $2::jsonb, 'queued', NOW(), NOW(), NOW())
+		RETURNING id
+	`
+
+	var id int64
+	if err := q.conn(ctx).QueryRowContext(ctx, query, jobType, string(encoded)).Scan(&id); err != nil {
+		return 0, fmt.Errorf("error enqueuing job: %w", err)
+	}
+	return id, nil
+}
+
+// ClaimJob locks the next due job of one of the types for the calling worker, counting the attempt.
+// Jobs left running for longer than lockTimeout belonged to a worker that died and are claimed again.
+// SKIP LOCKED lets concurrent workers claim different jobs without waiting on each other.
+// Without a due job it returns false.
+func (q *Queries) ClaimJob(ctx context.Context, types []string, lockTimeout time.Duration) (model.Job, bool, error) {
+	ctx, span := observability.Tracer.Start(ctx, "repository.claim_job")
+	defer span.End()
+
+	query := `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE type = ANY($1)
+				AND (
+					(status = 'queued' AND run_at <= NOW())
+					OR (status = 'running' AND locked_at < NOW() - make_interval(secs => $2))
+				)
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, type, payload, status, attempts, run_at, COALESCE(last_error, ''), created_at
+	`
+
+	var job model.Job
+	err := q.conn(ctx).QueryRowContext(ctx, query, types, lockTimeout.Seconds()).Scan(
+		&job.ID,
+		&job.Type,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.RunAt,
+		&job.LastError,
+		&job.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Job{}, false, nil
+		}
+		return model.Job{}, false, fmt.Errorf("error claiming job: %w", err)
+	}
+
+	return job, true, nil
+}
+
+func (q *Queries) CompleteJob(ctx context.Context, id int64) error {
+	query := `UPDATE jobs SET status = 'done', locked_at = NULL, last_error = NULL, updated_at = NOW() WHERE id = $1`
+	if _, err := q.conn(ctx).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("error completing job: %w", err)
+	}
+	return nil
+}
+
+// RetryJob queues a failed job again to run once delay has passed. The time is taken from the database clock,
+// the one ClaimJob compares run_at with.
+func (q *Queries) RetryJob(ctx context.Context, id int64, delay time.Duration, lastError string) error {
+	query := `
+		UPDATE jobs
+		SET status = 'queued', run_at = NOW() + make_interval(secs => $2), locked_at = NULL, last_error = $3, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := q.conn(ctx).ExecContext(ctx, query, id, delay.Seconds(), lastError); err != nil {
+		return fmt.Errorf("error retrying job: %w", err)
+	}
+	return nil
+}
+
+// BuryJob moves a job that ran out of attempts to the dead letter state
+func (q *Queries) BuryJob(ctx context.Context, id int64, lastError string) error {
+	query := `UPDATE jobs SET status = 'dead', locked_at = NULL, last_error = $2, updated_at = NOW() WHERE id = $1`
+	if _, err := q.conn(ctx).ExecContext(ctx, query, id, lastError); err != nil {
+		return fmt.Errorf("error burying job: %w", err)
+	}
+	return nil
+}
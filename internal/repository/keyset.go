@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"PattyWagon/internal/model"
+	"fmt"
+	"slices"
+)
+
+// keysetPage narrows a listing ordered by (created_at, id) to the rows past the cursor and returns the clause
+// ordering and limiting them. One row more than the limit is fetched so the caller can tell whether the listing
+// continues; without a cursor the offset is applied instead.
+func keysetPage(cursor *model.Cursor, descending bool, limit, offset int, conds []string, args []interface{}) ([]string, []interface{}, string) {
+	// paging backward walks the listing in reverse, trimKeysetPage flips the rows back afterwards
+	scanDescending := descending
+	if cursor != nil && cursor.Backward {
+		scanDescending = !descending
+	}
+
+	direction, comparison := "ASC", ">"
+	if scanDescending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if cursor != nil {
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		conds = append(conds, fmt.Sprintf("(created_at, id) %s ($%d, $%d)", comparison, len(args)-1, len(args)))
+	}
+
+	clause := fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT %d", direction, direction, limit+1)
+	if cursor == nil && offset > 0 {
+		clause += fmt.Sprintf(" OFFSET %d", offset)
+	}
+
+	return conds, args, clause
+}
+
+// searchPage orders the rows of a search by relevance, newest first among equally relevant ones. Ranked rows have
+// no position a cursor could resume from, so a search pages by offset, fetching one extra row like keysetPage.
+func searchPage(relevance string, limit, offset int) string {
+	return fmt.Sprintf(" ORDER BY %s DESC, created_at DESC, id DESC LIMIT %d OFFSET %d", relevance, limit+1, offset)
+}
+
+// trimKeysetPage drops the extra row fetched by keysetPage, reporting whether it was there
+func trimKeysetPage[T any](rows []T, limit int, cursor *model.Cursor) ([]T, bool) {
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	if cursor != nil && cursor.Backward {
+		slices.Reverse(rows)
+	}
+
+	return rows, hasMore
+}
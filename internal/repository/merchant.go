@@ -4,27 +4,54 @@ import (
 	"PattyWagon/internal/constants"
 	"PattyWagon/internal/model"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// merchantScheduleColumns selects the timezone of merchants m with their opening hours and holidays as JSON
+const merchantScheduleColumns = `
+		m.timezone,
+		(
+			SELECT json_agg(json_build_object(
+				'weekday', oh.weekday,
+				'opensAt', to_char(oh.opens_at, 'HH24:MI'),
+				'closesAt', to_char(oh.closes_at, 'HH24:MI')
+			) ORDER BY oh.weekday, oh.opens_at)
+			FROM merchant_opening_hours oh
+			WHERE oh.merchant_id = m.id
+		),
+		(
+			SELECT json_agg(json_build_object(
+				'date', to_char(h.date, 'YYYY-MM-DD'),
+				'opensAt', to_char(h.opens_at, 'HH24:MI'),
+				'closesAt', to_char(h.closes_at, 'HH24:MI')
+			) ORDER BY h.date)
+			FROM merchant_holidays h
+			WHERE h.merchant_id = m.id
+		)`
+
+// InsertMerchant creates a merchant, defaulting its opening hours timezone to UTC
 func (q *Queries) InsertMerchant(ctx context.Context, data model.Merchant) (res int64, err error) {
 	query := `
 		INSERT INTO merchants (
-			user_id, name, category, image_url, latitude, longitude, created_at, updated_at
+			user_id, name, category, image_url, latitude, longitude, timezone, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, NOW(), NOW()
+			$1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'UTC'), NOW(), NOW()
 		)
 		RETURNING id
 	`
 
-	err = q.db.QueryRowContext(ctx, query,
+	err = q.conn(ctx).QueryRowContext(ctx, query,
 		data.UserID,
 		data.Name,
 		data.Category,
 		data.ImageURL,
 		data.Latitude,
 		data.Longitude,
+		data.Timezone,
 	).Scan(&data.ID)
 
 	if err != nil {
@@ -34,51 +61,17 @@ func (q *Queries) InsertMerchant(ctx context.Context, data model.Merchant) (res
 	return data.ID, nil
 }
 
-func (q *Queries) GetMerchants(ctx context.Context, filter model.FilterMerchant) (res []model.Merchant, err error) {
+// GetMerchants returns a page of merchants ordered by creation time, or by relevance when searching, reporting
+// whether more rows follow in the direction of the page
+func (q *Queries) GetMerchants(ctx context.Context, filter model.FilterMerchant) (res []model.Merchant, hasMore bool, err error) {
+	conds, args, search := merchantListConds(filter)
 	query := `
-		SELECT id, name, category, image_url, latitude, longitude, created_at
-		FROM merchants
+		SELECT id, name, category, image_url, latitude, longitude, created_at,` + merchantScheduleColumns + `,
+			` + search.highlights + `
+		FROM merchants m
 	`
-	conds := []string{}
-	args := []interface{}{}
-	argIdx := 1
-
-	// filter by MerchantID
-	if filter.MerchantID != 0 {
-		conds = append(conds, fmt.Sprintf("id = $%d", argIdx))
-		args = append(args, filter.MerchantID)
-		argIdx++
-	}
-
-	// filter by Name
-	if filter.Name != "" {
-		conds = append(conds, fmt.Sprintf("name ILIKE $%d", argIdx))
-		args = append(args, "%"+filter.Name+"%")
-		argIdx++
-	}
-
-	// filter by category
-	if filter.MerchantCategory != "" {
-		conds = append(conds, fmt.Sprintf("LOWER(category) = LOWER($%d)", argIdx))
-		args = append(args, filter.MerchantCategory)
-		argIdx++
-	}
-
-	// conditions
-	if len(conds) > 0 {
-		query += " WHERE " + strings.Join(conds, " AND ")
-	}
-
-	// sorting createdAt
-	if strings.ToLower(filter.CreatedAt) == "asc" {
-		query += " ORDER BY created_at ASC"
-	} else if strings.ToLower(filter.CreatedAt) == "desc" {
-		query += " ORDER BY created_at DESC"
-	} else {
-		query += " ORDER BY created_at DESC"
-	}
 
-	// pagination
+	// pagination, newest first unless sorted ascending
 	limit := filter.Limit
 	if limit <= 0 {
 		limit = 5
@@ -87,17 +80,32 @@ func (q *Queries) GetMerchants(ctx context.Context, filter model.FilterMerchant)
 	if offset < 0 {
 		offset = 0
 	}
-	query += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+	var pageClause string
+	if filter.Query != "" {
+		pageClause = searchPage(search.relevance, limit, offset)
+		filter.Cursor = nil
+	} else {
+		conds, args, pageClause = keysetPage(filter.Cursor, model.SortsDescending(filter.CreatedAt), limit, offset, conds, args)
+	}
+
+	// conditions
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += pageClause
 
-	rows, err := q.db.QueryContext(ctx, query, args...)
+	rows, err := q.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	defer rows.Close()
 
 	var merchants []model.Merchant
 	for rows.Next() {
-		var m model.Merchant
+		var (
+			m                                  model.Merchant
+			openingHours, holidays, highlights []byte
+		)
 		if err := rows.Scan(
 			&m.ID,
 			&m.Name,
@@ -106,31 +114,90 @@ func (q *Queries) GetMerchants(ctx context.Context, filter model.FilterMerchant)
 			&m.Latitude,
 			&m.Longitude,
 			&m.CreatedAt,
+			&m.Timezone,
+			&openingHours,
+			&holidays,
+			&highlights,
 		); err != nil {
-			return nil, err
+			return nil, false, err
+		}
+		if err := unmarshalMerchantSchedule(&m, openingHours, holidays); err != nil {
+			return nil, false, err
+		}
+		if highlights != nil {
+			if err := json.Unmarshal(highlights, &m.Highlights); err != nil {
+				return nil, false, fmt.Errorf("error decoding highlights of merchant %d: %w", m.ID, err)
+			}
 		}
 		merchants = append(merchants, m)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	res = merchants
-	return
+	res, hasMore = trimKeysetPage(merchants, limit, filter.Cursor)
+	return res, hasMore, nil
 }
 
-func (q *Queries) MerchantExists(ctx context.Context, merchantID int64) (res bool, err error) {
-	var exists bool
-	err = q.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM merchants WHERE id=$1)", merchantID).Scan(&exists)
-	if err != nil {
-		return false, err
+// CountMerchants counts every merchant matching the filter, regardless of the page
+func (q *Queries) CountMerchants(ctx context.Context, filter model.FilterMerchant) (int, error) {
+	query := "SELECT COUNT(*) FROM merchants m"
+
+	conds, args, _ := merchantListConds(filter)
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
 	}
-	if !exists {
-		return false, constants.ErrMerchantNotFound
+
+	var count int
+	if err := q.conn(ctx).QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, err
 	}
 
-	return exists, nil
+	return count, nil
+}
+
+// merchantListConds translates the filter into conditions on merchants m. The search text matches the merchant
+// name or any of its item names, like the nearby search.
+func merchantListConds(filter model.FilterMerchant) ([]string, []interface{}, nameSearch) {
+	conds := []string{}
+	args := []interface{}{}
+	search := noSearch
+
+	// filter by owner
+	if filter.UserID != 0 {
+		conds = append(conds, fmt.Sprintf("user_id = $%d", len(args)+1))
+		args = append(args, filter.UserID)
+	}
+
+	// filter by MerchantID
+	if filter.MerchantID != 0 {
+		conds = append(conds, fmt.Sprintf("id = $%d", len(args)+1))
+		args = append(args, filter.MerchantID)
+	}
+
+	// filter by Name
+	if filter.Name != "" {
+		conds = append(conds, fmt.Sprintf("name ILIKE $%d", len(args)+1))
+		args = append(args, "%"+filter.Name+"%")
+	}
+
+	// filter by category
+	if filter.MerchantCategory != "" {
+		conds = append(conds, fmt.Sprintf("LOWER(category) = LOWER($%d)", len(args)+1))
+		args = append(args, filter.MerchantCategory)
+	}
+
+	// search by name
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+
+		var cond string
+		cond, search = merchantSearchConds(len(args))
+		conds = append(conds, cond)
+	}
+
+	return conds, args, search
 }
 
 func (q *Queries) BulkInsertMerchantLocations(ctx context.Context, locations []model.MerchantLocation) error {
@@ -151,7 +218,7 @@ func (q *Queries) BulkInsertMerchantLocations(ctx context.Context, locations []m
 
 	query += strings.Join(placeholders, ", ")
 
-	_, err := q.db.ExecContext(ctx, query, values...)
+	_, err := q.conn(ctx).ExecContext(ctx, query, values...)
 	if err != nil {
 		return fmt.Errorf("error bulk inserting merchant locations: %w", err)
 	}
@@ -159,10 +226,181 @@ func (q *Queries) BulkInsertMerchantLocations(ctx context.Context, locations []m
 	return nil
 }
 
+func (q *Queries) UpdateMerchant(ctx context.Context, data model.Merchant) (res model.Merchant, err error) {
+	query := `
+		UPDATE merchants
+		SET name = $2, category = $3, image_url = $4, latitude = $5, longitude = $6, timezone = $7, updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, user_id, name, category, image_url, latitude, longitude, timezone, created_at, updated_at
+	`
+
+	err = q.conn(ctx).QueryRowContext(ctx, query,
+		data.ID,
+		data.Name,
+		data.Category,
+		data.ImageURL,
+		data.Latitude,
+		data.Longitude,
+		data.Timezone,
+	).Scan(
+		&res.ID,
+		&res.UserID,
+		&res.Name,
+		&res.Category,
+		&res.ImageURL,
+		&res.Latitude,
+		&res.Longitude,
+		&res.Timezone,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Merchant{}, constants.ErrMerchantNotFound
+		}
+		return model.Merchant{}, fmt.Errorf("error updating merchant: %w", err)
+	}
+
+	return res, nil
+}
+
+func (q *Queries) DeleteMerchant(ctx context.Context, userID, merchantID int64) error {
+	result, err := q.conn(ctx).ExecContext(ctx, "DELETE FROM merchants WHERE id = $1 AND user_id = $2", merchantID, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting merchant: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return constants.ErrMerchantNotFound
+	}
+
+	return nil
+}
+
+// ReplaceMerchantLocations swaps every H3 cell of a merchant for the given ones in a single statement,
+// so nearby search never observes a merchant without cells
+func (q *Queries) ReplaceMerchantLocations(ctx context.Context, merchantID int64, locations []model.MerchantLocation) error {
+	query := `
+		WITH deleted AS (
+			DELETE FROM merchant_locations WHERE merchant_id = $1
+		)
+	`
+
+	values := []interface{}{merchantID}
+	placeholders := []string{}
+
+	for i, loc := range locations {
+		placeholders = append(placeholders,
+			fmt.Sprintf("($%d::BIGINT, $%d::BIGINT, $%d::SMALLINT, NOW(), NOW())", i*3+2, i*3+3, i*3+4))
+		values = append(values, loc.MerchantID, loc.H3Index, loc.Resolution)
+	}
+
+	if len(placeholders) > 0 {
+		query += `INSERT INTO merchant_locations (merchant_id, h3_index, resolution, created_at, updated_at) VALUES ` +
+			strings.Join(placeholders, ", ")
+	} else {
+		query += `SELECT 1`
+	}
+
+	_, err := q.conn(ctx).ExecContext(ctx, query, values...)
+	if err != nil {
+		return fmt.Errorf("error replacing merchant locations: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceMerchantOpeningHours swaps every opening hours range of a merchant for the given ones
+func (q *Queries) ReplaceMerchantOpeningHours(ctx context.Context, merchantID int64, openingHours []model.OpeningHours) error {
+	query := `
+		WITH deleted AS (
+			DELETE FROM merchant_opening_hours WHERE merchant_id = $1
+		)
+	`
+
+	values := []interface{}{merchantID}
+	placeholders := []string{}
+
+	for i, hours := range openingHours {
+		placeholders = append(placeholders,
+			fmt.Sprintf("($1, $%d::SMALLINT, $%d::TIME, $%d::TIME)", i*3+2, i*3+3, i*3+4))
+		values = append(values, int(hours.Weekday), hours.OpensAt, hours.ClosesAt)
+	}
+
+	if len(placeholders) > 0 {
+		query += `INSERT INTO merchant_opening_hours (merchant_id, weekday, opens_at, closes_at) VALUES ` +
+			strings.Join(placeholders, ", ")
+	} else {
+		query += `SELECT 1`
+	}
+
+	_, err := q.conn(ctx).ExecContext(ctx, query, values...)
+	if err != nil {
+		return fmt.Errorf("error replacing merchant opening hours: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceMerchantHolidays swaps every holiday override of a merchant for the given ones. The old overrides are
+// deleted by a separate statement first, so dates kept across the swap do not collide on the unique constraint;
+// run it in a transaction.
+func (q *Queries) ReplaceMerchantHolidays(ctx context.Context, merchantID int64, holidays []model.HolidayOverride) error {
+	_, err := q.conn(ctx).ExecContext(ctx, "DELETE FROM merchant_holidays WHERE merchant_id = $1", merchantID)
+	if err != nil {
+		return fmt.Errorf("error replacing merchant holidays: %w", err)
+	}
+
+	if len(holidays) == 0 {
+		return nil
+	}
+
+	values := []interface{}{merchantID}
+	placeholders := []string{}
+
+	for i, holiday := range holidays {
+		placeholders = append(placeholders,
+			fmt.Sprintf("($1, $%d::DATE, $%d::TIME, $%d::TIME)", i*3+2, i*3+3, i*3+4))
+		values = append(values, holiday.Date, holiday.OpensAt, holiday.ClosesAt)
+	}
+
+	query := `INSERT INTO merchant_holidays (merchant_id, date, opens_at, closes_at) VALUES ` +
+		strings.Join(placeholders, ", ")
+
+	_, err = q.conn(ctx).ExecContext(ctx, query, values...)
+	if err != nil {
+		return fmt.Errorf("error replacing merchant holidays: %w", err)
+	}
+
+	return nil
+}
+
+// unmarshalMerchantSchedule decodes the opening hours and holidays selected by merchantScheduleColumns
+func unmarshalMerchantSchedule(merchant *model.Merchant, openingHours, holidays []byte) error {
+	if openingHours != nil {
+		if err := json.Unmarshal(openingHours, &merchant.OpeningHours); err != nil {
+			return err
+		}
+	}
+
+	if holidays != nil {
+		if err := json.Unmarshal(holidays, &merchant.Holidays); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (q *Queries) GetMerchantCount(ctx context.Context) (int64, error) {
 	var count int64
 	query := `SELECT COUNT(*) FROM merchants`
-	err := q.db.QueryRowContext(ctx, query).Scan(&count)
+	err := q.conn(ctx).QueryRowContext(ctx, query).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
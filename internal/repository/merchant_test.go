@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMerchantsScopedToOwner(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, _, _ := seedMerchantWithItem(t, repo)
+	other, _, _ := seedMerchantWithItem(t, repo)
+
+	result, _, err := repo.GetMerchants(context.TODO(), model.FilterMerchant{
+		UserID: merchant.UserID,
+		Limit:  10,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, merchant.ID, result[0].ID)
+
+	result, _, err = repo.GetMerchants(context.TODO(), model.FilterMerchant{
+		UserID:     merchant.UserID,
+		MerchantID: other.ID,
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, result)
+}
+
+func TestUpdateMerchantRollsBackWhenCellsFail(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, _, cellID := seedMerchantWithItem(t, repo)
+	ctx := context.TODO()
+
+	moved := merchant
+	moved.Latitude = -6.6000
+	moved.Longitude = 106.8000
+
+	err := repo.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := repo.UpdateMerchant(ctx, moved); err != nil {
+			return err
+		}
+		// no merchant -1, so the foreign key fails the insert
+		return repo.ReplaceMerchantLocations(ctx, merchant.ID, []model.MerchantLocation{
+			{MerchantID: -1, H3Index: cellID + 1, Resolution: 8},
+		})
+	})
+	require.Error(t, err)
+
+	result, err := repo.GetMerchantByID(ctx, merchant.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, merchant.Latitude, result.Latitude, 0.000001)
+	assert.InDelta(t, merchant.Longitude, result.Longitude, 0.000001)
+
+	// the old cell survives the failed replacement
+	found, err := repo.GetMerchantByCellID(ctx, cellID)
+	require.NoError(t, err)
+	assert.Equal(t, merchant.ID, found.ID)
+}
+
+func TestDeleteMerchantScopedToOwner(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, _, _ := seedMerchantWithItem(t, repo)
+	other, _, _ := seedMerchantWithItem(t, repo)
+
+	err := repo.DeleteMerchant(context.TODO(), merchant.UserID, other.ID)
+	assert.ErrorIs(t, err, constants.ErrMerchantNotFound)
+
+	_, err = repo.GetMerchantByID(context.TODO(), other.ID)
+	assert.Nil(t, err)
+}
+
+func TestGetMerchantsKeysetPagination(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, _, _ := seedMerchantWithItem(t, repo)
+
+	ids := []int64{merchant.ID}
+	for range 4 {
+		extra := merchant
+		id, err := repo.InsertMerchant(context.TODO(), extra)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	filter := model.FilterMerchant{UserID: merchant.UserID, Limit: 2}
+
+	total, err := repo.CountMerchants(context.TODO(), filter)
+	require.NoError(t, err)
+	assert.Equal(t, len(ids), total)
+
+	// walk every page forward, newest first
+	var (
+		walked  []int64
+		pages   [][]model.Merchant
+		hasMore = true
+	)
+	for hasMore {
+		var page []model.Merchant
+		page, hasMore, err = repo.GetMerchants(context.TODO(), filter)
+		require.NoError(t, err)
+		require.NotEmpty(t, page)
+
+		for _, m := range page {
+			walked = append(walked, m.ID)
+		}
+		pages = append(pages, page)
+
+		last := page[len(page)-1]
+		filter.Cursor = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	assert.Len(t, pages, 3)
+	assert.ElementsMatch(t, ids, walked)
+
+	// and back from the last page to the second
+	first := pages[2][0]
+	filter.Cursor = &model.Cursor{CreatedAt: first.CreatedAt, ID: first.ID, Backward: true}
+	page, hasMore, err := repo.GetMerchants(context.TODO(), filter)
+	require.NoError(t, err)
+	assert.True(t, hasMore)
+	assert.Equal(t, pages[1], page)
+}
+
+func TestGetMerchantsSearch(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, item, _ := seedMerchantWithItem(t, repo)
+	identifier := merchant.Name[len("Seed Merchant "):]
+
+	search := func(t *testing.T, query string) []model.Merchant {
+		t.Helper()
+		filter := model.FilterMerchant{UserID: merchant.UserID, Query: query, Limit: 5}
+
+		result, _, err := repo.GetMerchants(context.TODO(), filter)
+		require.NoError(t, err)
+
+		total, err := repo.CountMerchants(context.TODO(), filter)
+		require.NoError(t, err)
+		assert.Equal(t, len(result), total)
+		return result
+	}
+
+	t.Run("ItemName", func(t *testing.T) {
+		result := search(t, item.Name)
+		require.Len(t, result, 1)
+		assert.Equal(t, merchant.ID, result[0].ID)
+		assert.Contains(t, result[0].Highlights, "<mark>Seed</mark> <mark>Item</mark> <mark>"+identifier+"</mark>")
+	})
+
+	t.Run("Typo", func(t *testing.T) {
+		result := search(t, "Seed Merchnt "+identifier)
+		require.Len(t, result, 1)
+		assert.Equal(t, merchant.ID, result[0].ID)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		assert.Empty(t, search(t, "zzqx"+identifier))
+	})
+}
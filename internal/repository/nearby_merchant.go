@@ -1,42 +1,42 @@
 package repository
 
 import (
+	"PattyWagon/internal/constants"
 	"PattyWagon/internal/model"
-	"PattyWagon/logger"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
-
-	"github.com/rs/zerolog/log"
 )
 
 const (
-	baseMerchantQueryWithCell = `
-WITH matching_merchants AS (
-    -- Base merchants query
-    SELECT DISTINCT ml.merchant_id
-    FROM merchant_locations ml
-    INNER JOIN merchants m ON m.id = ml.merchant_id`
-
-	baseMerchantQueryWithoutCell = `
-WITH matching_merchants AS (
-    -- Base merchants query
-    SELECT DISTINCT m.id as merchant_id
-		FROM merchants as m
-`
-	unionItemQuery = `
-    UNION
-    -- Merchants matching by item name
-    SELECT DISTINCT ml.merchant_id
-    FROM merchant_locations ml
-    INNER JOIN merchants m ON m.id = ml.merchant_id
-    INNER JOIN items i ON i.merchant_id = m.id
-  	`
-
-	finalSelectQuery = `
+	// haversineDistance is the great-circle distance in meters between a merchant and the location in $1 (lat), $2 (long)
+	haversineDistance = `2 * 6371000 * ASIN(SQRT(LEAST(1,
+    POWER(SIN(RADIANS(m.latitude::float8 - $1::float8) / 2), 2) +
+    COS(RADIANS($1::float8)) * COS(RADIANS(m.latitude::float8)) *
+    POWER(SIN(RADIANS(m.longitude::float8 - $2::float8) / 2), 2)
+  )))`
+
+	// geographyDistance is the spheroid distance in meters the GiST index on merchants.location orders by
+	geographyDistance = `m.location <-> ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography`
+
+	// listMerchantPage orders the merchants matching the page conditions (%[2]s) by relevance (%[6]s), then by a
+	// distance expression (%[1]s), breaking ties by id so pages never overlap. Both counts are selected from a single
+	// row the page is left joined to, so a page past the end still reports them.
+	listMerchantPage = `
+WITH page AS (
+    SELECT m.id, %[1]s AS distance, %[6]s AS relevance
+    FROM merchants m
+    WHERE %[2]s
+    ORDER BY relevance DESC, distance, m.id
+    LIMIT %[4]d OFFSET %[5]d
 )
 SELECT
+  (SELECT COUNT(*) FROM merchants m WHERE %[3]s) AS total,
+  (SELECT COUNT(*) FROM merchants m WHERE %[2]s) AS matched,
   m.id,
   m.name,
   m.category,
@@ -44,20 +44,60 @@ SELECT
   m.latitude,
   m.longitude,
   m.created_at,
-  json_agg(
-    json_build_object(
-      'id', i.id,
-      'name', i.name,
-      'category', i.category,
-      'price', i.price,
-      'image_url', i.image_url,
-      'created_at', i.created_at AT TIME ZONE 'UTC'
-    )
-  ) as items
-FROM matching_merchants
-INNER JOIN merchants as m on m.id = matching_merchants.merchant_id
-LEFT JOIN items as i on i.merchant_id = matching_merchants.merchant_id
-GROUP BY m.id`
+  page.distance,
+  merchant_is_open(m.id, NOW()) AS is_open,
+  merchant_items.items,
+  %[7]s AS highlights
+FROM (SELECT 1) AS counts
+LEFT JOIN page ON true
+LEFT JOIN merchants m ON m.id = page.id
+LEFT JOIN LATERAL (
+    SELECT json_agg(
+      json_build_object(
+        'id', i.id,
+        'name', i.name,
+        'category', i.category,
+        'price', i.price,
+        'image_url', i.image_url,
+        'is_available', i.is_available,
+        'stock', i.stock,
+        'created_at', i.created_at AT TIME ZONE 'UTC',
+        'modifier_groups', ` + itemModifierGroups + `
+      )
+      ORDER BY i.id
+    ) AS items
+    FROM items i
+    WHERE i.merchant_id = m.id AND i.is_available AND (i.stock IS NULL OR i.stock > 0)
+) merchant_items ON true
+ORDER BY page.relevance DESC, page.distance, page.id`
+
+	// searchQuery parses the search text in $%[1]d as a web search, words are matched whole
+	searchQuery = `websearch_to_tsquery('simple', $%[1]d::text)`
+
+	// searchMatches tells whether a name matches the search text in $%[1]d, either by its words or, to tolerate
+	// typos, by trigram similarity with one of its words
+	searchMatches = `(%[2]s.search_vector @@ websearch_to_tsquery('simple', $%[1]d::text) OR $%[1]d::text <%% %[2]s.name)`
+
+	// searchRelevance ranks a name against the search text in $%[1]d
+	searchRelevance = `(ts_rank(%[2]s.search_vector, websearch_to_tsquery('simple', $%[1]d::text)) + word_similarity($%[1]d::text, %[2]s.name))`
+
+	// searchHighlights lists the merchant and item names matching the search text in $%[1]d, with the matched words
+	// wrapped in <mark>
+	searchHighlights = `(
+    SELECT json_agg(matched.highlight ORDER BY matched.position)
+    FROM (
+      SELECT 0 AS position, ts_headline('simple', m.name, %[2]s, '%[5]s') AS highlight
+      WHERE %[3]s
+      UNION ALL
+      SELECT i.id, ts_headline('simple', i.name, %[2]s, '%[5]s')
+      FROM items i
+      WHERE i.merchant_id = m.id AND %[4]s
+    ) matched
+  )`
+
+	searchHighlightOptions = "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"
+
+	defaultMerchantPageLimit = 5
 )
 
 const (
@@ -70,6 +110,7 @@ SELECT
   m.latitude,
   m.longitude,
   m.created_at,
+  merchant_is_open(m.id, NOW()) as is_open,
   json_agg(
     json_build_object(
       'id', i.id,
@@ -77,167 +118,330 @@ SELECT
       'category', i.category,
       'price', i.price,
       'image_url', i.image_url,
-      'created_at', i.created_at AT TIME ZONE 'UTC'
+      'is_available', i.is_available,
+      'stock', i.stock,
+      'created_at', i.created_at AT TIME ZONE 'UTC',
+      'modifier_groups', ` + itemModifierGroups + `
     )
   ) as items
 FROM merchants as m
 LEFT JOIN items as i on i.merchant_id = m.id
 WHERE m.id=$1
 GROUP BY m.id`
+
+	getMerchantByID = `
+SELECT id, user_id, name, category, image_url, latitude, longitude, created_at, updated_at,` + merchantScheduleColumns + `
+FROM merchants m
+WHERE id = $1`
+
+	getMerchantByCellID = `
+SELECT m.id, m.user_id, m.name, m.category, m.image_url, m.latitude, m.longitude, m.created_at, m.updated_at
+FROM merchant_locations ml
+INNER JOIN merchants m ON m.id = ml.merchant_id
+WHERE ml.h3_index = $1
+ORDER BY m.id
+LIMIT 1`
 )
 
 func (q *Queries) GetMerchantByID(ctx context.Context, id int64) (model.Merchant, error) {
-	panic("unimplemented")
+	return q.getMerchantByID(ctx, getMerchantByID, id)
 }
 
-func (q *Queries) GetMerchantByCellID(ctx context.Context, cellID int64) (model.Merchant, error) {
-	panic("unimplemented")
+// GetMerchantByIDForUpdate returns a merchant and locks its row until the transaction ends, so concurrent updates
+// of the merchant apply one after another; run it in a transaction.
+func (q *Queries) GetMerchantByIDForUpdate(ctx context.Context, id int64) (model.Merchant, error) {
+	return q.getMerchantByID(ctx, getMerchantByID+"\nFOR UPDATE", id)
 }
 
-func (q *Queries) ListMerchantWithItems(ctx context.Context, filter model.ListMerchantWithItemParams) ([]model.MerchantItem, error) {
-	log := logger.GetLoggerFromContext(ctx)
-	// Build the query dynamically
-	var query string
+func (q *Queries) getMerchantByID(ctx context.Context, query string, id int64) (model.Merchant, error) {
+	var (
+		merchant               model.Merchant
+		openingHours, holidays []byte
+	)
+	err := q.conn(ctx).QueryRowContext(ctx, query, id).Scan(
+		&merchant.ID,
+		&merchant.UserID,
+		&merchant.Name,
+		&merchant.Category,
+		&merchant.ImageURL,
+		&merchant.Latitude,
+		&merchant.Longitude,
+		&merchant.CreatedAt,
+		&merchant.UpdatedAt,
+		&merchant.Timezone,
+		&openingHours,
+		&holidays,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Merchant{}, constants.ErrMerchantNotFound
+		}
+		return model.Merchant{}, err
+	}
+
+	if err := unmarshalMerchantSchedule(&merchant, openingHours, holidays); err != nil {
+		return model.Merchant{}, err
+	}
 
-	args := []interface{}{}
-	argIdx := 1
+	return merchant, nil
+}
+
+// IsMerchantOpen tells whether a merchant is open right now
+func (q *Queries) IsMerchantOpen(ctx context.Context, merchantID int64) (bool, error) {
+	var isOpen bool
+	err := q.conn(ctx).QueryRowContext(ctx,
+		"SELECT merchant_is_open(id, NOW()) FROM merchants WHERE id = $1", merchantID,
+	).Scan(&isOpen)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, constants.ErrMerchantNotFound
+		}
+		return false, err
+	}
 
-	// Add conditions to base query
-	baseConds := []string{}
+	return isOpen, nil
+}
 
-	if filter.Cell != nil {
-		query = baseMerchantQueryWithCell
+// GetMerchantByCellID returns the earliest registered merchant located in the given H3 cell
+func (q *Queries) GetMerchantByCellID(ctx context.Context, cellID int64) (model.Merchant, error) {
+	var merchant model.Merchant
+	err := q.conn(ctx).QueryRowContext(ctx, getMerchantByCellID, cellID).Scan(
+		&merchant.ID,
+		&merchant.UserID,
+		&merchant.Name,
+		&merchant.Category,
+		&merchant.ImageURL,
+		&merchant.Latitude,
+		&merchant.Longitude,
+		&merchant.CreatedAt,
+		&merchant.UpdatedAt,
+	)
 
-		baseConds = append(baseConds, fmt.Sprintf(" ml.h3_index = $%d", argIdx))
-		args = append(args, filter.Cell.CellID)
-		argIdx++
-	} else {
-		query = baseMerchantQueryWithoutCell
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Merchant{}, constants.ErrMerchantNotFound
+		}
+		return model.Merchant{}, err
 	}
 
-	if filter.MerchantCategory != nil {
-		baseConds = append(baseConds, fmt.Sprintf(" m.category = $%d", argIdx))
-		args = append(args, *filter.MerchantCategory)
-		argIdx++
+	return merchant, nil
+}
+
+// ListMerchantWithItems pages the merchants matching the filter by their haversine distance from filter.Location.
+// When cells are given only merchants inside them are paged, while the total still counts every matching merchant.
+func (q *Queries) ListMerchantWithItems(ctx context.Context, filter model.ListMerchantWithItemParams) (model.NearbyMerchantPage, error) {
+	args := []interface{}{filter.Location.Lat, filter.Location.Long}
+
+	totalConds, search, args := merchantFilterConds(filter.MerchantParams, args)
+	conds := totalConds
+
+	if len(filter.Cells) > 0 {
+		cellIDs := make([]int64, 0, len(filter.Cells))
+		for _, cell := range filter.Cells {
+			cellIDs = append(cellIDs, cell.CellID)
+		}
+
+		args = append(args, cellIDs)
+		conds = append(slices.Clip(conds), fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM merchant_locations ml WHERE ml.merchant_id = m.id AND ml.h3_index = ANY($%d::BIGINT[]))",
+			len(args)))
 	}
 
-	if filter.Name != nil {
-		baseConds = append(baseConds, fmt.Sprintf(" m.name ILIKE $%d", argIdx))
-		args = append(args, "%"+*filter.Name+"%")
-		argIdx++
+	return q.listMerchantPage(ctx, haversineDistance, conds, totalConds, args, search, filter.MerchantParams)
+}
+
+// ListNearestMerchantsWithItems finds merchants within the radius of a location through the GiST index on
+// merchants.location, ordered nearest first with KNN and paginated in the database
+func (q *Queries) ListNearestMerchantsWithItems(ctx context.Context, filter model.ListNearestMerchantParams) (model.NearbyMerchantPage, error) {
+	args := []interface{}{filter.Location.Lat, filter.Location.Long, filter.RadiusInMeters}
+
+	conds := []string{"ST_DWithin(m.location, ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography, $3)"}
+	filterConds, search, args := merchantFilterConds(filter.MerchantParams, args)
+	conds = append(conds, filterConds...)
+
+	return q.listMerchantPage(ctx, geographyDistance, conds, conds, args, search, filter.MerchantParams)
+}
+
+// nameSearch holds the expressions ranking and highlighting the rows of a search, noSearch ranks every row the same
+type nameSearch struct {
+	relevance  string
+	highlights string
+}
+
+var noSearch = nameSearch{relevance: "0", highlights: "NULL"}
+
+// merchantSearchConds matches the merchants m whose name or any item name matches the search text in $idx
+func merchantSearchConds(idx int) (string, nameSearch) {
+	merchantMatches := fmt.Sprintf(searchMatches, idx, "m")
+	itemMatches := fmt.Sprintf(searchMatches, idx, "i")
+
+	cond := fmt.Sprintf(
+		"(%s OR EXISTS (SELECT 1 FROM items i WHERE i.merchant_id = m.id AND %s))", merchantMatches, itemMatches)
+
+	return cond, nameSearch{
+		// a merchant ranks as high as its best matching name
+		relevance: fmt.Sprintf(
+			"GREATEST(%s, COALESCE((SELECT MAX(%s) FROM items i WHERE i.merchant_id = m.id AND %s), 0))",
+			fmt.Sprintf(searchRelevance, idx, "m"), fmt.Sprintf(searchRelevance, idx, "i"), itemMatches),
+		highlights: fmt.Sprintf(searchHighlights,
+			idx, fmt.Sprintf(searchQuery, idx), merchantMatches, itemMatches, searchHighlightOptions),
 	}
+}
 
-	// Add base conditions to query
-	if len(baseConds) > 0 {
-		query += " " + "WHERE" + " " + strings.Join(baseConds, " AND")
+// itemSearchConds matches the items i whose name matches the search text in $idx
+func itemSearchConds(idx int) (string, nameSearch) {
+	return fmt.Sprintf(searchMatches, idx, "i"), nameSearch{
+		relevance: fmt.Sprintf(searchRelevance, idx, "i"),
+		highlights: fmt.Sprintf("json_build_array(ts_headline('simple', i.name, %s, '%s'))",
+			fmt.Sprintf(searchQuery, idx), searchHighlightOptions),
 	}
+}
 
-	// Add UNION conditionally if Name filter exists
-	if filter.Name != nil {
-		// Add same conditions for union query
-		unionConds := []string{}
-		query += unionItemQuery
-
-		// Add h3_index for union query
-		if filter.Cell != nil {
-			unionConds = append(unionConds, fmt.Sprintf(" ml.h3_index = $%d", argIdx))
-			args = append(args, filter.Cell.CellID)
-			argIdx++
-		}
+// merchantFilterConds translates the merchant filters into conditions on merchants m, appending their arguments.
+// A name or search text matches either the merchant or any of its items.
+func merchantFilterConds(params model.MerchantParams, args []interface{}) ([]string, nameSearch, []interface{}) {
+	conds := []string{}
+	search := noSearch
 
-		if filter.MerchantCategory != nil {
-			unionConds = append(unionConds, fmt.Sprintf(" m.category = $%d", argIdx))
-			args = append(args, *filter.MerchantCategory)
-			argIdx++
-		}
+	if params.MerchantID != nil {
+		args = append(args, *params.MerchantID)
+		conds = append(conds, fmt.Sprintf("m.id = $%d", len(args)))
+	}
 
-		unionConds = append(unionConds, fmt.Sprintf(" i.name ILIKE $%d", argIdx))
-		args = append(args, "%"+*filter.Name+"%")
-		argIdx++
+	if params.MerchantCategory != nil {
+		args = append(args, *params.MerchantCategory)
+		conds = append(conds, fmt.Sprintf("m.category = $%d", len(args)))
+	}
 
-		if len(unionConds) > 0 {
-			query += " " + "WHERE " + strings.Join(unionConds, " AND")
-		}
+	if params.Name != nil {
+		args = append(args, "%"+*params.Name+"%")
+		conds = append(conds, fmt.Sprintf(
+			"(m.name ILIKE $%d OR EXISTS (SELECT 1 FROM items i WHERE i.merchant_id = m.id AND i.name ILIKE $%d))",
+			len(args), len(args)))
+	}
+
+	if params.OpenNow {
+		conds = append(conds, "merchant_is_open(m.id, NOW())")
 	}
 
-	// Complete the query
-	query += finalSelectQuery
+	if params.Query != nil {
+		args = append(args, *params.Query)
 
-	// Add final WHERE conditions if needed
-	finalConds := []string{}
-	if filter.MerchantID != nil {
-		finalConds = append(finalConds, fmt.Sprintf("WHERE m.id = $%d", argIdx))
-		args = append(args, *filter.MerchantID)
-		argIdx++
+		var cond string
+		cond, search = merchantSearchConds(len(args))
+		conds = append(conds, cond)
 	}
 
-	if len(finalConds) > 0 {
-		// Insert WHERE clause before GROUP BY
-		query = strings.Replace(query, "GROUP BY m.id", strings.Join(finalConds, " AND ")+" GROUP BY m.id", 1)
+	return conds, search, args
+}
+
+func (q *Queries) listMerchantPage(ctx context.Context, distance string, conds, totalConds []string, args []interface{}, search nameSearch, params model.MerchantParams) (model.NearbyMerchantPage, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultMerchantPageLimit
 	}
+	offset := max(params.Offset, 0)
+
+	query := fmt.Sprintf(listMerchantPage,
+		distance, joinConds(conds), joinConds(totalConds), limit, offset, search.relevance, search.highlights)
 
-	// Add sorting
-	// if filter.SortingOrder != nil {
-	// 	if strings.ToLower(*filter.SortingOrder) == "asc" {
-	// 		query += " ORDER BY m.created_at ASC"
-	// 	} else {
-	// 		query += " ORDER BY m.created_at DESC"
-	// 	}
-	// } else {
-	// 	query += " ORDER BY m.created_at DESC"
-	// }
-
-	rows, err := q.db.QueryContext(ctx, query, args...)
+	rows, err := q.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return model.NearbyMerchantPage{}, err
 	}
 	defer rows.Close()
 
-	var merchantItems []model.MerchantItem
+	return scanMerchantPage(rows)
+}
 
-	for rows.Next() {
-		var merchantItem model.MerchantItem
+func joinConds(conds []string) string {
+	if len(conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// scanMerchantPage reads the counts and the merchants of a page, each carrying its items aggregated as JSON.
+// The merchant columns are NULL on the only row of an empty page.
+func scanMerchantPage(rows *sql.Rows) (model.NearbyMerchantPage, error) {
+	var page model.NearbyMerchantPage
 
-		var items []byte
+	for rows.Next() {
+		var (
+			merchantItem model.MerchantItem
+			id           sql.NullInt64
+			name         sql.NullString
+			imageURL     sql.NullString
+			latitude     sql.NullFloat64
+			longitude    sql.NullFloat64
+			createdAt    sql.NullTime
+			distance     sql.NullFloat64
+			isOpen       sql.NullBool
+			items        []byte
+			highlights   []byte
+		)
 
 		err := rows.Scan(
-			&merchantItem.Merchant.ID,
-			&merchantItem.Merchant.Name,
+			&page.Total,
+			&page.Matched,
+			&id,
+			&name,
 			&merchantItem.Merchant.Category,
-			&merchantItem.Merchant.ImageURL,
-			&merchantItem.Merchant.Latitude,
-			&merchantItem.Merchant.Longitude,
-			&merchantItem.Merchant.CreatedAt,
+			&imageURL,
+			&latitude,
+			&longitude,
+			&createdAt,
+			&distance,
+			&isOpen,
 			&items,
+			&highlights,
 		)
-
 		if err != nil {
-			return nil, err
+			return model.NearbyMerchantPage{}, err
+		}
+
+		if !id.Valid {
+			continue
 		}
 
+		merchantItem.Merchant.ID = id.Int64
+		merchantItem.Merchant.Name = name.String
+		merchantItem.Merchant.ImageURL = imageURL.String
+		merchantItem.Merchant.Latitude = latitude.Float64
+		merchantItem.Merchant.Longitude = longitude.Float64
+		merchantItem.Merchant.CreatedAt = createdAt.Time
+		merchantItem.DistanceInMeters = distance.Float64
+		merchantItem.IsOpen = isOpen.Bool
+
 		if items != nil {
 			if err := json.Unmarshal(items, &merchantItem.Items); err != nil {
-				log.Err(err)
-				return nil, err
+				return model.NearbyMerchantPage{}, fmt.Errorf("error decoding items of merchant %d: %w", id.Int64, err)
 			}
 		}
 
-		merchantItems = append(merchantItems, merchantItem)
+		if highlights != nil {
+			if err := json.Unmarshal(highlights, &merchantItem.Highlights); err != nil {
+				return model.NearbyMerchantPage{}, fmt.Errorf("error decoding highlights of merchant %d: %w", id.Int64, err)
+			}
+		}
+
+		page.Merchants = append(page.Merchants, merchantItem)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return model.NearbyMerchantPage{}, err
 	}
 
-	return merchantItems, nil
+	return page, nil
 }
 
+// GetMerchantWithItems returns a merchant with every one of its items, including the ones that cannot be sold
 func (q *Queries) GetMerchantWithItems(ctx context.Context, merchantID int64) (model.MerchantItem, error) {
-
 	var merchantItem model.MerchantItem
 	var items []byte
 
-	err := q.db.QueryRowContext(ctx, getMerchantWithItems, merchantID).Scan(
+	err := q.conn(ctx).QueryRowContext(ctx, getMerchantWithItems, merchantID).Scan(
 		&merchantItem.Merchant.ID,
 		&merchantItem.Merchant.Name,
 		&merchantItem.Merchant.Category,
@@ -245,17 +449,20 @@ func (q *Queries) GetMerchantWithItems(ctx context.Context, merchantID int64) (m
 		&merchantItem.Merchant.Latitude,
 		&merchantItem.Merchant.Longitude,
 		&merchantItem.Merchant.CreatedAt,
+		&merchantItem.IsOpen,
 		&items,
 	)
 
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.MerchantItem{}, constants.ErrMerchantNotFound
+		}
 		return model.MerchantItem{}, err
 	}
 
 	if items != nil {
 		if err := json.Unmarshal(items, &merchantItem.Items); err != nil {
-			log.Err(err)
-			return model.MerchantItem{}, err
+			return model.MerchantItem{}, fmt.Errorf("error decoding items of merchant %d: %w", merchantID, err)
 		}
 	}
 
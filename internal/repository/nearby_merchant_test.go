@@ -1,55 +1,147 @@
 package repository
 
 import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/constants"
 	"PattyWagon/internal/database"
 	"PattyWagon/internal/model"
 	"context"
+	"database/sql"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupRepo(t *testing.T) *Queries {
 	t.Helper()
 
-	db := database.New(
-		"localhost",
-		"5432",
-		"patty-wagon-dev",
-		"postgres",
-		"postgres",
-		"public",
-		&database.ConnectionPoolConfig{
-			MaxOpenConns:    int(database.MaxOpenConns),
-			MaxIdleConns:    int(database.MaxIdleConns),
-			ConnMaxIdleTime: time.Duration(database.ConnMaxIdleTime * int64(time.Second)),
-			ConnMaxLifeTime: time.Duration(database.ConnMaxLifeTime * int64(time.Second)),
-		},
-	)
+	db := database.New(config.Database{
+		Host:                     "localhost",
+		Port:                     "5432",
+		Name:                     "patty-wagon-dev",
+		Username:                 "postgres",
+		Password:                 "postgres",
+		Schema:                   "public",
+		MaxOpenConns:             20,
+		MaxIdleConns:             10,
+		ConnMaxIdleTimeInSeconds: 60,
+		ConnMaxLifeTimeInSeconds: 300,
+	})
 
 	repo := New(db)
 	return repo
 }
 
+// seedMerchantWithItem inserts an admin owning one merchant with a single item located in a unique cell.
+// Everything is removed again when the test finishes.
+func seedMerchantWithItem(t *testing.T, repo *Queries) (model.Merchant, model.Item, int64) {
+	t.Helper()
+	ctx := context.TODO()
+
+	identifier := uuid.NewString()[:8]
+	user, err := repo.InsertUser(ctx, model.User{
+		Username: sql.NullString{String: "seed-" + identifier, Valid: true},
+		Email:    sql.NullString{String: "seed-" + identifier + "@pattywagon.test", Valid: true},
+		Role:     0,
+	}, "hash")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		repo.db.ExecContext(context.Background(), "DELETE FROM users WHERE id = $1", user.ID)
+	})
+
+	merchant := model.Merchant{
+		UserID:    user.ID,
+		Name:      "Seed Merchant " + identifier,
+		Category:  stringPtr("BoothKiosk"),
+		ImageURL:  "http://localhost:9000/images/seed.jpg",
+		Latitude:  -6.2088,
+		Longitude: 106.8456,
+	}
+	merchant.ID, err = repo.InsertMerchant(ctx, merchant)
+	require.NoError(t, err)
+
+	cellID := time.Now().UnixNano()
+	err = repo.BulkInsertMerchantLocations(ctx, []model.MerchantLocation{
+		{MerchantID: merchant.ID, H3Index: cellID, Resolution: 8},
+	})
+	require.NoError(t, err)
+
+	item := model.Item{
+		MerchantID:  merchant.ID,
+		Name:        "Seed Item " + identifier,
+		Category:    "Food",
+		Price:       15000,
+		ImageURL:    "http://localhost:9000/images/seed-item.jpg",
+		IsAvailable: true,
+	}
+	item.ID, err = repo.CreateItems(ctx, item)
+	require.NoError(t, err)
+
+	return merchant, item, cellID
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestGetMerchantByID(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, _, _ := seedMerchantWithItem(t, repo)
+
+	t.Run("Valid", func(t *testing.T) {
+		result, err := repo.GetMerchantByID(context.TODO(), merchant.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, merchant.ID, result.ID)
+		assert.Equal(t, merchant.UserID, result.UserID)
+		assert.Equal(t, merchant.Name, result.Name)
+		assert.Equal(t, *merchant.Category, *result.Category)
+		assert.InDelta(t, merchant.Latitude, result.Latitude, 0.000001)
+		assert.InDelta(t, merchant.Longitude, result.Longitude, 0.000001)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := repo.GetMerchantByID(context.TODO(), -1)
+		assert.ErrorIs(t, err, constants.ErrMerchantNotFound)
+	})
+}
+
+func TestGetMerchantByCellID(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, _, cellID := seedMerchantWithItem(t, repo)
+
+	t.Run("Valid", func(t *testing.T) {
+		result, err := repo.GetMerchantByCellID(context.TODO(), cellID)
+		assert.Nil(t, err)
+		assert.Equal(t, merchant.ID, result.ID)
+		assert.Equal(t, merchant.Name, result.Name)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := repo.GetMerchantByCellID(context.TODO(), -1)
+		assert.ErrorIs(t, err, constants.ErrMerchantNotFound)
+	})
+}
+
 func TestListMerchantWitItems(t *testing.T) {
 	repo := setupRepo(t)
 	t.Run("Valid", func(t *testing.T) {
 		filter := model.ListMerchantWithItemParams{
-			Cell: &model.Cell{
-				CellID:     610049360213835775,
-				Resolution: 8,
+			Cells: []model.Cell{
+				{CellID: 610049360213835775, Resolution: 8},
 			},
 			MerchantParams: model.MerchantParams{
 				// Name: "",
 			},
 		}
-		merchantItems, err := repo.ListMerchantWithItems(context.TODO(), filter)
+		page, err := repo.ListMerchantWithItems(context.TODO(), filter)
 		assert.Nil(t, err)
-		assert.NotEmpty(t, merchantItems)
+		assert.NotEmpty(t, page.Merchants)
 
-		for i, merchant := range merchantItems {
+		for i, merchant := range page.Merchants {
 			t.Logf("merchant %d: %v", i, merchant)
 		}
 	})
@@ -60,15 +152,15 @@ func TestListMerchantWitItems(t *testing.T) {
 				// Name: "",
 			},
 		}
-		merchantItems, err := repo.ListMerchantWithItems(context.TODO(), filter)
+		page, err := repo.ListMerchantWithItems(context.TODO(), filter)
 		if err != nil {
 			fmt.Println(err)
 		}
 
 		assert.Nil(t, err)
-		assert.NotEmpty(t, merchantItems)
+		assert.NotEmpty(t, page.Merchants)
 
-		for i, merchant := range merchantItems {
+		for i, merchant := range page.Merchants {
 			t.Logf("merchant %d: %v", i, merchant.Merchant)
 		}
 	})
@@ -76,19 +168,19 @@ func TestListMerchantWitItems(t *testing.T) {
 	t.Run("Valid_WithFilterName", func(t *testing.T) {
 		filterName := "bat"
 		filter := model.ListMerchantWithItemParams{
-			Cell: &model.Cell{
-				CellID: 614348827586985983,
+			Cells: []model.Cell{
+				{CellID: 614348827586985983, Resolution: 8},
 			},
 			MerchantParams: model.MerchantParams{
 				Name: &filterName,
 			},
 		}
 
-		merchantItems, err := repo.ListMerchantWithItems(context.TODO(), filter)
+		page, err := repo.ListMerchantWithItems(context.TODO(), filter)
 		assert.Nil(t, err)
-		assert.NotEmpty(t, merchantItems)
+		assert.NotEmpty(t, page.Merchants)
 
-		for i, merchant := range merchantItems {
+		for i, merchant := range page.Merchants {
 			t.Logf("merchant %d: %v", i, merchant)
 		}
 	})
@@ -96,19 +188,19 @@ func TestListMerchantWitItems(t *testing.T) {
 	t.Run("Valid_WithFilterName", func(t *testing.T) {
 		filterName := "tok"
 		filter := model.ListMerchantWithItemParams{
-			Cell: &model.Cell{
-				CellID: 614348827586985983,
+			Cells: []model.Cell{
+				{CellID: 614348827586985983, Resolution: 8},
 			},
 			MerchantParams: model.MerchantParams{
 				Name: &filterName,
 			},
 		}
 
-		merchantItems, err := repo.ListMerchantWithItems(context.TODO(), filter)
+		page, err := repo.ListMerchantWithItems(context.TODO(), filter)
 		assert.Nil(t, err)
-		assert.NotEmpty(t, merchantItems)
+		assert.NotEmpty(t, page.Merchants)
 
-		for i, merchant := range merchantItems {
+		for i, merchant := range page.Merchants {
 			t.Logf("merchant %d: %v", i, merchant)
 		}
 	})
@@ -121,11 +213,11 @@ func TestListMerchantWitItems(t *testing.T) {
 			},
 		}
 
-		merchantItems, err := repo.ListMerchantWithItems(context.TODO(), filter)
+		page, err := repo.ListMerchantWithItems(context.TODO(), filter)
 		assert.Nil(t, err)
-		assert.NotEmpty(t, merchantItems)
+		assert.NotEmpty(t, page.Merchants)
 
-		for i, merchant := range merchantItems {
+		for i, merchant := range page.Merchants {
 			t.Logf("merchant %d: %v", i, merchant)
 		}
 	})
@@ -138,9 +230,9 @@ func TestListMerchantWitItems(t *testing.T) {
 			},
 		}
 
-		merchantItems, err := repo.ListMerchantWithItems(context.TODO(), filter)
+		page, err := repo.ListMerchantWithItems(context.TODO(), filter)
 		assert.Nil(t, err)
-		assert.Empty(t, merchantItems)
+		assert.Empty(t, page.Merchants)
 	})
 
 	t.Run("Valid_WithCategory", func(t *testing.T) {
@@ -151,11 +243,175 @@ func TestListMerchantWitItems(t *testing.T) {
 			},
 		}
 
-		merchantItems, err := repo.ListMerchantWithItems(context.TODO(), filter)
+		page, err := repo.ListMerchantWithItems(context.TODO(), filter)
 		assert.Nil(t, err)
-		assert.NotEmpty(t, merchantItems)
-		for i, merchant := range merchantItems {
+		assert.NotEmpty(t, page.Merchants)
+		for i, merchant := range page.Merchants {
 			t.Logf("merchant %d: %v", i, merchant.Merchant.Name)
 		}
 	})
 }
+
+func TestListMerchantWithItemsPagination(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, _, cellID := seedMerchantWithItem(t, repo)
+	userLocation := model.Location{Lat: merchant.Latitude + 0.01, Long: merchant.Longitude}
+
+	t.Run("StableAcrossPages", func(t *testing.T) {
+		seen := make(map[int64]bool)
+		prevDistance := -1.0
+
+		for offset := 0; offset < 3; offset++ {
+			page, err := repo.ListMerchantWithItems(context.TODO(), model.ListMerchantWithItemParams{
+				Location:       userLocation,
+				MerchantParams: model.MerchantParams{Limit: 1, Offset: offset},
+			})
+			require.NoError(t, err)
+			if len(page.Merchants) == 0 {
+				break
+			}
+
+			merchantItem := page.Merchants[0]
+			assert.False(t, seen[merchantItem.Merchant.ID], "merchant %d returned on two pages", merchantItem.Merchant.ID)
+			assert.GreaterOrEqual(t, merchantItem.DistanceInMeters, prevDistance)
+			seen[merchantItem.Merchant.ID] = true
+			prevDistance = merchantItem.DistanceInMeters
+		}
+	})
+
+	t.Run("DistanceInMeters", func(t *testing.T) {
+		id := merchant.ID
+		page, err := repo.ListMerchantWithItems(context.TODO(), model.ListMerchantWithItemParams{
+			Location:       userLocation,
+			MerchantParams: model.MerchantParams{MerchantID: &id},
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Merchants, 1)
+		// 0.01 degree of latitude
+		assert.InDelta(t, 1112, page.Merchants[0].DistanceInMeters, 2)
+		assert.Len(t, page.Merchants[0].Items, 1)
+	})
+
+	t.Run("CellsRestrictMatchedButNotTotal", func(t *testing.T) {
+		name := merchant.Name
+		page, err := repo.ListMerchantWithItems(context.TODO(), model.ListMerchantWithItemParams{
+			Location:       userLocation,
+			Cells:          []model.Cell{{CellID: cellID + 1, Resolution: 8}},
+			MerchantParams: model.MerchantParams{Name: &name},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, page.Merchants)
+		assert.Equal(t, 0, page.Matched)
+		assert.Equal(t, 1, page.Total)
+	})
+
+	t.Run("PastTheEndKeepsTotal", func(t *testing.T) {
+		name := merchant.Name
+		page, err := repo.ListMerchantWithItems(context.TODO(), model.ListMerchantWithItemParams{
+			Location:       userLocation,
+			MerchantParams: model.MerchantParams{Name: &name, Limit: 5, Offset: 5},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, page.Merchants)
+		assert.Equal(t, 1, page.Total)
+	})
+}
+
+func TestListMerchantWithItemsSearch(t *testing.T) {
+	repo := setupRepo(t)
+	merchant, item, _ := seedMerchantWithItem(t, repo)
+	userLocation := model.Location{Lat: merchant.Latitude, Long: merchant.Longitude}
+	identifier := merchant.Name[len("Seed Merchant "):]
+
+	search := func(t *testing.T, query string) model.NearbyMerchantPage {
+		t.Helper()
+		page, err := repo.ListMerchantWithItems(context.TODO(), model.ListMerchantWithItemParams{
+			Location:       userLocation,
+			MerchantParams: model.MerchantParams{Query: &query, Limit: 5},
+		})
+		require.NoError(t, err)
+		return page
+	}
+
+	t.Run("ItemName", func(t *testing.T) {
+		page := search(t, item.Name)
+		require.NotEmpty(t, page.Merchants)
+		assert.Equal(t, merchant.ID, page.Merchants[0].Merchant.ID)
+		assert.Contains(t, page.Merchants[0].Highlights, "<mark>Seed</mark> <mark>Item</mark> <mark>"+identifier+"</mark>")
+	})
+
+	t.Run("Typo", func(t *testing.T) {
+		page := search(t, "Seed Merchnt "+identifier)
+		require.NotEmpty(t, page.Merchants)
+		assert.Equal(t, merchant.ID, page.Merchants[0].Merchant.ID)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		page := search(t, "zzqx"+identifier)
+		assert.Empty(t, page.Merchants)
+		assert.Equal(t, 0, page.Total)
+	})
+}
+
+func TestListMerchantWithItemsOpenNow(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.TODO()
+	merchant, _, cellID := seedMerchantWithItem(t, repo)
+
+	list := func(t *testing.T, openNow bool) model.NearbyMerchantPage {
+		t.Helper()
+		page, err := repo.ListMerchantWithItems(ctx, model.ListMerchantWithItemParams{
+			Location:       model.Location{Lat: merchant.Latitude, Long: merchant.Longitude},
+			Cells:          []model.Cell{{CellID: cellID, Resolution: 8}},
+			MerchantParams: model.MerchantParams{Limit: 5, OpenNow: openNow},
+		})
+		require.NoError(t, err)
+		return page
+	}
+
+	t.Run("WithoutOpeningHours", func(t *testing.T) {
+		page := list(t, true)
+		require.Len(t, page.Merchants, 1)
+		assert.True(t, page.Merchants[0].IsOpen)
+	})
+
+	// a single minute of the day that has just passed keeps the merchant closed now
+	now := time.Now().UTC()
+	err := repo.ReplaceMerchantOpeningHours(ctx, merchant.ID, []model.OpeningHours{{
+		Weekday:  now.Weekday(),
+		OpensAt:  now.Add(-2 * time.Minute).Format("15:04"),
+		ClosesAt: now.Add(-time.Minute).Format("15:04"),
+	}})
+	require.NoError(t, err)
+
+	t.Run("Closed", func(t *testing.T) {
+		page := list(t, false)
+		require.Len(t, page.Merchants, 1)
+		assert.False(t, page.Merchants[0].IsOpen)
+
+		assert.Empty(t, list(t, true).Merchants)
+
+		merchantItem, err := repo.GetMerchantWithItems(ctx, merchant.ID)
+		require.NoError(t, err)
+		assert.False(t, merchantItem.IsOpen)
+	})
+
+	t.Run("HolidayOverride", func(t *testing.T) {
+		err := repo.ReplaceMerchantHolidays(ctx, merchant.ID, []model.HolidayOverride{{
+			Date:     now.Format("2006-01-02"),
+			OpensAt:  stringPtr("00:00"),
+			ClosesAt: stringPtr("00:00"),
+		}})
+		require.NoError(t, err)
+
+		page := list(t, true)
+		require.Len(t, page.Merchants, 1)
+		assert.True(t, page.Merchants[0].IsOpen)
+
+		got, err := repo.GetMerchantByID(ctx, merchant.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "UTC", got.Timezone)
+		assert.Len(t, got.OpeningHours, 1)
+		assert.Len(t, got.Holidays, 1)
+	})
+}
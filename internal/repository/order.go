@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"PattyWagon/internal/model"
+	"PattyWagon/observability"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func (q *Queries) InsertOrder(ctx context.Context, data model.UserOrder) (res int64, err error) {
+	ctx, span := observability.Tracer.Start(ctx, "repository.insert_order")
+	defer span.End()
+
+	query := `
+		INSERT INTO orders (
+			user_id, estimation_id, total_price, estimated_delivery_in_minutes,
+			distance_in_meters, delivery_fee, service_fee, discount, tax, grand_total,
+			created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW()
+		)
+		RETURNING id
+	`
+
+	err = q.conn(ctx).QueryRowContext(ctx, query,
+		data.UserID,
+		data.EstimationID,
+		data.TotalPrice,
+		data.EstimatedDeliveryInMinutes,
+		data.Breakdown.DistanceInMeters,
+		data.Breakdown.DeliveryFee,
+		data.Breakdown.ServiceFee,
+		data.Breakdown.Discount,
+		data.Breakdown.Tax,
+		data.Breakdown.GrandTotal,
+	).Scan(&res)
+
+	if err != nil {
+		return 0, fmt.Errorf("error inserting order: %w", err)
+	}
+
+	return res, nil
+}
+
+func (q *Queries) BulkInsertOrderItems(ctx context.Context, items []model.UserOrderItem) error {
+	ctx, span := observability.Tracer.Start(ctx, "repository.bulk_insert_order_items")
+	defer span.End()
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO order_items (order_id, merchant_id, item_id, quantity, price, modifiers, created_at, updated_at) VALUES `
+
+	values := []interface{}{}
+	placeholders := []string{}
+
+	for i, item := range items {
+		modifiers, err := marshalModifiers(item.Modifiers)
+		if err != nil {
+			return err
+		}
+
+		placeholders = append(placeholders,
+			fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d::jsonb, NOW(), NOW())", i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6))
+		values = append(values, item.OrderID, item.MerchantID, item.ItemID, item.Quantity, item.Price, modifiers)
+	}
+
+	query += strings.Join(placeholders, ", ")
+
+	_, err := q.conn(ctx).ExecContext(ctx, query, values...)
+	if err != nil {
+		return fmt.Errorf("error bulk inserting order items: %w", err)
+	}
+
+	return nil
+}
+
+func (q *Queries) GetOrders(ctx context.Context, filter model.FilterOrder) (res []model.OrderHistory, err error) {
+	ctx, span := observability.Tracer.Start(ctx, "repository.get_orders")
+	defer span.End()
+
+	where, args := orderListConds(filter)
+
+	query := `
+		SELECT o.id, o.total_price, o.distance_in_meters, o.delivery_fee, o.service_fee, o.discount, o.tax, o.grand_total,
+			o.created_at
+		FROM orders o
+		WHERE ` + where
+
+	// pagination
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 5
+	}
+	offset := filter.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	query += fmt.Sprintf(" ORDER BY o.created_at DESC, o.id DESC LIMIT %d OFFSET %d", limit, offset)
+
+	rows, err := q.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orderIDs []int64
+	orderIdx := make(map[int64]int)
+	for rows.Next() {
+		var order model.OrderHistory
+		if err := rows.Scan(
+			&order.ID,
+			&order.TotalPrice,
+			&order.Breakdown.DistanceInMeters,
+			&order.Breakdown.DeliveryFee,
+			&order.Breakdown.ServiceFee,
+			&order.Breakdown.Discount,
+			&order.Breakdown.Tax,
+			&order.Breakdown.GrandTotal,
+			&order.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		order.Breakdown.Subtotal = order.TotalPrice
+		orderIdx[order.ID] = len(res)
+		orderIDs = append(orderIDs, order.ID)
+		res = append(res, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(orderIDs) == 0 {
+		return res, nil
+	}
+
+	if err := q.fillOrderHistoryItems(ctx, orderIDs, orderIdx, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// CountOrders counts every order of the user matching the filter, regardless of the page
+func (q *Queries) CountOrders(ctx context.Context, filter model.FilterOrder) (int, error) {
+	ctx, span := observability.Tracer.Start(ctx, "repository.count_orders")
+	defer span.End()
+
+	where, args := orderListConds(filter)
+	query := "SELECT COUNT(*) FROM orders o WHERE " + where
+
+	var count int
+	if err := q.conn(ctx).QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// orderListConds builds the WHERE clause shared by the order listing and its count
+func orderListConds(filter model.FilterOrder) (string, []interface{}) {
+	where := "o.user_id = $1"
+	args := []interface{}{filter.UserID}
+
+	// filter by merchant name, item name and merchant category
+	conds := []string{}
+	if filter.Name != "" {
+		conds = append(conds, fmt.Sprintf("(m.name ILIKE $%d OR i.name ILIKE $%d)", len(args)+1, len(args)+1))
+		args = append(args, "%"+filter.Name+"%")
+	}
+
+	if filter.MerchantCategory != "" {
+		conds = append(conds, fmt.Sprintf("m.category = $%d", len(args)+1))
+		args = append(args, filter.MerchantCategory)
+	}
+
+	if len(conds) > 0 {
+		where += `
+		AND EXISTS (
+			SELECT 1
+			FROM order_items oi
+			INNER JOIN merchants m ON m.id = oi.merchant_id
+			INNER JOIN items i ON i.id = oi.item_id
+			WHERE oi.order_id = o.id AND ` + strings.Join(conds, " AND ") + `
+		)`
+	}
+
+	return where, args
+}
+
+func (q *Queries) fillOrderHistoryItems(ctx context.Context, orderIDs []int64, orderIdx map[int64]int, orders []model.OrderHistory) error {
+	query := `
+		SELECT
+			oi.order_id, oi.quantity, oi.price, oi.modifiers,
+			m.id, m.name, m.category, m.image_url, m.latitude, m.longitude, m.created_at,
+			i.id, i.merchant_id, i.name, i.category, i.image_url, i.created_at
+		FROM order_items oi
+		INNER JOIN merchants m ON m.id = oi.merchant_id
+		INNER JOIN items i ON i.id = oi.item_id
+		WHERE oi.order_id = ANY($1)
+		ORDER BY oi.order_id, oi.id
+	`
+
+	rows, err := q.conn(ctx).QueryContext(ctx, query, orderIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// merchantIdx keeps the position of each merchant inside its order, keyed by order then merchant ID
+	merchantIdx := make(map[int64]map[int64]int)
+	for rows.Next() {
+		var orderID int64
+		var merchant model.Merchant
+		var item model.OrderedItem
+		var modifiers []byte
+		if err := rows.Scan(
+			&orderID, &item.Quantity, &item.Price, &modifiers,
+			&merchant.ID, &merchant.Name, &merchant.Category, &merchant.ImageURL, &merchant.Latitude, &merchant.Longitude, &merchant.CreatedAt,
+			&item.ID, &item.MerchantID, &item.Name, &item.Category, &item.ImageURL, &item.CreatedAt,
+		); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(modifiers, &item.Modifiers); err != nil {
+			return err
+		}
+
+		order := &orders[orderIdx[orderID]]
+		if merchantIdx[orderID] == nil {
+			merchantIdx[orderID] = make(map[int64]int)
+		}
+
+		idx, exists := merchantIdx[orderID][merchant.ID]
+		if !exists {
+			idx = len(order.Merchants)
+			merchantIdx[orderID][merchant.ID] = idx
+			order.Merchants = append(order.Merchants, model.MerchantOrder{Merchant: merchant})
+		}
+		order.Merchants[idx].Items = append(order.Merchants[idx].Items, item)
+	}
+
+	return rows.Err()
+}
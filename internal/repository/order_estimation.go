@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"PattyWagon/observability"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func (q *Queries) InsertOrderEstimation(ctx context.Context, data model.EstimationPrice) (res int64, err error) {
+	ctx, span := observability.Tracer.Start(ctx, "repository.insert_order_estimation")
+	defer span.End()
+
+	query := `
+		INSERT INTO order_estimations (
+			user_id, user_latitude, user_longitude, total_price, estimated_delivery_in_minutes,
+			distance_in_meters, delivery_fee, service_fee, discount, tax, grand_total, promo_code_id,
+			created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW()
+		)
+		RETURNING id
+	`
+
+	err = q.conn(ctx).QueryRowContext(ctx, query,
+		data.UserID,
+		data.UserLocation.Lat,
+		data.UserLocation.Long,
+		data.TotalPrice,
+		data.EstimatedDeliveryInMinutes,
+		data.Breakdown.DistanceInMeters,
+		data.Breakdown.DeliveryFee,
+		data.Breakdown.ServiceFee,
+		data.Breakdown.Discount,
+		data.Breakdown.Tax,
+		data.Breakdown.GrandTotal,
+		data.PromoCodeID,
+	).Scan(&res)
+
+	if err != nil {
+		return 0, fmt.Errorf("error inserting order estimation: %w", err)
+	}
+
+	return res, nil
+}
+
+func (q *Queries) BulkInsertOrderEstimationItems(ctx context.Context, items []model.EstimationItem) error {
+	ctx, span := observability.Tracer.Start(ctx, "repository.bulk_insert_order_estimation_items")
+	defer span.End()
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO order_estimation_items (estimation_id, merchant_id, item_id, quantity, price, modifiers, created_at, updated_at) VALUES `
+
+	values := []interface{}{}
+	placeholders := []string{}
+
+	for i, item := range items {
+		modifiers, err := marshalModifiers(item.Modifiers)
+		if err != nil {
+			return err
+		}
+
+		placeholders = append(placeholders,
+			fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d::jsonb, NOW(), NOW())", i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6))
+		values = append(values, item.EstimationID, item.MerchantID, item.ItemID, item.Quantity, item.Price, modifiers)
+	}
+
+	query += strings.Join(placeholders, ", ")
+
+	_, err := q.conn(ctx).ExecContext(ctx, query, values...)
+	if err != nil {
+		return fmt.Errorf("error bulk inserting order estimation items: %w", err)
+	}
+
+	return nil
+}
+
+func (q *Queries) GetOrderEstimationByID(ctx context.Context, id int64) (model.EstimationPrice, error) {
+	ctx, span := observability.Tracer.Start(ctx, "repository.get_order_estimation_by_id")
+	defer span.End()
+
+	query := `
+		SELECT
+			id, user_id, user_latitude, user_longitude, total_price, estimated_delivery_in_minutes,
+			distance_in_meters, delivery_fee, service_fee, discount, tax, grand_total, promo_code_id,
+			created_at
+		FROM order_estimations
+		WHERE id = $1
+	`
+
+	var res model.EstimationPrice
+	err := q.conn(ctx).QueryRowContext(ctx, query, id).Scan(
+		&res.ID,
+		&res.UserID,
+		&res.UserLocation.Lat,
+		&res.UserLocation.Long,
+		&res.TotalPrice,
+		&res.EstimatedDeliveryInMinutes,
+		&res.Breakdown.DistanceInMeters,
+		&res.Breakdown.DeliveryFee,
+		&res.Breakdown.ServiceFee,
+		&res.Breakdown.Discount,
+		&res.Breakdown.Tax,
+		&res.Breakdown.GrandTotal,
+		&res.PromoCodeID,
+		&res.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.EstimationPrice{}, constants.ErrEstimationNotFound
+		}
+		return model.EstimationPrice{}, err
+	}
+
+	itemsQuery := `
+		SELECT id, estimation_id, merchant_id, item_id, quantity, price, modifiers
+		FROM order_estimation_items
+		WHERE estimation_id = $1
+		ORDER BY id
+	`
+
+	rows, err := q.conn(ctx).QueryContext(ctx, itemsQuery, id)
+	if err != nil {
+		return model.EstimationPrice{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			item      model.EstimationItem
+			modifiers []byte
+		)
+		if err := rows.Scan(
+			&item.ID,
+			&item.EstimationID,
+			&item.MerchantID,
+			&item.ItemID,
+			&item.Quantity,
+			&item.Price,
+			&modifiers,
+		); err != nil {
+			return model.EstimationPrice{}, err
+		}
+		if err := json.Unmarshal(modifiers, &item.Modifiers); err != nil {
+			return model.EstimationPrice{}, err
+		}
+		res.Items = append(res.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return model.EstimationPrice{}, err
+	}
+	res.Breakdown.Subtotal = res.TotalPrice
+
+	return res, nil
+}
+
+// marshalModifiers encodes the modifiers picked for an ordered item, storing none as an empty list
+func marshalModifiers(modifiers []model.SelectedModifier) (string, error) {
+	if modifiers == nil {
+		modifiers = []model.SelectedModifier{}
+	}
+
+	encoded, err := json.Marshal(modifiers)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
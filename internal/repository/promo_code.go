@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// GetPromoCodeByCode looks a promo code up, ignoring the case of the code
+func (q *Queries) GetPromoCodeByCode(ctx context.Context, code string) (model.PromoCode, error) {
+	query := `
+		SELECT id, code, discount_type, discount_value, max_discount, min_spend, usage_limit_per_user, starts_at, expires_at
+		FROM promo_codes
+		WHERE UPPER(code) = UPPER($1)
+	`
+
+	var promo model.PromoCode
+	err := q.conn(ctx).QueryRowContext(ctx, query, code).Scan(
+		&promo.ID,
+		&promo.Code,
+		&promo.DiscountType,
+		&promo.DiscountValue,
+		&promo.MaxDiscount,
+		&promo.MinSpend,
+		&promo.UsageLimitPerUser,
+		&promo.StartsAt,
+		&promo.ExpiresAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.PromoCode{}, constants.ErrPromoCodeNotFound
+		}
+		return model.PromoCode{}, err
+	}
+
+	return promo, nil
+}
+
+// CountPromoCodeRedemptions counts the orders a user placed with a promo code
+func (q *Queries) CountPromoCodeRedemptions(ctx context.Context, promoCodeID, userID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM promo_code_redemptions WHERE promo_code_id = $1 AND user_id = $2`
+
+	var count int
+	if err := q.conn(ctx).QueryRowContext(ctx, query, promoCodeID, userID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RedeemPromoCode records an order placed with a promo code, failing once the code is no longer active or the user
+// reached its usage limit. The promo code row stays locked until the transaction ends, so concurrent orders of the
+// same code are counted one after another; run it in a transaction.
+func (q *Queries) RedeemPromoCode(ctx context.Context, redemption model.PromoCodeRedemption) error {
+	var (
+		usageLimit sql.NullInt64
+		active     bool
+	)
+	err := q.conn(ctx).QueryRowContext(ctx, `
+		SELECT usage_limit_per_user, (starts_at IS NULL OR starts_at <= NOW()) AND (expires_at IS NULL OR expires_at > NOW())
+		FROM promo_codes
+		WHERE id = $1
+		FOR UPDATE
+	`, redemption.PromoCodeID).Scan(&usageLimit, &active)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return constants.ErrPromoCodeNotFound
+		}
+		return err
+	}
+
+	if !active {
+		return constants.ErrPromoCodeExpired
+	}
+
+	if usageLimit.Valid {
+		count, err := q.CountPromoCodeRedemptions(ctx, redemption.PromoCodeID, redemption.UserID)
+		if err != nil {
+			return err
+		}
+		if int64(count) >= usageLimit.Int64 {
+			return constants.ErrPromoCodeLimitReached
+		}
+	}
+
+	_, err = q.conn(ctx).ExecContext(ctx, `
+		INSERT INTO promo_code_redemptions (promo_code_id, user_id, order_id, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, redemption.PromoCodeID, redemption.UserID, redemption.OrderID)
+	if err != nil {
+		return fmt.Errorf("error redeeming promo code: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (q *Queries) InsertRefreshToken(ctx context.Context, token model.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, token_hash, access_token_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := q.conn(ctx).ExecContext(ctx, query,
+		token.UserID,
+		token.TokenHash,
+		token.AccessTokenID,
+		token.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting refresh token: %w", err)
+	}
+
+	return nil
+}
+
+func (q *Queries) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (res model.RefreshToken, err error) {
+	query := `
+		SELECT rt.id, rt.user_id, u.role, rt.token_hash, rt.access_token_id, rt.expires_at, rt.revoked_at,
+			rt.revoked_reason, rt.created_at
+		FROM refresh_tokens rt
+		INNER JOIN users u ON u.id = rt.user_id
+		WHERE rt.token_hash = $1
+	`
+
+	err = q.conn(ctx).QueryRowContext(ctx, query, tokenHash).Scan(
+		&res.ID,
+		&res.UserID,
+		&res.Role,
+		&res.TokenHash,
+		&res.AccessTokenID,
+		&res.ExpiresAt,
+		&res.RevokedAt,
+		&res.RevokedReason,
+		&res.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.RefreshToken{}, constants.ErrInvalidRefreshToken
+		}
+		return model.RefreshToken{}, err
+	}
+
+	return res, nil
+}
+
+// RevokeRefreshToken marks a refresh token as used for the given reason. Revoking a token that is already
+// revoked reports ErrInvalidRefreshToken so concurrent refreshes cannot both succeed.
+func (q *Queries) RevokeRefreshToken(ctx context.Context, id int64, reason string) error {
+	result, err := q.conn(ctx).ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = NOW(), revoked_reason = $2 WHERE id = $1 AND revoked_at IS NULL",
+		id, reason)
+	if err != nil {
+		return fmt.Errorf("error revoking refresh token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return constants.ErrInvalidRefreshToken
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokenOfAccessToken revokes the live refresh token issued alongside an access token, if any
+func (q *Queries) RevokeRefreshTokenOfAccessToken(ctx context.Context, accessTokenID string) error {
+	_, err := q.conn(ctx).ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = NOW(), revoked_reason = $2 WHERE access_token_id = $1 AND revoked_at IS NULL",
+		accessTokenID, model.RefreshTokenLogout)
+	if err != nil {
+		return fmt.Errorf("error revoking refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every live refresh token of a user and returns the IDs of the
+// access tokens issued alongside them
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID int64) (res []string, err error) {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW(), revoked_reason = $2
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING access_token_id
+	`
+
+	rows, err := q.conn(ctx).QueryContext(ctx, query, userID, model.RefreshTokenLogout)
+	if err != nil {
+		return nil, fmt.Errorf("error revoking refresh tokens: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tokenID string
+		if err := rows.Scan(&tokenID); err != nil {
+			return nil, err
+		}
+		res = append(res, tokenID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// RevokeAccessTokens adds access token IDs to the denylist until expiresAt, pruning entries that already expired
+func (q *Queries) RevokeAccessTokens(ctx context.Context, tokenIDs []string, expiresAt time.Time) error {
+	if len(tokenIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		WITH pruned AS (
+			DELETE FROM revoked_access_tokens WHERE expires_at < NOW()
+		)
+		INSERT INTO revoked_access_tokens (token_id, expires_at)
+		SELECT UNNEST($1::TEXT[]), $2
+		ON CONFLICT (token_id) DO NOTHING
+	`
+
+	_, err := q.conn(ctx).ExecContext(ctx, query, tokenIDs, expiresAt)
+	if err != nil {
+		return fmt.Errorf("error revoking access tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (q *Queries) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var revoked bool
+	err := q.conn(ctx).QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM revoked_access_tokens WHERE token_id = $1)", tokenID).Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
@@ -11,7 +11,7 @@ const (
 )
 
 func (q *Queries) InsertUser(ctx context.Context, user model.User, passwordHash string) (res model.User, err error) {
-	err = q.db.QueryRowContext(ctx, insertUserQuery,
+	err = q.conn(ctx).QueryRowContext(ctx, insertUserQuery,
 		user.Email,
 		user.Username,
 		user.Role,
@@ -26,7 +26,7 @@ func (q *Queries) InsertUser(ctx context.Context, user model.User, passwordHash
 }
 
 func (q *Queries) SelectUserCredentialsByUsernameAndRole(ctx context.Context, username string, role int16) (res model.User, err error) {
-	err = q.db.QueryRowContext(ctx, selectUserCredentialsByUsernameQuery, username, role).Scan(&res.ID, &res.Email, &res.PasswordHash)
+	err = q.conn(ctx).QueryRowContext(ctx, selectUserCredentialsByUsernameQuery, username, role).Scan(&res.ID, &res.Email, &res.PasswordHash)
 	if err != nil {
 		return model.User{}, err
 	}
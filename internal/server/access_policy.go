@@ -0,0 +1,43 @@
+package server
+
+import (
+	"PattyWagon/internal/constants"
+	"net/http"
+	"slices"
+)
+
+// access describes who may call a route. Public routes skip authentication entirely,
+// every other route requires a token whose role is listed in roles.
+type access struct {
+	public bool
+	roles  []int16
+}
+
+var (
+	publicAccess = access{public: true}
+	adminAccess  = access{roles: []int16{constants.RoleAdmin}}
+	userAccess   = access{roles: []int16{constants.RoleUser}}
+	anyRole      = access{roles: []int16{constants.RoleAdmin, constants.RoleUser}}
+)
+
+func (a access) allows(role int16) bool {
+	return slices.Contains(a.roles, role)
+}
+
+// route binds a ServeMux pattern ("METHOD /path") to its handler and access rule
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+	access  access
+}
+
+// accessPolicy maps a registered ServeMux pattern to its access rule
+type accessPolicy map[string]access
+
+func newAccessPolicy(routes []route) accessPolicy {
+	policy := make(accessPolicy, len(routes))
+	for _, rt := range routes {
+		policy[rt.pattern] = rt.access
+	}
+	return policy
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/utils"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// policyTestHandler serves the real route table with stub handlers behind authMiddleware
+func policyTestHandler(routes []route) http.Handler {
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		mux.HandleFunc(rt.pattern, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+
+	s := &Server{}
+	return s.authMiddleware(mux, newAccessPolicy(routes))
+}
+
+func TestAccessPolicy(t *testing.T) {
+	s := &Server{}
+	handler := policyTestHandler(s.routes())
+
+	_, signingKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	jwtKeys, err := utils.NewJWTKeys(signingKey)
+	require.NoError(t, err)
+	utils.SetJWTKeys(jwtKeys)
+	t.Cleanup(func() { utils.SetJWTKeys(nil) })
+
+	adminToken, _, err := utils.GenerateToken(1, constants.RoleAdmin)
+	require.NoError(t, err)
+	userToken, _, err := utils.GenerateToken(2, constants.RoleUser)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"PublicWithoutToken", http.MethodPost, "/users/login", "", http.StatusOK},
+		{"HealthWithoutToken", http.MethodGet, "/health", "", http.StatusOK},
+		{"JWKSWithoutToken", http.MethodGet, "/.well-known/jwks.json", "", http.StatusOK},
+		{"AdminRouteWithoutToken", http.MethodGet, "/admin/merchants", "", http.StatusUnauthorized},
+		{"AdminRouteWithInvalidToken", http.MethodGet, "/admin/merchants", "invalid", http.StatusUnauthorized},
+		{"AdminRouteAsAdmin", http.MethodGet, "/admin/merchants", adminToken, http.StatusOK},
+		{"AdminRouteAsUser", http.MethodGet, "/admin/merchants", userToken, http.StatusForbidden},
+		{"AdminItemRouteAsAdmin", http.MethodDelete, "/admin/merchants/1/items/2", adminToken, http.StatusOK},
+		{"AdminItemRouteAsUser", http.MethodPatch, "/admin/merchants/1/items/2", userToken, http.StatusForbidden},
+		{"UploadAsUser", http.MethodPost, "/v1/file", userToken, http.StatusForbidden},
+		{"UserRouteAsUser", http.MethodPost, "/users/orders", userToken, http.StatusOK},
+		{"UserRouteAsAdmin", http.MethodGet, "/users/orders", adminToken, http.StatusForbidden},
+		{"NearbyAsUser", http.MethodGet, "/merchants/nearby/-6.2,106.8", userToken, http.StatusOK},
+		{"NearbyAsAdmin", http.MethodGet, "/merchants/nearby/-6.2,106.8", adminToken, http.StatusForbidden},
+		{"RefreshWithoutToken", http.MethodPost, "/auth/refresh", "", http.StatusOK},
+		{"LogoutWithoutToken", http.MethodPost, "/auth/logout", "", http.StatusUnauthorized},
+		{"LogoutAsUser", http.MethodPost, "/auth/logout", userToken, http.StatusOK},
+		{"FallbackWithoutToken", http.MethodGet, "/unknown", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestAccessPolicyFailsClosed(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /unlisted", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := &Server{}
+	handler := s.authMiddleware(mux, newAccessPolicy(nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/unlisted", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
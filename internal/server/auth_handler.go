@@ -3,6 +3,7 @@ package server
 import (
 	"PattyWagon/internal/constants"
 	"PattyWagon/internal/model"
+	"PattyWagon/internal/utils"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -12,11 +13,11 @@ import (
 )
 
 func (s *Server) adminLoginHandler(w http.ResponseWriter, r *http.Request) {
-	s.loginHandler(w, r, 0) // 0 for admin
+	s.loginHandler(w, r, constants.RoleAdmin)
 }
 
 func (s *Server) userLoginHandler(w http.ResponseWriter, r *http.Request) {
-	s.loginHandler(w, r, 1) // 1 for user
+	s.loginHandler(w, r, constants.RoleUser)
 }
 
 func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request, role int16) {
@@ -48,17 +49,18 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request, role int16
 	}
 
 	resp := LoginResponse{
-		Token: token,
+		Token:        token.AccessToken,
+		RefreshToken: token.RefreshToken,
 	}
 	sendResponse(w, http.StatusOK, resp)
 }
 
 func (s *Server) adminRegisterHandler(w http.ResponseWriter, r *http.Request) {
-	s.registerHandler(w, r, 0) // 0 for admin
+	s.registerHandler(w, r, constants.RoleAdmin)
 }
 
 func (s *Server) userRegisterHandler(w http.ResponseWriter, r *http.Request) {
-	s.registerHandler(w, r, 1) // 1 for user
+	s.registerHandler(w, r, constants.RoleUser)
 }
 
 func (s *Server) registerHandler(w http.ResponseWriter, r *http.Request, role int16) {
@@ -101,7 +103,82 @@ func (s *Server) registerHandler(w http.ResponseWriter, r *http.Request, role in
 	}
 
 	resp := RegisterResponse{
-		Token: token,
+		Token:        token.AccessToken,
+		RefreshToken: token.RefreshToken,
 	}
 	sendResponse(w, http.StatusCreated, resp)
 }
+
+func (s *Server) refreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req RefreshTokenRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	err = s.validator.Struct(req)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, err := s.service.RefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, constants.ErrInvalidRefreshToken) {
+			sendErrorResponse(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		log.Printf("failed to refresh token: %s\n", err.Error())
+		sendErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	resp := RefreshTokenResponse{
+		Token:        token.AccessToken,
+		RefreshToken: token.RefreshToken,
+	}
+	sendResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req LogoutRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	tokenID, ok := utils.GetTokenIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	err := s.service.Logout(ctx, userID, tokenID, req.RefreshToken, req.AllSessions)
+	if err != nil {
+		if errors.Is(err, constants.ErrInvalidRefreshToken) {
+			sendErrorResponse(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		log.Printf("failed to logout: %s\n", err.Error())
+		sendErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	sendResponse(w, http.StatusNoContent, nil)
+}
+
+// jwksHandler publishes the public token verification keys so other services can verify PattyWagon tokens
+func (s *Server) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	sendResponse(w, http.StatusOK, utils.PublicJWKS())
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/utils"
+	"PattyWagon/logger"
+	"PattyWagon/observability"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+func (s *Server) EstimateOrderPrice(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLoggerFromContext(r.Context())
+	ctx, span := observability.Tracer.Start(r.Context(), "handler.estimate_order_price")
+	defer span.End()
+
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req OrderEstimationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		log.Printf("invalid estimation request: %s\n", err.Error())
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	estimation := req.ToModel()
+	estimation.UserID = userID
+
+	result, err := s.service.EstimateOrderPrice(ctx, estimation)
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.ErrInvalidStartingPoint),
+			errors.Is(err, constants.ErrMerchantTooFar),
+			errors.Is(err, constants.ErrMerchantClosed),
+			errors.Is(err, constants.ErrItemUnavailable),
+			errors.Is(err, constants.ErrItemOutOfStock),
+			errors.Is(err, constants.ErrInvalidModifiers),
+			errors.Is(err, constants.ErrPromoCodeExpired),
+			errors.Is(err, constants.ErrPromoCodeMinSpend),
+			errors.Is(err, constants.ErrPromoCodeLimitReached):
+			sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, constants.ErrMerchantNotFound),
+			errors.Is(err, constants.ErrItemNotFound),
+			errors.Is(err, constants.ErrPromoCodeNotFound):
+			sendErrorResponse(w, http.StatusNotFound, err.Error())
+		default:
+			log.Printf("failed to estimate order price: %s\n", err.Error())
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	sendResponse(w, http.StatusOK, NewEstimationPriceResponse(result))
+}
@@ -3,9 +3,14 @@ package server
 import (
 	"PattyWagon/internal/constants"
 	"PattyWagon/observability"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"path"
 	"strconv"
+	"time"
 )
 
 func (s *Server) fileUploadHandler(w http.ResponseWriter, r *http.Request) {
@@ -42,13 +47,143 @@ func (s *Server) fileUploadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := FileUploadResponse{
-		FileID:           strconv.FormatInt(uploadedFile.ID, 10),
-		FileUri:          uploadedFile.Uri,
-		FileThumbnailUri: uploadedFile.ThumbnailUri,
+	sendResponse(w, http.StatusOK, NewFileUploadResponse(uploadedFile))
+	defer r.Body.Close()
+
+}
+
+// getStoredFileHandler serves the files of the local and in-memory storage backends
+func (s *Server) getStoredFileHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observability.Tracer.Start(r.Context(), "handler.get_stored_file")
+	defer span.End()
+
+	key := r.PathValue("key")
+	file, err := s.service.OpenStoredFile(ctx, key)
+	if err != nil {
+		if errors.Is(err, constants.ErrFileNotFound) {
+			sendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
 	}
+	defer file.Close()
 
-	sendResponse(w, http.StatusOK, resp)
-	defer r.Body.Close()
+	// the key keeps the file extension, which ServeContent reads the content type from
+	http.ServeContent(w, r, path.Base(key), time.Time{}, file)
+}
+
+func (s *Server) createUploadURLHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observability.Tracer.Start(r.Context(), "handler.create_upload_url")
+	defer span.End()
+
+	var req CreateUploadURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "request doesn't pass validation")
+		return
+	}
+
+	upload, err := s.service.CreateUploadURL(ctx, req.Filename, req.SizeInBytes)
+	if err != nil {
+		sendFileUploadError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusCreated, UploadURLResponse{
+		FileID:    strconv.FormatInt(upload.File.ID, 10),
+		UploadURL: upload.UploadURL,
+		Method:    upload.Method,
+		ExpiresAt: upload.File.UploadExpiresAt.Time,
+		Status:    upload.File.Status,
+	})
+}
+
+func (s *Server) completeFileUploadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observability.Tracer.Start(r.Context(), "handler.complete_file_upload")
+	defer span.End()
+
+	fileID, err := strconv.ParseInt(r.PathValue("fileId"), 10, 64)
+	if err != nil || fileID <= 0 {
+		sendErrorResponse(w, http.StatusNotFound, constants.ErrFileNotFound.Error())
+		return
+	}
 
+	file, err := s.service.CompleteFileUpload(ctx, fileID)
+	if err != nil {
+		sendFileUploadError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusOK, NewFileUploadResponse(file))
+}
+
+// getFileHandler reports whether a file and its thumbnail are ready
+func (s *Server) getFileHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observability.Tracer.Start(r.Context(), "handler.get_file")
+	defer span.End()
+
+	fileID, err := strconv.ParseInt(r.PathValue("fileId"), 10, 64)
+	if err != nil || fileID <= 0 {
+		sendErrorResponse(w, http.StatusNotFound, constants.ErrFileNotFound.Error())
+		return
+	}
+
+	file, err := s.service.GetFile(ctx, fileID)
+	if err != nil {
+		sendFileUploadError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusOK, NewFileUploadResponse(file))
+}
+
+// receiveSignedUploadHandler accepts uploads to urls presigned by the local and in-memory storage backends
+func (s *Server) receiveSignedUploadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := observability.Tracer.Start(r.Context(), "handler.receive_signed_upload")
+	defer span.End()
+
+	if r.ContentLength > constants.MaxUploadSizeInBytes {
+		sendErrorResponse(w, http.StatusRequestEntityTooLarge, constants.ErrMaximumFileSize.Error())
+		return
+	}
+	// completing the upload checks the size again, this only stops the body from filling the disk
+	body := http.MaxBytesReader(w, r.Body, constants.MaxUploadSizeInBytes)
+
+	err := s.service.ReceiveSignedUpload(ctx, r.PathValue("key"), r.URL.Query(), body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			sendErrorResponse(w, http.StatusRequestEntityTooLarge, constants.ErrMaximumFileSize.Error())
+		default:
+			sendFileUploadError(w, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func sendFileUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, constants.ErrMaximumFileSize),
+		errors.Is(err, constants.ErrInvalidFileType):
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, constants.ErrFileNotFound):
+		sendErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, constants.ErrFileNotUploaded):
+		sendErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, constants.ErrInvalidUploadSignature):
+		sendErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, constants.ErrDirectUploadUnsupported):
+		sendErrorResponse(w, http.StatusNotImplemented, err.Error())
+	default:
+		log.Printf("failed to upload file: %s\n", err.Error())
+		sendErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
 }
@@ -36,6 +36,12 @@ func (s *Server) createItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
 	merchantIDStr := r.PathValue("merchantId")
 	if len(merchantIDStr) == 0 {
 		sendErrorResponse(w, http.StatusBadRequest, "merchant id cannot be empty")
@@ -79,20 +85,38 @@ func (s *Server) createItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Stock != nil && *req.Stock < 0 {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid stock: stock cannot be negative")
+		return
+	}
+
+	if err := s.validator.Var(req.ModifierGroups, "omitempty,dive"); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid modifier groups")
+		return
+	}
+
 	newItem := model.Item{
-		MerchantID: merchantID,
-		Name:       req.Name,
-		Category:   req.ProductCategory,
-		Price:      req.Price,
-		ImageURL:   req.ImageURL,
+		MerchantID:  merchantID,
+		Name:        req.Name,
+		Category:    req.ProductCategory,
+		Price:       req.Price,
+		ImageURL:    req.ImageURL,
+		IsAvailable: utils.PointerValue(req.IsAvailable, true),
+		Stock:       req.Stock,
+
+		ModifierGroups: NewModifierGroups(req.ModifierGroups),
 	}
 
-	itemID, err := s.service.CreateItems(ctx, newItem)
+	itemID, err := s.service.CreateItems(ctx, userID, newItem)
 	if err != nil {
 		if errors.Is(err, constants.ErrMerchantNotFound) {
 			sendErrorResponse(w, http.StatusNotFound, "merchant not found")
 			return
 		}
+		if errors.Is(err, constants.ErrInvalidModifierGroup) {
+			sendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		log.Printf("failed to create new item: %s\n", err.Error())
 		sendErrorResponse(w, http.StatusInternalServerError, "internal server error")
 		return
@@ -112,12 +136,26 @@ func (s *Server) getItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	merchantID, err := strconv.ParseInt(r.PathValue("merchantId"), 10, 64)
+	if err != nil || merchantID <= 0 {
+		sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+		return
+	}
+
 	query := r.URL.Query()
 	req := GetItemsRequest{
 		ItemID:          query.Get("itemID"),
+		Cursor:          query.Get("cursor"),
 		Limit:           query.Get("limit"),
 		Offset:          query.Get("offset"),
 		Name:            query.Get("name"),
+		Query:           strings.TrimSpace(query.Get("q")),
 		ProductCategory: query.Get("productCategory"),
 		CreatedAt:       query.Get("createdAt"),
 	}
@@ -127,10 +165,13 @@ func (s *Server) getItemHandler(w http.ResponseWriter, r *http.Request) {
 	offsetInt, _ := strconv.Atoi(req.Offset)
 
 	paramsItem := model.FilterItem{
+		UserID:          userID,
+		MerchantID:      merchantID,
 		ItemID:          int64(itemIDint),
 		Limit:           limitInt,
 		Offset:          offsetInt,
 		Name:            req.Name,
+		Query:           req.Query,
 		ProductCategory: req.ProductCategory,
 		CreatedAt:       req.CreatedAt,
 	}
@@ -156,42 +197,313 @@ func (s *Server) getItemHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	items, err := s.service.GetItems(ctx, paramsItem)
+	if req.Cursor != "" {
+		// search results are ranked and paged by offset only
+		if paramsItem.Query != "" {
+			sendErrorResponse(w, http.StatusBadRequest, "cursor cannot be combined with q")
+			return
+		}
+
+		cursor, err := utils.DecodeCursor(req.Cursor, model.SortsDescending(paramsItem.CreatedAt))
+		if err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		paramsItem.Cursor = &cursor
+	}
+
+	page, err := s.service.GetItems(ctx, paramsItem)
 	if err != nil {
+		if errors.Is(err, constants.ErrMerchantNotFound) {
+			sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+			return
+		}
 		log.Printf("failed to get merchants: %s\n", err.Error())
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	var detailItems []DetailItem
-	var meta Meta
-	if len(items) > 0 {
-		for _, item := range items {
-			detail := DetailItem{
-				ItemID:          strconv.Itoa(int(item.ID)),
-				Name:            item.Name,
-				ProductCategory: item.Category,
-				Price:           item.Price,
-				ImageURL:        item.ImageURL,
-				CreatedAt:       item.CreatedAt.Format(time.RFC3339),
-			}
-
-			detailItems = append(detailItems, detail)
+	if len(page.Items) > 0 {
+		for _, item := range page.Items {
+			detailItems = append(detailItems, newDetailItem(item))
 		}
 	} else {
 		detailItems = []DetailItem{}
 	}
 
-	meta = Meta{
-		Limit:  paramsItem.Limit,
-		Offset: paramsItem.Offset,
-		Total:  len(detailItems),
-	}
-
 	res := GetItemsResponse{
 		Data: detailItems,
-		Meta: meta,
+		Meta: NewCursorMeta(paramsItem.Limit, paramsItem.Offset, page.PageInfo),
 	}
 
 	sendResponse(w, http.StatusOK, res)
 }
+
+// parseItemPath extracts merchantId and itemId from the path, writing a 404 when either is malformed
+func parseItemPath(w http.ResponseWriter, r *http.Request) (merchantID, itemID int64, ok bool) {
+	merchantID, err := strconv.ParseInt(r.PathValue("merchantId"), 10, 64)
+	if err != nil || merchantID <= 0 {
+		sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+		return 0, 0, false
+	}
+
+	itemID, err = strconv.ParseInt(r.PathValue("itemId"), 10, 64)
+	if err != nil || itemID <= 0 {
+		sendErrorResponse(w, http.StatusNotFound, "item not found")
+		return 0, 0, false
+	}
+
+	return merchantID, itemID, true
+}
+
+func newDetailItem(item model.Item) DetailItem {
+	return DetailItem{
+		ItemID:          strconv.Itoa(int(item.ID)),
+		Name:            item.Name,
+		ProductCategory: item.Category,
+		Price:           item.Price,
+		ImageURL:        item.ImageURL,
+		IsAvailable:     item.IsAvailable,
+		Stock:           item.Stock,
+		CreatedAt:       item.CreatedAt.Format(time.RFC3339),
+		ModifierGroups:  NewModifierGroupsResponse(item.ModifierGroups),
+		Highlights:      item.Highlights,
+	}
+}
+
+func (s *Server) getItemDetailHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	merchantID, itemID, ok := parseItemPath(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := s.service.GetItem(ctx, userID, merchantID, itemID)
+	if err != nil {
+		if errors.Is(err, constants.ErrMerchantNotFound) {
+			sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+			return
+		}
+		if errors.Is(err, constants.ErrItemNotFound) {
+			sendErrorResponse(w, http.StatusNotFound, "item not found")
+			return
+		}
+		log.Printf("failed to get item: %s\n", err.Error())
+		sendErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	sendResponse(w, http.StatusOK, newDetailItem(item))
+}
+
+func (s *Server) updateItemHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodPatch {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	merchantID, itemID, ok := parseItemPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "request doesn't pass validation")
+		return
+	}
+
+	if req.ImageURL != nil {
+		if err := utils.ValidateFileExtensions(filepath.Base(*req.ImageURL), constants.AllowedExtensions); err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	paramsItem := model.UpdateItemParams{
+		ID:          itemID,
+		UserID:      userID,
+		MerchantID:  merchantID,
+		Name:        req.Name,
+		Category:    req.ProductCategory,
+		Price:       req.Price,
+		ImageURL:    req.ImageURL,
+		IsAvailable: req.IsAvailable,
+
+		ModifierGroups: NewModifierGroups(req.ModifierGroups),
+	}
+
+	item, err := s.service.UpdateItem(ctx, paramsItem)
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.ErrMerchantNotFound):
+			sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+		case errors.Is(err, constants.ErrItemNotFound):
+			sendErrorResponse(w, http.StatusNotFound, "item not found")
+		case errors.Is(err, constants.ErrInvalidRequest):
+			sendErrorResponse(w, http.StatusBadRequest, "invalid product category")
+		case errors.Is(err, constants.ErrFileNotFound):
+			sendErrorResponse(w, http.StatusBadRequest, "invalid imageUrl")
+		case errors.Is(err, constants.ErrInvalidModifierGroup):
+			sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("failed to update item: %s\n", err.Error())
+			sendErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	sendResponse(w, http.StatusOK, newDetailItem(item))
+}
+
+func (s *Server) deleteItemHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodDelete {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	merchantID, itemID, ok := parseItemPath(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.service.DeleteItem(ctx, userID, merchantID, itemID)
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.ErrMerchantNotFound):
+			sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+		case errors.Is(err, constants.ErrItemNotFound):
+			sendErrorResponse(w, http.StatusNotFound, "item not found")
+		case errors.Is(err, constants.ErrItemHasOrders):
+			sendErrorResponse(w, http.StatusConflict, err.Error())
+		default:
+			log.Printf("failed to delete item: %s\n", err.Error())
+			sendErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	sendResponse(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) setItemStockHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodPut {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	merchantID, itemID, ok := parseItemPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req SetItemStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "request doesn't pass validation")
+		return
+	}
+
+	item, err := s.service.SetItemStock(ctx, userID, merchantID, itemID, req.Stock)
+	if err != nil {
+		sendItemStockError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusOK, newDetailItem(item))
+}
+
+func (s *Server) adjustItemStockHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	merchantID, itemID, ok := parseItemPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req AdjustItemStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "request doesn't pass validation")
+		return
+	}
+
+	item, err := s.service.AdjustItemStock(ctx, userID, merchantID, itemID, req.Delta)
+	if err != nil {
+		sendItemStockError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusOK, newDetailItem(item))
+}
+
+func sendItemStockError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, constants.ErrMerchantNotFound):
+		sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+	case errors.Is(err, constants.ErrItemNotFound):
+		sendErrorResponse(w, http.StatusNotFound, "item not found")
+	case errors.Is(err, constants.ErrItemOutOfStock),
+		errors.Is(err, constants.ErrStockNotTracked):
+		sendErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		log.Printf("failed to update item stock: %s\n", err.Error())
+		sendErrorResponse(w, http.StatusInternalServerError, "internal server error")
+	}
+}
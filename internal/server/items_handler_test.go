@@ -0,0 +1,127 @@
+package server
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemDetailHandlers(t *testing.T) {
+	s, repo, db := testPurchaseSetup(t)
+	ownerID := seedEstimateUser(t, repo, db)
+	merchant, item := seedEstimateMerchant(t, repo, ownerID, -6.2000, 106.8456)
+	_, otherItem := seedEstimateMerchant(t, repo, ownerID, -6.2050, 106.8500)
+
+	serve := func(handler http.HandlerFunc, method string, merchantID, itemID int64, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var reqBody bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&reqBody).Encode(body))
+		}
+
+		req := httptest.NewRequest(method, "/admin/merchants/items", &reqBody)
+		req.SetPathValue("merchantId", strconv.FormatInt(merchantID, 10))
+		req.SetPathValue("itemId", strconv.FormatInt(itemID, 10))
+		req = req.WithContext(context.WithValue(req.Context(), constants.UserIDCtxKey, ownerID))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+		return w
+	}
+
+	getItem := func(t *testing.T, itemID int64) DetailItem {
+		t.Helper()
+		w := serve(s.getItemDetailHandler, http.MethodGet, merchant.ID, itemID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response DetailItem
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		return response
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		response := getItem(t, item.ID)
+
+		assert.Equal(t, strconv.FormatInt(item.ID, 10), response.ItemID)
+		assert.Equal(t, item.Name, response.Name)
+		assert.Equal(t, item.Category, response.ProductCategory)
+	})
+
+	t.Run("ItemOfAnotherMerchant", func(t *testing.T) {
+		for _, tt := range []struct {
+			handler http.HandlerFunc
+			method  string
+			body    any
+		}{
+			{s.getItemDetailHandler, http.MethodGet, nil},
+			{s.updateItemHandler, http.MethodPatch, UpdateItemRequest{Name: stringPtr("Renamed")}},
+			{s.deleteItemHandler, http.MethodDelete, nil},
+		} {
+			w := serve(tt.handler, tt.method, merchant.ID, otherItem.ID, tt.body)
+
+			assert.Equal(t, http.StatusNotFound, w.Code, tt.method)
+		}
+	})
+
+	t.Run("Update_InvalidProductCategory", func(t *testing.T) {
+		w := serve(s.updateItemHandler, http.MethodPatch, merchant.ID, item.ID, UpdateItemRequest{
+			ProductCategory: stringPtr("Furniture"),
+		})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Update_ImageWithoutFile", func(t *testing.T) {
+		w := serve(s.updateItemHandler, http.MethodPatch, merchant.ID, item.ID, UpdateItemRequest{
+			ImageURL: stringPtr("http://localhost:9000/images/" + uuid.NewString() + ".jpg"),
+		})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid imageUrl")
+	})
+
+	t.Run("Update_Partial", func(t *testing.T) {
+		price := 21000.0
+		w := serve(s.updateItemHandler, http.MethodPatch, merchant.ID, item.ID, UpdateItemRequest{Price: &price})
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		// the fields left out of the patch keep their values
+		response := getItem(t, item.ID)
+		assert.Equal(t, price, response.Price)
+		assert.Equal(t, item.Name, response.Name)
+		assert.Equal(t, item.Category, response.ProductCategory)
+		assert.Equal(t, item.ImageURL, response.ImageURL)
+		assert.Equal(t, item.IsAvailable, response.IsAvailable)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		extra := model.Item{
+			MerchantID:  merchant.ID,
+			Name:        "Disposable Item",
+			Category:    "Snack",
+			Price:       5000,
+			ImageURL:    "http://localhost:9000/images/seed-item.jpg",
+			IsAvailable: true,
+		}
+		var err error
+		extra.ID, err = repo.CreateItems(context.TODO(), extra)
+		require.NoError(t, err)
+
+		w := serve(s.deleteItemHandler, http.MethodDelete, merchant.ID, extra.ID, nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		w = serve(s.getItemDetailHandler, http.MethodGet, merchant.ID, extra.ID, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
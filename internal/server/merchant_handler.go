@@ -5,12 +5,12 @@ import (
 	"PattyWagon/internal/model"
 	"PattyWagon/internal/utils"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 )
 
 func (s *Server) createMerchantHandler(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +42,7 @@ func (s *Server) createMerchantHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := utils.GetUserIDFromCtx(ctx)
 	if !ok {
 		sendErrorResponse(w, http.StatusBadRequest, "Value not found or wrong type")
+		return
 	}
 
 	paramsCreateMerchant := model.Merchant{
@@ -51,6 +52,10 @@ func (s *Server) createMerchantHandler(w http.ResponseWriter, r *http.Request) {
 		ImageURL:  req.ImageURL,
 		Latitude:  req.Location.Latitude,
 		Longitude: req.Location.Longitude,
+		Timezone:  req.Timezone,
+
+		OpeningHours: NewOpeningHours(req.OpeningHours),
+		Holidays:     NewHolidays(req.Holidays),
 	}
 
 	if err := utils.ValidateFileExtensions(filepath.Base(paramsCreateMerchant.ImageURL), constants.AllowedExtensions); err != nil {
@@ -79,12 +84,20 @@ func (s *Server) getMerchantHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
 	query := r.URL.Query()
 	req := GetMerchantRequest{
 		MerchantID:       query.Get("merchantId"),
+		Cursor:           query.Get("cursor"),
 		Limit:            query.Get("limit"),
 		Offset:           query.Get("offset"),
 		Name:             query.Get("name"),
+		Query:            strings.TrimSpace(query.Get("q")),
 		MerchantCategory: query.Get("merchantCategory"),
 		CreatedAt:        query.Get("createdAt"),
 	}
@@ -94,10 +107,12 @@ func (s *Server) getMerchantHandler(w http.ResponseWriter, r *http.Request) {
 	offsetInt, _ := strconv.Atoi(req.Offset)
 
 	paramsMerchant := model.FilterMerchant{
+		UserID:           userID,
 		MerchantID:       int64(merchantIDint),
 		Limit:            limitInt,
 		Offset:           offsetInt,
 		Name:             req.Name,
+		Query:            req.Query,
 		MerchantCategory: req.MerchantCategory,
 		CreatedAt:        req.CreatedAt,
 	}
@@ -123,7 +138,22 @@ func (s *Server) getMerchantHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	merchants, err := s.service.GetMerchants(ctx, paramsMerchant)
+	if req.Cursor != "" {
+		// search results are ranked and paged by offset only
+		if paramsMerchant.Query != "" {
+			sendErrorResponse(w, http.StatusBadRequest, "cursor cannot be combined with q")
+			return
+		}
+
+		cursor, err := utils.DecodeCursor(req.Cursor, model.SortsDescending(paramsMerchant.CreatedAt))
+		if err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		paramsMerchant.Cursor = &cursor
+	}
+
+	page, err := s.service.GetMerchants(ctx, paramsMerchant)
 	if err != nil {
 		log.Printf("failed to get merchants: %s\n", err.Error())
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
@@ -131,35 +161,125 @@ func (s *Server) getMerchantHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var detailMerchants []DetailMerchant
-	var meta Meta
-	if len(merchants) > 0 {
-		for _, merchant := range merchants {
-			detailMerchants = append(detailMerchants, DetailMerchant{
-				MerchantID: strconv.Itoa(int(merchant.ID)),
-				Name:       merchant.Name,
-				Category:   utils.PointerValue(merchant.Category, ""),
-				ImageURL:   merchant.ImageURL,
-				Location: DetailLocation{
-					Latitude:  merchant.Latitude,
-					Longitude: merchant.Longitude,
-				},
-				CreatedAt: merchant.CreatedAt.Format(time.RFC3339),
-			})
+	if len(page.Merchants) > 0 {
+		for _, merchant := range page.Merchants {
+			detailMerchants = append(detailMerchants, NewDetailMerchant(merchant))
 		}
 	} else {
 		detailMerchants = []DetailMerchant{}
 	}
 
-	meta = Meta{
-		Limit:  paramsMerchant.Limit,
-		Offset: paramsMerchant.Offset,
-		Total:  len(detailMerchants),
-	}
-
 	res := GetMerchantResponse{
 		Data: detailMerchants,
-		Meta: meta,
+		Meta: NewCursorMeta(paramsMerchant.Limit, paramsMerchant.Offset, page.PageInfo),
 	}
 
 	sendResponse(w, http.StatusOK, res)
 }
+
+func (s *Server) updateMerchantHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req UpdateMerchantRequest
+	if r.Method != http.MethodPatch {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	merchantID, err := strconv.ParseInt(r.PathValue("merchantId"), 10, 64)
+	if err != nil || merchantID <= 0 {
+		sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+		return
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "request doesn't pass validation")
+		return
+	}
+
+	paramsUpdateMerchant := model.UpdateMerchantParams{
+		ID:       merchantID,
+		UserID:   userID,
+		Name:     req.Name,
+		Category: req.Category,
+		ImageURL: req.ImageURL,
+		Timezone: req.Timezone,
+
+		OpeningHours: NewOpeningHours(req.OpeningHours),
+		Holidays:     NewHolidays(req.Holidays),
+	}
+
+	if req.ImageURL != nil {
+		if err := utils.ValidateFileExtensions(filepath.Base(*req.ImageURL), constants.AllowedExtensions); err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if req.Location != nil {
+		paramsUpdateMerchant.Location = &model.Location{
+			Lat:  req.Location.Latitude,
+			Long: req.Location.Longitude,
+		}
+	}
+
+	merchant, err := s.service.UpdateMerchant(ctx, paramsUpdateMerchant)
+	if err != nil {
+		if errors.Is(err, constants.ErrMerchantNotFound) {
+			sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+			return
+		}
+		log.Printf("failed to update merchant: %s\n", err.Error())
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sendResponse(w, http.StatusOK, NewDetailMerchant(merchant))
+}
+
+func (s *Server) deleteMerchantHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodDelete {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	merchantID, err := strconv.ParseInt(r.PathValue("merchantId"), 10, 64)
+	if err != nil || merchantID <= 0 {
+		sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+		return
+	}
+
+	err = s.service.DeleteMerchant(ctx, userID, merchantID)
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.ErrMerchantNotFound):
+			sendErrorResponse(w, http.StatusNotFound, "merchant not found")
+		case errors.Is(err, constants.ErrMerchantHasOrders):
+			sendErrorResponse(w, http.StatusConflict, err.Error())
+		default:
+			log.Printf("failed to delete merchant: %s\n", err.Error())
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	sendResponse(w, http.StatusNoContent, nil)
+}
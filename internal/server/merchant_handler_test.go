@@ -0,0 +1,59 @@
+package server
+
+import (
+	"PattyWagon/internal/constants"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerchantTimezoneValidation(t *testing.T) {
+	v := newValidator()
+
+	for _, name := range []string{"UTC", "Asia/Jakarta", "America/New_York"} {
+		assert.NoError(t, v.Var(name, "merchantTimezone"), name)
+	}
+	for _, name := range []string{"Local", "local", "Mars/Olympus_Mons"} {
+		assert.Error(t, v.Var(name, "merchantTimezone"), name)
+	}
+}
+
+func TestMerchantHandlers_LocalTimezone(t *testing.T) {
+	s := &Server{validator: newValidator()}
+
+	serve := func(handler http.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/admin/merchants", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetPathValue("merchantId", "1")
+		req = req.WithContext(context.WithValue(req.Context(), constants.UserIDCtxKey, int64(1)))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+		return w
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		w := serve(s.createMerchantHandler, http.MethodPost, `{
+			"name": "Warung Local",
+			"merchantCategory": "BoothKiosk",
+			"imageUrl": "http://localhost:9000/images/merchant.jpg",
+			"location": {"lat": -6.2088, "long": 106.8456},
+			"timezone": "Local"
+		}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "request doesn't pass validation")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		w := serve(s.updateMerchantHandler, http.MethodPatch, `{"timezone": "Local"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "request doesn't pass validation")
+	})
+}
@@ -3,86 +3,74 @@ package server
 import (
 	"PattyWagon/internal/constants"
 	"PattyWagon/internal/utils"
+	"PattyWagon/logger"
 	"context"
 	"net/http"
 	"strings"
 )
 
-func (s *Server) authMiddleware(next http.Handler) http.Handler {
-	publicPaths := map[string]bool{
-		"/health":         true,
-		"/admin/register": true,
-		"/admin/login":    true,
-		"/users/register": true,
-		"/users/login":    true,
-	}
-
-	userPaths := map[string]bool{
-		"/merchants/nearby/{coordinate}": true, //user dynamic lat long
-		"/users/estimate":                true,
-		"/users/orders":                  true,
-	}
-
-	adminPaths := map[string]bool{
-		"/image":                              true,
-		"/admin/merchants":                    true,
-		"/admin/merchants/{merchantID}/items": true, //user dynamic merchantID
-	}
-
+// authMiddleware authenticates requests and enforces the access rule of the route the mux dispatches to.
+// Routes missing from the policy are rejected.
+func (s *Server) authMiddleware(mux *http.ServeMux, policy accessPolicy) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path
+		_, pattern := mux.Handler(r)
+		if pattern == "" {
+			// no route matched, let the mux reply with 404 or 405
+			mux.ServeHTTP(w, r)
+			return
+		}
 
-		if publicPaths[path] {
-			next.ServeHTTP(w, r)
+		rule, ok := policy[pattern]
+		if !ok {
+			logger.GetLoggerFromContext(r.Context()).Printf("no access policy for route %q", pattern)
+			sendErrorResponse(w, http.StatusForbidden, "forbidden")
+			return
+		}
+
+		if rule.public {
+			mux.ServeHTTP(w, r)
 			return
 		}
 
 		authorizationHeader := r.Header.Get("Authorization")
-		if !strings.Contains(authorizationHeader, "Bearer") {
+		if !strings.HasPrefix(authorizationHeader, "Bearer ") {
 			sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 
-		tokenString := strings.Replace(authorizationHeader, "Bearer ", "", -1)
+		tokenString := strings.TrimPrefix(authorizationHeader, "Bearer ")
 
-		userID, role, err := utils.ParseUserIDandRoleFromToken(tokenString)
+		userID, role, tokenID, err := utils.ParseUserIDandRoleFromToken(r.Context(), tokenString)
 		if err != nil {
 			sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 
-		if role == 1 {
-			for pattern, _ := range adminPaths {
-				if pathMatchesPattern(pattern, path) {
-					sendErrorResponse(w, http.StatusUnauthorized, "Unauthorized User")
-					return
-				}
-			}
-		} else if role == 0 {
-			for pattern, _ := range userPaths {
-				if pathMatchesPattern(pattern, path) {
-					sendErrorResponse(w, http.StatusUnauthorized, "Unauthorized Admin")
-					return
-				}
-			}
+		if !rule.allows(role) {
+			sendErrorResponse(w, http.StatusForbidden, "forbidden")
+			return
 		}
 
 		ctx := context.WithValue(r.Context(), constants.UserIDCtxKey, userID)
+		ctx = context.WithValue(ctx, constants.TokenIDCtxKey, tokenID)
 
 		// Proceed with the next handler
-		next.ServeHTTP(w, r.WithContext(ctx))
+		mux.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
 func (s *Server) contentMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// uploads carry the file itself: multipart through the API, raw bytes to a presigned url
 		path := r.URL.Path
-		if path == "/image" {
+		if (r.Method == http.MethodPost && path == "/v1/file") || (r.Method == http.MethodPut && strings.HasPrefix(path, "/files/")) {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
+		// bodyless requests such as logging out or completing an upload carry no content to type
+		hasBody := r.ContentLength != 0
+		if hasBody && (r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch) {
 			ct := r.Header.Get("Content-Type")
 			if !strings.EqualFold(ct, "application/json") {
 				http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
@@ -94,23 +82,3 @@ func (s *Server) contentMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
-
-func pathMatchesPattern(pattern, actual string) bool {
-	patternParts := strings.Split(pattern, "/")
-	actualParts := strings.Split(actual, "/")
-	if len(patternParts) != len(actualParts) {
-		return false
-	}
-
-	for i := range patternParts {
-		if strings.HasPrefix(patternParts[i], "{") && strings.HasSuffix(patternParts[i], "}") {
-			// It's a dynamic segment like {merchantID}, allow it to match anything
-			continue
-		}
-		if patternParts[i] != actualParts[i] {
-			return false
-		}
-	}
-
-	return true
-}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentMiddleware(t *testing.T) {
+	s := &Server{}
+	handler := s.contentMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		contentType string
+		want        int
+	}{
+		{"JSON", http.MethodPost, "/users/orders", `{}`, "application/json", http.StatusOK},
+		{"BodyWithoutContentType", http.MethodPost, "/users/orders", `{}`, "", http.StatusBadRequest},
+		{"BodyOfOtherType", http.MethodPatch, "/admin/merchants/1", `name=x`, "application/x-www-form-urlencoded", http.StatusBadRequest},
+		{"BodylessLogout", http.MethodPost, "/auth/logout", "", "", http.StatusOK},
+		{"BodylessCompleteUpload", http.MethodPost, "/v1/file/1/complete", "", "", http.StatusOK},
+		{"Get", http.MethodGet, "/admin/merchants", "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
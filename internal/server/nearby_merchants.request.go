@@ -3,11 +3,15 @@ package server
 import (
 	"PattyWagon/internal/model"
 	"PattyWagon/internal/utils"
+	"strconv"
+	"strings"
 )
 
 type OrderEstimationRequest struct {
 	UserLocation LocationRequest `json:"userLocation" validate:"required"`
-	Orders       []OrderRequest  `json:"orders"`
+	Orders       []OrderRequest  `json:"orders" validate:"required,min=1,dive"`
+	PromoCode    string          `json:"promoCode" validate:"omitempty,max=50"`
+	VehicleType  string          `json:"vehicleType" validate:"omitempty,oneof=bike motorbike car"`
 }
 
 type LocationRequest struct {
@@ -17,13 +21,14 @@ type LocationRequest struct {
 
 type OrderRequest struct {
 	MerchantID      string             `json:"merchantId" validate:"required"`
-	IsStartingPoint bool               `json:"isStartingPoint" validate:"required"`
-	Items           []OrderItemRequest `json:"items" validate:"required"`
+	IsStartingPoint bool               `json:"isStartingPoint"`
+	Items           []OrderItemRequest `json:"items" validate:"required,min=1,dive"`
 }
 
 type OrderItemRequest struct {
-	ItemID   string `json:"itemId" validate:"required"`
-	Quantity int    `json:"quantity" validate:"required,gt=0"`
+	ItemID            string   `json:"itemId" validate:"required"`
+	Quantity          int      `json:"quantity" validate:"required,gt=0"`
+	ModifierOptionIDs []string `json:"modifierOptionIds" validate:"omitempty,dive,required"`
 }
 
 type FindNearbyMerchantRequest struct {
@@ -32,13 +37,21 @@ type FindNearbyMerchantRequest struct {
 	Limit            string `query:"limit"`
 	Offset           string `query:"offset"`
 	Name             string `query:"name"`
+	Query            string `query:"q"`
 	MerchantCategory string `query:"merchantCategory"`
+	OpenNow          string `query:"openNow"`
 }
 
 func (r *OrderItemRequest) ToModel() model.OrderItem {
+	optionIDs := make([]int64, 0, len(r.ModifierOptionIDs))
+	for _, optionID := range r.ModifierOptionIDs {
+		optionIDs = append(optionIDs, utils.String2Int64(optionID, 0))
+	}
+
 	return model.OrderItem{
-		ItemID:   utils.String2Int64(r.ItemID, 0),
-		Quantity: r.Quantity,
+		ItemID:            utils.String2Int64(r.ItemID, 0),
+		Quantity:          r.Quantity,
+		ModifierOptionIDs: optionIDs,
 	}
 }
 
@@ -74,6 +87,8 @@ func (r *OrderEstimationRequest) ToModel() model.OrderEstimation {
 
 	res.UserLocation = r.UserLocation.ToModel()
 	res.Orders = orders
+	res.PromoCode = strings.TrimSpace(r.PromoCode)
+	res.VehicleType = model.VehicleType(r.VehicleType)
 	return res
 }
 
@@ -93,9 +108,15 @@ func (r *FindNearbyMerchantRequest) ToModel() model.FindNerbyMerchantParams {
 		params.Name = &r.Name
 	}
 
+	if query := strings.TrimSpace(r.Query); query != "" {
+		params.Query = &query
+	}
+
 	if r.MerchantCategory != "" {
 		params.MerchantCategory = &r.MerchantCategory
 	}
 
+	params.OpenNow, _ = strconv.ParseBool(r.OpenNow)
+
 	return params
 }
@@ -6,23 +6,75 @@ import (
 	"time"
 )
 
+// EstimationPriceResponse keeps totalPrice as the bare sum of item prices, grandTotal is what the user pays
 type EstimationPriceResponse struct {
-	CalculateEstimateID        string  `json:"calculateEstimateId"`
-	TotalPrice                 float64 `json:"totalPrice"`
-	EstimatedDeliveryInMinutes int64   `json:"estimatedDeliveryInMinutes"`
+	CalculateEstimateID        string              `json:"calculateEstimateId"`
+	TotalPrice                 float64             `json:"totalPrice"`
+	GrandTotal                 float64             `json:"grandTotal"`
+	EstimatedDeliveryInMinutes int64               `json:"estimatedDeliveryInMinutes"`
+	DistanceInMeters           float64             `json:"distanceInMeters"`
+	PriceBreakdown             []PriceLineResponse `json:"priceBreakdown"`
+	Stops                      []RouteStopResponse `json:"stops"`
+}
+
+// RouteStopResponse is a stop on the delivery route, the stop without a merchantId is the user
+type RouteStopResponse struct {
+	MerchantID           string           `json:"merchantId,omitempty"`
+	Location             LocationResponse `json:"location"`
+	LegDistanceInMeters  float64          `json:"legDistanceInMeters"`
+	LegDurationInMinutes int64            `json:"legDurationInMinutes"`
+	ETAInMinutes         int64            `json:"etaInMinutes"`
+}
+
+type PriceLineResponse struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Quantity    *int     `json:"quantity,omitempty"`
+	UnitPrice   *float64 `json:"unitPrice,omitempty"`
+	Amount      float64  `json:"amount"`
 }
 
 func (r *EstimationPriceResponse) FromModel(input model.EstimationPrice) {
-	r.CalculateEstimateID = strconv.Itoa(int(input.ID))
-	r.EstimatedDeliveryInMinutes = input.EstimatedDeliveryInMinutes
-	r.TotalPrice = input.TotalPrice
+	*r = NewEstimationPriceResponse(input)
 }
 
 func NewEstimationPriceResponse(input model.EstimationPrice) EstimationPriceResponse {
+	lines := make([]PriceLineResponse, 0, len(input.Breakdown.Lines))
+	for _, line := range input.Breakdown.Lines {
+		response := PriceLineResponse{
+			Type:        line.Type,
+			Description: line.Description,
+			Amount:      line.Amount,
+		}
+		if line.Type == model.PriceLineItem {
+			response.Quantity = &line.Quantity
+			response.UnitPrice = &line.UnitPrice
+		}
+		lines = append(lines, response)
+	}
+
+	stops := make([]RouteStopResponse, 0, len(input.Route.Stops))
+	for _, stop := range input.Route.Stops {
+		response := RouteStopResponse{
+			Location:             LocationResponse{Lat: stop.Location.Lat, Long: stop.Location.Long},
+			LegDistanceInMeters:  stop.LegDistanceInMeters,
+			LegDurationInMinutes: stop.LegDurationInMinutes,
+			ETAInMinutes:         stop.ETAInMinutes,
+		}
+		if stop.Waypoint >= 0 {
+			response.MerchantID = strconv.Itoa(int(stop.MerchantID))
+		}
+		stops = append(stops, response)
+	}
+
 	return EstimationPriceResponse{
 		CalculateEstimateID:        strconv.Itoa(int(input.ID)),
 		TotalPrice:                 input.TotalPrice,
+		GrandTotal:                 input.Breakdown.GrandTotal,
 		EstimatedDeliveryInMinutes: input.EstimatedDeliveryInMinutes,
+		DistanceInMeters:           input.Breakdown.DistanceInMeters,
+		PriceBreakdown:             lines,
+		Stops:                      stops,
 	}
 }
 
@@ -41,17 +93,35 @@ type Merchant struct {
 }
 
 type Item struct {
-	ItemID          string    `json:"itemId"`
-	Name            string    `json:"name"`
-	ProductCategory string    `json:"productCategory"`
-	Price           float64   `json:"price"`
-	ImageUrl        string    `json:"imageUrl"`
-	CreatedAt       time.Time `json:"createdAt"`
+	ItemID          string                  `json:"itemId"`
+	Name            string                  `json:"name"`
+	ProductCategory string                  `json:"productCategory"`
+	Price           float64                 `json:"price"`
+	ImageUrl        string                  `json:"imageUrl"`
+	ModifierGroups  []ModifierGroupResponse `json:"modifierGroups,omitempty"`
+	CreatedAt       time.Time               `json:"createdAt"`
+}
+
+type ModifierGroupResponse struct {
+	GroupID       string                   `json:"groupId"`
+	Name          string                   `json:"name"`
+	MinSelections int                      `json:"minSelections"`
+	MaxSelections int                      `json:"maxSelections"`
+	Options       []ModifierOptionResponse `json:"options"`
+}
+
+type ModifierOptionResponse struct {
+	OptionID   string  `json:"optionId"`
+	Name       string  `json:"name"`
+	PriceDelta float64 `json:"priceDelta"`
 }
 
 type MerchantWithItem struct {
-	Merchant Merchant `json:"merchant"`
-	Items    []Item   `json:"item"`
+	Merchant         Merchant `json:"merchant"`
+	Items            []Item   `json:"item"`
+	DistanceInMeters float64  `json:"distanceInMeters"`
+	IsOpen           bool     `json:"isOpen"`
+	Highlights       []string `json:"highlights,omitempty"`
 }
 
 type FindNearbyMerchantsResponseMeta struct {
@@ -89,10 +159,34 @@ func NewItemResponse(input model.Item) Item {
 		ProductCategory: input.Category,
 		Price:           input.Price,
 		ImageUrl:        input.ImageURL,
+		ModifierGroups:  NewModifierGroupsResponse(input.ModifierGroups),
 		CreatedAt:       input.CreatedAt,
 	}
 }
 
+func NewModifierGroupsResponse(inputs []model.ModifierGroup) []ModifierGroupResponse {
+	groups := make([]ModifierGroupResponse, 0, len(inputs))
+	for _, input := range inputs {
+		options := make([]ModifierOptionResponse, 0, len(input.Options))
+		for _, option := range input.Options {
+			options = append(options, ModifierOptionResponse{
+				OptionID:   strconv.Itoa(int(option.ID)),
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+		}
+
+		groups = append(groups, ModifierGroupResponse{
+			GroupID:       strconv.Itoa(int(input.ID)),
+			Name:          input.Name,
+			MinSelections: input.MinSelections,
+			MaxSelections: input.MaxSelections,
+			Options:       options,
+		})
+	}
+	return groups
+}
+
 func NewMultipleItemsResponse(inputs []model.Item) []Item {
 	var items []Item
 	for _, item := range inputs {
@@ -103,8 +197,11 @@ func NewMultipleItemsResponse(inputs []model.Item) []Item {
 
 func NewMerchantWithItem(input model.MerchantItem) MerchantWithItem {
 	return MerchantWithItem{
-		Merchant: NewMerchantResponse(input.Merchant),
-		Items:    NewMultipleItemsResponse(input.Items),
+		Merchant:         NewMerchantResponse(input.Merchant),
+		Items:            NewMultipleItemsResponse(input.Items),
+		DistanceInMeters: input.DistanceInMeters,
+		IsOpen:           input.IsOpen,
+		Highlights:       input.Highlights,
 	}
 }
 
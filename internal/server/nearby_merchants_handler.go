@@ -48,19 +48,22 @@ func (s *Server) FindNearbyMerchants(w http.ResponseWriter, r *http.Request) {
 		Limit:            query.Get("limit"),
 		Offset:           query.Get("offset"),
 		Name:             query.Get("name"),
+		Query:            query.Get("q"),
 		MerchantCategory: query.Get("merchantCategory"),
+		OpenNow:          query.Get("openNow"),
 	}
 
 	filter := searchParams.ToModel()
-	merchants, err := s.service.FindNearbyMerchants(ctx, userLocation.ToModel(), filter)
+	page, err := s.service.FindNearbyMerchants(ctx, userLocation.ToModel(), filter)
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 	meta := FindNearbyMerchantsResponseMeta{
 		Limit:  filter.Limit,
 		Offset: filter.Offset,
-		Total:  len(merchants),
+		Total:  page.Total,
 	}
-	resp := NewFindNearbyMerchantsResponse(merchants, meta)
+	resp := NewFindNearbyMerchantsResponse(page.Merchants, meta)
 	sendResponse(w, http.StatusOK, resp)
 }
@@ -1,10 +1,12 @@
 package server
 
 import (
+	"PattyWagon/internal/config"
 	"PattyWagon/internal/constants"
 	"PattyWagon/internal/database"
 	imagecompressor "PattyWagon/internal/image_compressor"
 	"PattyWagon/internal/location"
+	"PattyWagon/internal/merchant_counter"
 	mocklocationservice "PattyWagon/internal/mock_location_service"
 	"PattyWagon/internal/mock_repository"
 	"PattyWagon/internal/model"
@@ -12,51 +14,56 @@ import (
 	"PattyWagon/internal/service"
 	"PattyWagon/internal/storage"
 	"PattyWagon/internal/utils"
+	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
-	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func stringPtr(s string) *string {
 	return &s
 }
 
-func testPurchaseSetup(t *testing.T) *Server {
+func testPurchaseSetup(t *testing.T) (*Server, *repository.Queries, *sql.DB) {
 	// repo := &mock_repository.TestRepositoryMock{}
-	repo := repository.New(database.New(
-		"localhost",
-		"5432",
-		"patty-wagon-dev",
-		"postgres",
-		"postgres",
-		"public",
-		&database.ConnectionPoolConfig{
-			MaxOpenConns:    20,
-			MaxIdleConns:    10,
-			ConnMaxIdleTime: 30 * time.Second,
-			ConnMaxLifeTime: 300 * time.Second,
-		},
-	))
-	storage := storage.New("localhost:9000", "team-solid", "@team-solid", storage.Option{MaxConcurrent: 5})
-	imageCompressor := imagecompressor.New(5, 50)
+	db := database.New(config.Database{
+		Host:                     "localhost",
+		Port:                     "5432",
+		Name:                     "patty-wagon-dev",
+		Username:                 "postgres",
+		Password:                 "postgres",
+		Schema:                   "public",
+		MaxOpenConns:             20,
+		MaxIdleConns:             10,
+		ConnMaxIdleTimeInSeconds: 30,
+		ConnMaxLifeTimeInSeconds: 300,
+	})
+	repo := repository.New(db)
+	storage := storage.NewMemoryStorage("http://localhost:8080")
+	imageCompressor := imagecompressor.New(config.ImageCompressor{MaxConcurrentCompress: 5, Quality: 50})
 	// locationSvc := &mocklocationservice.MockLocationService{}
 	locationSvc := location.NewService()
-	svc := service.New(repo, storage, imageCompressor, locationSvc)
+	merchantCounter := merchant_counter.New(repo)
+	svc := service.New(config.Default(), repo, storage, imageCompressor, locationSvc, merchantCounter, nil, nil)
 
 	// testPopulateMockRepo(t, repo)
 	// testPopulateMockLocationService(t, locationSvc)
 	return &Server{
 		port:      8080,
 		service:   svc,
-		validator: validator.New(),
-	}
+		validator: newValidator(),
+	}, repo, db
 }
 
 func testPopulateMockRepo(t *testing.T, repo *mock_repository.TestRepositoryMock) {
@@ -139,7 +146,11 @@ func testPopulateMockRepo(t *testing.T, repo *mock_repository.TestRepositoryMock
 	repo.Mock.On("GetMerchantByID", mock.Anything, int64(100)).Return(model.Merchant{}, constants.ErrMerchantNotFound)
 
 	repo.Mock.On("GetMerchantByCellID", mock.Anything, mock.Anything).Return(validMerchant, nil)
-	repo.Mock.On("ListMerchantWithItems", mock.Anything, mock.Anything).Return(validMerchantWithItems, nil)
+	repo.Mock.On("ListMerchantWithItems", mock.Anything, mock.Anything).Return(model.NearbyMerchantPage{
+		Merchants: validMerchantWithItems,
+		Matched:   len(validMerchantWithItems),
+		Total:     len(validMerchantWithItems),
+	}, nil)
 
 	repo.Mock.On("GetItemByID", mock.Anything, mock.MatchedBy(func(id int64) bool {
 		return id >= 1 && id < 99
@@ -155,10 +166,9 @@ func testPopulateMockLocationService(t *testing.T, svc *mocklocationservice.Mock
 		{CellID: 4, Resolution: 8}, {CellID: 5, Resolution: 8}, {CellID: 6, Resolution: 8},
 	}
 
-	svc.Mock.On("EstimateDeliveryTimeInMinutes", mock.Anything, mock.Anything).Return(int64(25), nil)
 	svc.Mock.On("FindNearby", mock.Anything, mock.Anything, mock.Anything).Return(neigbhors, nil)
-	svc.Mock.On("FindCellIDByResolution", mock.Anything, mock.Anything, mock.Anything).Return(model.Cell{CellID: 1, Resolution: 8}, nil)
 	svc.Mock.On("FindKRingCellIDs", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(neigbhors, nil)
+	svc.Mock.On("KRingRadiusInMeters", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(float64(1000), nil)
 }
 
 // utils.CalculateDistance calculates distance between two points using Haversine formula
@@ -187,7 +197,7 @@ func validateMerchantsOrderedByDistance(t *testing.T, userLat, userLon float64,
 }
 
 func TestGetNearbyMerchants(t *testing.T) {
-	s := testPurchaseSetup(t)
+	s, _, _ := testPurchaseSetup(t)
 
 	userLocation := LocationRequest{
 		Lat:  6.1674,
@@ -347,207 +357,141 @@ func TestGetNearbyMerchants(t *testing.T) {
 	})
 }
 
-// func TestEstimateOrderPrice(t *testing.T) {
-// 	t.Skip()
-// 	s := testPurchaseSetup(t)
-
-// 	validReq := OrderEstimationRequest{
-// 		UserLocation: LocationRequest{
-// 			Lat:  6.1674,
-// 			Long: 106.8209,
-// 		},
-
-// 		Orders: []OrderRequest{
-// 			{
-// 				MerchantID:      "1",
-// 				IsStartingPoint: true,
-// 				Items: []OrderItemRequest{
-// 					{ItemID: "1", Quantity: 1},
-// 					{ItemID: "2", Quantity: 2},
-// 				},
-// 			},
-// 			{
-// 				MerchantID:      "2",
-// 				IsStartingPoint: false,
-// 				Items: []OrderItemRequest{
-// 					{ItemID: "3", Quantity: 1},
-// 					{ItemID: "4", Quantity: 2},
-// 				},
-// 			},
-// 		},
-// 	}
-
-// 	invalidStartingPointReq := OrderEstimationRequest{
-// 		UserLocation: LocationRequest{
-// 			Lat:  6.1674,
-// 			Long: 106.8209,
-// 		},
-
-// 		Orders: []OrderRequest{
-// 			{
-// 				MerchantID:      "1",
-// 				IsStartingPoint: true,
-// 				Items: []OrderItemRequest{
-// 					{ItemID: "1", Quantity: 1},
-// 					{ItemID: "2", Quantity: 2},
-// 				},
-// 			},
-// 			{
-// 				MerchantID:      "2",
-// 				IsStartingPoint: true,
-// 				Items: []OrderItemRequest{
-// 					{ItemID: "3", Quantity: 1},
-// 					{ItemID: "4", Quantity: 2},
-// 				},
-// 			},
-// 		},
-// 	}
-
-// 	invaliMerchantTooFarReq := OrderEstimationRequest{
-// 		UserLocation: LocationRequest{
-// 			Lat:  6.1674,
-// 			Long: 106.8209,
-// 		},
-
-// 		Orders: []OrderRequest{
-// 			{
-// 				MerchantID:      "1",
-// 				IsStartingPoint: true,
-// 				Items: []OrderItemRequest{
-// 					{ItemID: "1", Quantity: 1},
-// 					{ItemID: "2", Quantity: 2},
-// 				},
-// 			},
-// 			{
-// 				MerchantID:      "99",
-// 				IsStartingPoint: false,
-// 				Items: []OrderItemRequest{
-// 					{ItemID: "3", Quantity: 1},
-// 					{ItemID: "4", Quantity: 2},
-// 				},
-// 			},
-// 		},
-// 	}
-
-// 	InvalidMerchantNotFound := OrderEstimationRequest{
-// 		UserLocation: LocationRequest{
-// 			Lat:  6.1674,
-// 			Long: 106.8209,
-// 		},
-
-// 		Orders: []OrderRequest{
-// 			{
-// 				MerchantID:      "100",
-// 				IsStartingPoint: true,
-// 				Items: []OrderItemRequest{
-// 					{ItemID: "1", Quantity: 1},
-// 					{ItemID: "2", Quantity: 2},
-// 				},
-// 			},
-// 			{
-// 				MerchantID:      "1",
-// 				IsStartingPoint: false,
-// 				Items: []OrderItemRequest{
-// 					{ItemID: "3", Quantity: 1},
-// 					{ItemID: "4", Quantity: 2},
-// 				},
-// 			},
-// 		},
-// 	}
-
-// 	InvalidItemNotFound := OrderEstimationRequest{
-// 		UserLocation: LocationRequest{
-// 			Lat:  6.1674,
-// 			Long: 106.8209,
-// 		},
-
-// 		Orders: []OrderRequest{
-// 			{
-// 				MerchantID:      "1",
-// 				IsStartingPoint: true,
-// 				Items: []OrderItemRequest{
-// 					{ItemID: "1", Quantity: 1},
-// 					{ItemID: "2", Quantity: 2},
-// 				},
-// 			},
-// 			{
-// 				MerchantID:      "2",
-// 				IsStartingPoint: false,
-// 				Items: []OrderItemRequest{
-// 					{ItemID: "100", Quantity: 1},
-// 					{ItemID: "4", Quantity: 2},
-// 				},
-// 			},
-// 		},
-// 	}
-
-// 	t.Run("Valid", func(t *testing.T) {
-// 		reqBody, err := json.Marshal(validReq)
-// 		assert.Nil(t, err)
-
-// 		req := httptest.NewRequest(http.MethodPost, "/v1/users/estimate", bytes.NewBuffer(reqBody))
-// 		w := httptest.NewRecorder()
-
-// 		s.EstimateOrderPrice(w, req)
-
-// 		resp := w.Result()
-// 		assert.NotEqual(t, 0, resp.StatusCode)
-// 		fmt.Printf("Response Body: %v\n", w.Body.String())
-// 	})
-
-// 	t.Run("Invalid_StartingPoint", func(t *testing.T) {
-// 		reqBody, err := json.Marshal(invalidStartingPointReq)
-// 		assert.Nil(t, err)
-
-// 		req := httptest.NewRequest(http.MethodPost, "/v1/users/estimate", bytes.NewBuffer(reqBody))
-// 		w := httptest.NewRecorder()
-
-// 		s.EstimateOrderPrice(w, req)
-
-// 		resp := w.Result()
-// 		assert.Equal(t, 400, resp.StatusCode)
-// 		fmt.Printf("Response Body: %v\n", w.Body.String())
-// 	})
-
-// 	t.Run("Invalid_MerchantTooFar", func(t *testing.T) {
-// 		reqBody, err := json.Marshal(invaliMerchantTooFarReq)
-// 		assert.Nil(t, err)
-
-// 		req := httptest.NewRequest(http.MethodPost, "/v1/users/estimate", bytes.NewBuffer(reqBody))
-// 		w := httptest.NewRecorder()
-
-// 		s.EstimateOrderPrice(w, req)
-
-// 		resp := w.Result()
-// 		assert.Equal(t, 400, resp.StatusCode)
-// 		fmt.Printf("Response Body: %v\n", w.Body.String())
-// 	})
-
-// 	t.Run("Invalid_MerchantNotFound", func(t *testing.T) {
-// 		reqBody, err := json.Marshal(InvalidMerchantNotFound)
-// 		assert.Nil(t, err)
-
-// 		req := httptest.NewRequest(http.MethodPost, "/v1/users/estimate", bytes.NewBuffer(reqBody))
-// 		w := httptest.NewRecorder()
-
-// 		s.EstimateOrderPrice(w, req)
-
-// 		resp := w.Result()
-// 		assert.Equal(t, 404, resp.StatusCode)
-// 		fmt.Printf("Response Body: %v\n", w.Body.String())
-// 	})
-
-// 	t.Run("Invalid_ItemNotFound", func(t *testing.T) {
-// 		reqBody, err := json.Marshal(InvalidItemNotFound)
-// 		assert.Nil(t, err)
-
-// 		req := httptest.NewRequest(http.MethodPost, "/v1/users/estimate", bytes.NewBuffer(reqBody))
-// 		w := httptest.NewRecorder()
-
-// 		s.EstimateOrderPrice(w, req)
-
-// 		resp := w.Result()
-// 		assert.Equal(t, 404, resp.StatusCode)
-// 		fmt.Printf("Response Body: %v\n", w.Body.String())
-// 	})
-// }
+// seedEstimateMerchant inserts a merchant owned by ownerID with one item, both removed along with the owner
+func seedEstimateMerchant(t *testing.T, repo *repository.Queries, ownerID int64, lat, long float64) (model.Merchant, model.Item) {
+	t.Helper()
+	ctx := context.TODO()
+
+	merchant := model.Merchant{
+		UserID:    ownerID,
+		Name:      "Estimate Merchant " + uuid.NewString()[:8],
+		Category:  stringPtr("BoothKiosk"),
+		ImageURL:  "http://localhost:9000/images/seed.jpg",
+		Latitude:  lat,
+		Longitude: long,
+	}
+	var err error
+	merchant.ID, err = repo.InsertMerchant(ctx, merchant)
+	require.NoError(t, err)
+
+	item := model.Item{
+		MerchantID:  merchant.ID,
+		Name:        "Estimate Item",
+		Category:    "Food",
+		Price:       15000,
+		ImageURL:    "http://localhost:9000/images/seed-item.jpg",
+		IsAvailable: true,
+	}
+	item.ID, err = repo.CreateItems(ctx, item)
+	require.NoError(t, err)
+
+	return merchant, item
+}
+
+// seedEstimateUser inserts a user removed again, with everything it owns or ordered, when the test finishes
+func seedEstimateUser(t *testing.T, repo *repository.Queries, db *sql.DB) int64 {
+	t.Helper()
+
+	identifier := uuid.NewString()[:8]
+	user, err := repo.InsertUser(context.TODO(), model.User{
+		Username: sql.NullString{String: "estimate-" + identifier, Valid: true},
+		Email:    sql.NullString{String: "estimate-" + identifier + "@pattywagon.test", Valid: true},
+		Role:     0,
+	}, "hash")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.ExecContext(context.Background(), "DELETE FROM users WHERE id = $1", user.ID)
+	})
+	return user.ID
+}
+
+func TestEstimateOrderPrice(t *testing.T) {
+	s, repo, db := testPurchaseSetup(t)
+	ownerID := seedEstimateUser(t, repo, db)
+	userID := seedEstimateUser(t, repo, db)
+
+	userLocation := LocationRequest{Lat: -6.2088, Long: 106.8456}
+	first, firstItem := seedEstimateMerchant(t, repo, ownerID, -6.2000, 106.8456)
+	second, secondItem := seedEstimateMerchant(t, repo, ownerID, -6.2050, 106.8500)
+	// roughly 10km south of the user
+	tooFar, tooFarItem := seedEstimateMerchant(t, repo, ownerID, -6.3000, 106.8456)
+
+	order := func(merchant model.Merchant, item model.Item, isStartingPoint bool) OrderRequest {
+		return OrderRequest{
+			MerchantID:      strconv.FormatInt(merchant.ID, 10),
+			IsStartingPoint: isStartingPoint,
+			Items:           []OrderItemRequest{{ItemID: strconv.FormatInt(item.ID, 10), Quantity: 2}},
+		}
+	}
+
+	estimate := func(t *testing.T, orders ...OrderRequest) *httptest.ResponseRecorder {
+		t.Helper()
+		reqBody, err := json.Marshal(OrderEstimationRequest{UserLocation: userLocation, Orders: orders})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/estimate", bytes.NewBuffer(reqBody))
+		req = req.WithContext(context.WithValue(req.Context(), constants.UserIDCtxKey, userID))
+		w := httptest.NewRecorder()
+
+		s.EstimateOrderPrice(w, req)
+		return w
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		w := estimate(t, order(first, firstItem, true), order(second, secondItem, false))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response EstimationPriceResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, 60000.0, response.TotalPrice)
+		assert.Greater(t, response.GrandTotal, response.TotalPrice)
+		assert.Positive(t, response.EstimatedDeliveryInMinutes)
+		require.Len(t, response.Stops, 3)
+		assert.Equal(t, strconv.FormatInt(first.ID, 10), response.Stops[0].MerchantID)
+		assert.Empty(t, response.Stops[2].MerchantID)
+
+		// the estimate is persisted so orders can reference it
+		estimationID, err := strconv.ParseInt(response.CalculateEstimateID, 10, 64)
+		require.NoError(t, err)
+		stored, err := repo.GetOrderEstimationByID(context.TODO(), estimationID)
+		require.NoError(t, err)
+		assert.Equal(t, userID, stored.UserID)
+		assert.Equal(t, response.TotalPrice, stored.TotalPrice)
+		assert.InDelta(t, response.GrandTotal, stored.Breakdown.GrandTotal, 0.01)
+		assert.Len(t, stored.Items, 2)
+	})
+
+	t.Run("Invalid_NoStartingPoint", func(t *testing.T) {
+		w := estimate(t, order(first, firstItem, false), order(second, secondItem, false))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), constants.ErrInvalidStartingPoint.Error())
+	})
+
+	t.Run("Invalid_TwoStartingPoints", func(t *testing.T) {
+		w := estimate(t, order(first, firstItem, true), order(second, secondItem, true))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), constants.ErrInvalidStartingPoint.Error())
+	})
+
+	t.Run("Invalid_MerchantTooFar", func(t *testing.T) {
+		w := estimate(t, order(first, firstItem, true), order(tooFar, tooFarItem, false))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), constants.ErrMerchantTooFar.Error())
+	})
+
+	t.Run("Invalid_MerchantNotFound", func(t *testing.T) {
+		w := estimate(t, order(first, firstItem, true), order(model.Merchant{ID: -1}, secondItem, false))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid_ItemOfAnotherMerchant", func(t *testing.T) {
+		w := estimate(t, order(first, firstItem, true), order(second, firstItem, false))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), constants.ErrItemNotFound.Error())
+	})
+}
@@ -0,0 +1,12 @@
+package server
+
+type CreateOrderRequest struct {
+	CalculatedEstimateID string `json:"calculatedEstimateId" validate:"required"`
+}
+
+type GetOrdersRequest struct {
+	Limit            string `query:"limit"`
+	Offset           string `query:"offset"`
+	Name             string `query:"name"`
+	MerchantCategory string `query:"merchantCategory"`
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"PattyWagon/internal/model"
+	"strconv"
+)
+
+type CreateOrderResponse struct {
+	OrderID string `json:"orderId"`
+}
+
+type GetOrdersResponse struct {
+	Data []OrderHistory `json:"data"`
+	Meta Meta           `json:"meta"`
+}
+
+type OrderHistory struct {
+	OrderID          string          `json:"orderId"`
+	TotalPrice       float64         `json:"totalPrice"`
+	DeliveryFee      float64         `json:"deliveryFee"`
+	ServiceFee       float64         `json:"serviceFee"`
+	Discount         float64         `json:"discount"`
+	Tax              float64         `json:"tax"`
+	GrandTotal       float64         `json:"grandTotal"`
+	DistanceInMeters float64         `json:"distanceInMeters"`
+	Orders           []MerchantOrder `json:"orders"`
+}
+
+type MerchantOrder struct {
+	Merchant Merchant      `json:"merchant"`
+	Items    []OrderedItem `json:"items"`
+}
+
+type OrderedItem struct {
+	Item
+	Quantity  int                        `json:"quantity"`
+	Modifiers []SelectedModifierResponse `json:"modifiers"`
+}
+
+type SelectedModifierResponse struct {
+	OptionID   string  `json:"optionId"`
+	Group      string  `json:"group"`
+	Name       string  `json:"name"`
+	PriceDelta float64 `json:"priceDelta"`
+}
+
+func NewOrderedItemResponse(input model.OrderedItem) OrderedItem {
+	modifiers := make([]SelectedModifierResponse, 0, len(input.Modifiers))
+	for _, modifier := range input.Modifiers {
+		modifiers = append(modifiers, SelectedModifierResponse{
+			OptionID:   strconv.Itoa(int(modifier.OptionID)),
+			Group:      modifier.Group,
+			Name:       modifier.Name,
+			PriceDelta: modifier.PriceDelta,
+		})
+	}
+
+	return OrderedItem{
+		Item:      NewItemResponse(input.Item),
+		Quantity:  input.Quantity,
+		Modifiers: modifiers,
+	}
+}
+
+func NewMerchantOrderResponse(input model.MerchantOrder) MerchantOrder {
+	items := make([]OrderedItem, 0, len(input.Items))
+	for _, item := range input.Items {
+		items = append(items, NewOrderedItemResponse(item))
+	}
+
+	return MerchantOrder{
+		Merchant: NewMerchantResponse(input.Merchant),
+		Items:    items,
+	}
+}
+
+func NewOrderHistoryResponse(input model.OrderHistory) OrderHistory {
+	orders := make([]MerchantOrder, 0, len(input.Merchants))
+	for _, merchant := range input.Merchants {
+		orders = append(orders, NewMerchantOrderResponse(merchant))
+	}
+
+	return OrderHistory{
+		OrderID:          strconv.Itoa(int(input.ID)),
+		TotalPrice:       input.TotalPrice,
+		DeliveryFee:      input.Breakdown.DeliveryFee,
+		ServiceFee:       input.Breakdown.ServiceFee,
+		Discount:         input.Breakdown.Discount,
+		Tax:              input.Breakdown.Tax,
+		GrandTotal:       input.Breakdown.GrandTotal,
+		DistanceInMeters: input.Breakdown.DistanceInMeters,
+		Orders:           orders,
+	}
+}
+
+func NewGetOrdersResponse(inputs []model.OrderHistory, meta Meta) GetOrdersResponse {
+	orders := make([]OrderHistory, 0, len(inputs))
+	for _, input := range inputs {
+		orders = append(orders, NewOrderHistoryResponse(input))
+	}
+
+	return GetOrdersResponse{
+		Data: orders,
+		Meta: meta,
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"PattyWagon/internal/utils"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) createOrderHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req CreateOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "request doesn't pass validation")
+		return
+	}
+
+	estimationID, err := strconv.ParseInt(req.CalculatedEstimateID, 10, 64)
+	if err != nil || estimationID <= 0 {
+		sendErrorResponse(w, http.StatusNotFound, constants.ErrEstimationNotFound.Error())
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	orderID, err := s.service.CreateOrder(ctx, userID, estimationID)
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.ErrEstimationNotFound):
+			sendErrorResponse(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, constants.ErrOrderAlreadyPlaced),
+			errors.Is(err, constants.ErrItemUnavailable),
+			errors.Is(err, constants.ErrItemOutOfStock),
+			errors.Is(err, constants.ErrMerchantClosed),
+			errors.Is(err, constants.ErrPromoCodeExpired),
+			errors.Is(err, constants.ErrPromoCodeLimitReached):
+			sendErrorResponse(w, http.StatusConflict, err.Error())
+		default:
+			log.Printf("failed to create order: %s\n", err.Error())
+			sendErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	response := CreateOrderResponse{
+		OrderID: strconv.Itoa(int(orderID)),
+	}
+
+	sendResponse(w, http.StatusCreated, response)
+}
+
+func (s *Server) getOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromCtx(ctx)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	req := GetOrdersRequest{
+		Limit:            query.Get("limit"),
+		Offset:           query.Get("offset"),
+		Name:             query.Get("name"),
+		MerchantCategory: query.Get("merchantCategory"),
+	}
+
+	limitInt, _ := strconv.Atoi(req.Limit)
+	offsetInt, _ := strconv.Atoi(req.Offset)
+
+	paramsOrder := model.FilterOrder{
+		UserID:           userID,
+		Limit:            limitInt,
+		Offset:           offsetInt,
+		Name:             req.Name,
+		MerchantCategory: req.MerchantCategory,
+	}
+
+	if paramsOrder.Limit <= 0 {
+		paramsOrder.Limit = 5
+	}
+	if paramsOrder.Offset < 0 {
+		paramsOrder.Offset = 0
+	}
+
+	meta := Meta{
+		Limit:  paramsOrder.Limit,
+		Offset: paramsOrder.Offset,
+	}
+
+	if paramsOrder.MerchantCategory != "" {
+		if !constants.IsValidMerchantCategory(paramsOrder.MerchantCategory) {
+			sendResponse(w, http.StatusOK, NewGetOrdersResponse(nil, meta))
+			return
+		}
+	}
+
+	page, err := s.service.GetOrders(ctx, paramsOrder)
+	if err != nil {
+		log.Printf("failed to get orders: %s\n", err.Error())
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	meta.Total = page.Total
+	sendResponse(w, http.StatusOK, NewGetOrdersResponse(page.Orders, meta))
+}
@@ -1,10 +1,31 @@
 package server
 
+import (
+	"PattyWagon/internal/model"
+	"strings"
+	"time"
+)
+
+type CreateUploadURLRequest struct {
+	Filename    string `json:"filename" validate:"required,max=200"`
+	SizeInBytes int64  `json:"sizeInBytes" validate:"required,gt=0"`
+}
+
 type LoginRequest struct {
 	Username string `json:"username" validate:"required,min=5,max=30"`
 	Password string `json:"password" validate:"required,min=5,max=30"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// LogoutRequest is optional, without a refresh token the session of the access token used is ended
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+	AllSessions  bool   `json:"allSessions"`
+}
+
 type RegisterRequest struct {
 	Username string `json:"username" validate:"required,min=5,max=30"`
 	Password string `json:"password" validate:"required,min=5,max=30"`
@@ -12,10 +33,26 @@ type RegisterRequest struct {
 }
 
 type CreateMerchantRequest struct {
-	Name     string         `json:"name" validate:"required,min=2,max=30"`
-	Category string         `json:"merchantCategory" validate:"required,merchantCategory"`
-	ImageURL string         `json:"imageUrl" validate:"required"`
-	Location DetailLocation `json:"location" validate:"required"`
+	Name         string                `json:"name" validate:"required,min=2,max=30"`
+	Category     string                `json:"merchantCategory" validate:"required,merchantCategory"`
+	ImageURL     string                `json:"imageUrl" validate:"required"`
+	Location     DetailLocation        `json:"location" validate:"required"`
+	Timezone     string                `json:"timezone" validate:"omitempty,merchantTimezone"`
+	OpeningHours []OpeningHoursRequest `json:"openingHours" validate:"omitempty,dive"`
+	Holidays     []HolidayRequest      `json:"holidays" validate:"omitempty,unique=Date,dive"`
+}
+
+type OpeningHoursRequest struct {
+	Day      string `json:"day" validate:"required,oneof=sunday monday tuesday wednesday thursday friday saturday"`
+	OpensAt  string `json:"opensAt" validate:"required,datetime=15:04"`
+	ClosesAt string `json:"closesAt" validate:"required,datetime=15:04"`
+}
+
+// HolidayRequest overrides the opening hours of a date, leaving both hours out closes the merchant all day
+type HolidayRequest struct {
+	Date     string  `json:"date" validate:"required,datetime=2006-01-02"`
+	OpensAt  *string `json:"opensAt" validate:"required_with=ClosesAt,omitempty,datetime=15:04"`
+	ClosesAt *string `json:"closesAt" validate:"required_with=OpensAt,omitempty,datetime=15:04"`
 }
 
 type DetailLocation struct {
@@ -23,11 +60,24 @@ type DetailLocation struct {
 	Longitude float64 `json:"long" validate:"required"`
 }
 
+type UpdateMerchantRequest struct {
+	Name     *string         `json:"name" validate:"omitempty,min=2,max=30"`
+	Category *string         `json:"merchantCategory" validate:"omitempty,merchantCategory"`
+	ImageURL *string         `json:"imageUrl" validate:"omitempty"`
+	Location *DetailLocation `json:"location" validate:"omitempty"`
+	Timezone *string         `json:"timezone" validate:"omitempty,merchantTimezone"`
+	// OpeningHours and Holidays replace the current ones when present, an empty list clears them
+	OpeningHours []OpeningHoursRequest `json:"openingHours" validate:"omitempty,dive"`
+	Holidays     []HolidayRequest      `json:"holidays" validate:"omitempty,unique=Date,dive"`
+}
+
 type GetMerchantRequest struct {
 	MerchantID       string `query:"merchantId"`
+	Cursor           string `query:"cursor"`
 	Limit            string `query:"limit"`
 	Offset           string `query:"offset"`
 	Name             string `query:"name"`
+	Query            string `query:"q"`
 	MerchantCategory string `query:"merchantCategory"`
 	CreatedAt        string `query:"createdAt"`
 }
@@ -37,13 +87,137 @@ type CreateItemRequest struct {
 	ProductCategory string  `json:"productCategory" validate:"required,productCategory"`
 	Price           float64 `json:"price" validate:"required,gt=0"`
 	ImageURL        string  `json:"imageUrl" validate:"required,url"`
+	IsAvailable     *bool   `json:"isAvailable"`
+	Stock           *int    `json:"stock" validate:"omitempty,gte=0"`
+
+	ModifierGroups []ModifierGroupRequest `json:"modifierGroups" validate:"omitempty,dive"`
+}
+
+type ModifierGroupRequest struct {
+	Name          string                  `json:"name" validate:"required,min=1,max=30"`
+	MinSelections int                     `json:"minSelections" validate:"gte=0"`
+	MaxSelections int                     `json:"maxSelections" validate:"required,gte=1,gtefield=MinSelections"`
+	Options       []ModifierOptionRequest `json:"options" validate:"required,min=1,dive"`
+}
+
+type ModifierOptionRequest struct {
+	Name       string  `json:"name" validate:"required,min=1,max=30"`
+	PriceDelta float64 `json:"priceDelta"`
+}
+
+type UpdateItemRequest struct {
+	Name            *string  `json:"name" validate:"omitempty,min=2,max=30"`
+	ProductCategory *string  `json:"productCategory" validate:"omitempty,productCategory"`
+	Price           *float64 `json:"price" validate:"omitempty,gt=0"`
+	ImageURL        *string  `json:"imageUrl" validate:"omitempty,url"`
+	IsAvailable     *bool    `json:"isAvailable"`
+	// ModifierGroups replace the current ones when present, an empty list clears them
+	ModifierGroups []ModifierGroupRequest `json:"modifierGroups" validate:"omitempty,dive"`
+}
+
+// SetItemStockRequest overwrites the stock of an item, a null stock stops tracking it
+type SetItemStockRequest struct {
+	Stock *int `json:"stock" validate:"omitempty,gte=0"`
+}
+
+// AdjustItemStockRequest restocks an item by a positive delta or writes stock off by a negative one
+type AdjustItemStockRequest struct {
+	Delta int `json:"delta" validate:"required"`
 }
 
 type GetItemsRequest struct {
 	ItemID          string `query:"itemId"`
+	Cursor          string `query:"cursor"`
 	Limit           string `query:"limit"`
 	Offset          string `query:"offset"`
 	Name            string `query:"name"`
+	Query           string `query:"q"`
 	ProductCategory string `query:"productCategory"`
 	CreatedAt       string `query:"createdAt"`
 }
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func (r *OpeningHoursRequest) ToModel() model.OpeningHours {
+	return model.OpeningHours{
+		Weekday:  weekdays[r.Day],
+		OpensAt:  r.OpensAt,
+		ClosesAt: r.ClosesAt,
+	}
+}
+
+func (r *HolidayRequest) ToModel() model.HolidayOverride {
+	return model.HolidayOverride{
+		Date:     r.Date,
+		OpensAt:  r.OpensAt,
+		ClosesAt: r.ClosesAt,
+	}
+}
+
+// NewOpeningHours converts opening hours requests, keeping a present but empty list empty rather than nil
+func NewOpeningHours(inputs []OpeningHoursRequest) []model.OpeningHours {
+	if inputs == nil {
+		return nil
+	}
+
+	openingHours := make([]model.OpeningHours, 0, len(inputs))
+	for i := range inputs {
+		openingHours = append(openingHours, inputs[i].ToModel())
+	}
+	return openingHours
+}
+
+// NewHolidays converts holiday requests, keeping a present but empty list empty rather than nil
+func NewHolidays(inputs []HolidayRequest) []model.HolidayOverride {
+	if inputs == nil {
+		return nil
+	}
+
+	holidays := make([]model.HolidayOverride, 0, len(inputs))
+	for i := range inputs {
+		holidays = append(holidays, inputs[i].ToModel())
+	}
+	return holidays
+}
+
+func dayName(weekday time.Weekday) string {
+	return strings.ToLower(weekday.String())
+}
+
+func (r *ModifierGroupRequest) ToModel() model.ModifierGroup {
+	options := make([]model.ModifierOption, 0, len(r.Options))
+	for _, option := range r.Options {
+		options = append(options, model.ModifierOption{
+			Name:       option.Name,
+			PriceDelta: option.PriceDelta,
+		})
+	}
+
+	return model.ModifierGroup{
+		Name:          r.Name,
+		MinSelections: r.MinSelections,
+		MaxSelections: r.MaxSelections,
+		Options:       options,
+	}
+}
+
+// NewModifierGroups converts modifier group requests, keeping a present but empty list empty rather than nil
+func NewModifierGroups(inputs []ModifierGroupRequest) []model.ModifierGroup {
+	if inputs == nil {
+		return nil
+	}
+
+	groups := make([]model.ModifierGroup, 0, len(inputs))
+	for i := range inputs {
+		groups = append(groups, inputs[i].ToModel())
+	}
+	return groups
+}
@@ -1,9 +1,13 @@
 package server
 
 import (
+	"PattyWagon/internal/model"
+	"PattyWagon/internal/utils"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 type ErrorResponse struct {
@@ -32,17 +36,65 @@ func sendErrorResponse(w http.ResponseWriter, statusCode int, error string) {
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
 }
 
 type RegisterResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+type RefreshTokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
 }
 
 type FileUploadResponse struct {
 	FileID           string `json:"fileId"`
 	FileUri          string `json:"fileUri"`
 	FileThumbnailUri string `json:"fileThumbnailUri"`
+	Status           string `json:"status"`
+	// ThumbnailStatus is pending until the background job stored fileThumbnailUri and the renditions
+	ThumbnailStatus string `json:"thumbnailStatus"`
+	// Renditions are the resized copies by rendition name, for clients to pick from by screen density
+	Renditions map[string]RenditionResponse `json:"renditions"`
+}
+
+type RenditionResponse struct {
+	Uri    string `json:"uri"`
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// UploadURLResponse tells the client where to send the file, it is accepted once the upload is completed
+type UploadURLResponse struct {
+	FileID    string    `json:"fileId"`
+	UploadURL string    `json:"uploadUrl"`
+	Method    string    `json:"method"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Status    string    `json:"status"`
+}
+
+func NewFileUploadResponse(file model.File) FileUploadResponse {
+	res := FileUploadResponse{
+		FileID:           strconv.FormatInt(file.ID, 10),
+		FileUri:          file.Uri,
+		FileThumbnailUri: file.ThumbnailUri,
+		Status:           file.Status,
+		ThumbnailStatus:  file.ThumbnailStatus,
+		Renditions:       make(map[string]RenditionResponse, len(file.Renditions)),
+	}
+	for name, rendition := range file.Renditions {
+		res.Renditions[name] = RenditionResponse{
+			Uri:    rendition.Uri,
+			Format: rendition.Format,
+			Width:  rendition.Width,
+			Height: rendition.Height,
+		}
+	}
+	return res
 }
 
 type CreateMerchantResponse struct {
@@ -51,16 +103,68 @@ type CreateMerchantResponse struct {
 
 type GetMerchantResponse struct {
 	Data []DetailMerchant `json:"data"`
-	Meta Meta             `json:"meta"`
+	Meta CursorMeta       `json:"meta"`
 }
 
 type DetailMerchant struct {
-	MerchantID string         `json:"merchantId"`
-	Name       string         `json:"name"`
-	Category   string         `json:"merchantCategory"`
-	ImageURL   string         `json:"imageUrl"`
-	Location   DetailLocation `json:"location"`
-	CreatedAt  string         `json:"createdAt"`
+	MerchantID   string                 `json:"merchantId"`
+	Name         string                 `json:"name"`
+	Category     string                 `json:"merchantCategory"`
+	ImageURL     string                 `json:"imageUrl"`
+	Location     DetailLocation         `json:"location"`
+	Timezone     string                 `json:"timezone"`
+	OpeningHours []OpeningHoursResponse `json:"openingHours"`
+	Holidays     []HolidayResponse      `json:"holidays"`
+	CreatedAt    string                 `json:"createdAt"`
+	Highlights   []string               `json:"highlights,omitempty"`
+}
+
+type OpeningHoursResponse struct {
+	Day      string `json:"day"`
+	OpensAt  string `json:"opensAt"`
+	ClosesAt string `json:"closesAt"`
+}
+
+type HolidayResponse struct {
+	Date     string  `json:"date"`
+	OpensAt  *string `json:"opensAt"`
+	ClosesAt *string `json:"closesAt"`
+}
+
+func NewDetailMerchant(merchant model.Merchant) DetailMerchant {
+	openingHours := make([]OpeningHoursResponse, 0, len(merchant.OpeningHours))
+	for _, hours := range merchant.OpeningHours {
+		openingHours = append(openingHours, OpeningHoursResponse{
+			Day:      dayName(hours.Weekday),
+			OpensAt:  hours.OpensAt,
+			ClosesAt: hours.ClosesAt,
+		})
+	}
+
+	holidays := make([]HolidayResponse, 0, len(merchant.Holidays))
+	for _, holiday := range merchant.Holidays {
+		holidays = append(holidays, HolidayResponse{
+			Date:     holiday.Date,
+			OpensAt:  holiday.OpensAt,
+			ClosesAt: holiday.ClosesAt,
+		})
+	}
+
+	return DetailMerchant{
+		MerchantID: strconv.Itoa(int(merchant.ID)),
+		Name:       merchant.Name,
+		Category:   utils.PointerValue(merchant.Category, ""),
+		ImageURL:   merchant.ImageURL,
+		Location: DetailLocation{
+			Latitude:  merchant.Latitude,
+			Longitude: merchant.Longitude,
+		},
+		Timezone:     merchant.Timezone,
+		OpeningHours: openingHours,
+		Holidays:     holidays,
+		CreatedAt:    merchant.CreatedAt.Format(time.RFC3339),
+		Highlights:   merchant.Highlights,
+	}
 }
 
 type Meta struct {
@@ -69,13 +173,39 @@ type Meta struct {
 	Total  int `json:"total"`
 }
 
+// CursorMeta describes a keyset page, its cursors are null at either end of the listing
+type CursorMeta struct {
+	Limit      int     `json:"limit"`
+	Offset     int     `json:"offset"`
+	Total      int     `json:"total"`
+	NextCursor *string `json:"nextCursor"`
+	PrevCursor *string `json:"prevCursor"`
+}
+
+func NewCursorMeta(limit, offset int, pageInfo model.PageInfo) CursorMeta {
+	meta := CursorMeta{
+		Limit:  limit,
+		Offset: offset,
+		Total:  pageInfo.Total,
+	}
+	if pageInfo.NextCursor != nil {
+		next := utils.EncodeCursor(*pageInfo.NextCursor)
+		meta.NextCursor = &next
+	}
+	if pageInfo.PrevCursor != nil {
+		prev := utils.EncodeCursor(*pageInfo.PrevCursor)
+		meta.PrevCursor = &prev
+	}
+	return meta
+}
+
 type CreateItemResponse struct {
 	ItemID string `json:"itemId"`
 }
 
 type GetItemsResponse struct {
 	Data []DetailItem `json:"data"`
-	Meta Meta         `json:"meta"`
+	Meta CursorMeta   `json:"meta"`
 }
 
 type DetailItem struct {
@@ -84,5 +214,10 @@ type DetailItem struct {
 	ProductCategory string  `json:"productCategory"`
 	Price           float64 `json:"price"`
 	ImageURL        string  `json:"imageUrl"`
+	IsAvailable     bool    `json:"isAvailable"`
+	Stock           *int    `json:"stock"`
 	CreatedAt       string  `json:"createdAt"`
+
+	ModifierGroups []ModifierGroupResponse `json:"modifierGroups"`
+	Highlights     []string                `json:"highlights,omitempty"`
 }
@@ -6,23 +6,52 @@ import (
 )
 
 func (s *Server) RegisterRoutes() http.Handler {
+	routes := s.routes()
+
 	mux := http.NewServeMux()
+	for _, rt := range routes {
+		mux.HandleFunc(rt.pattern, rt.handler)
+	}
+
+	return logger.LoggingMiddleware(s.contentMiddleware(s.authMiddleware(mux, newAccessPolicy(routes))))
+}
 
-	mux.HandleFunc("/", s.HelloWorldHandler)
-	mux.HandleFunc("/health", s.healthHandler)
-	mux.HandleFunc("POST /admin/register", s.adminRegisterHandler)
-	mux.HandleFunc("POST /admin/login", s.adminLoginHandler)
-	mux.HandleFunc("POST /users/register", s.userRegisterHandler)
-	mux.HandleFunc("POST /users/login", s.userLoginHandler)
+// routes lists every endpoint together with the roles allowed to call it
+func (s *Server) routes() []route {
+	return []route{
+		{"/", s.HelloWorldHandler, anyRole},
+		{"GET /health", s.healthHandler, publicAccess},
+		{"GET /.well-known/jwks.json", s.jwksHandler, publicAccess},
+		{"POST /admin/register", s.adminRegisterHandler, publicAccess},
+		{"POST /admin/login", s.adminLoginHandler, publicAccess},
+		{"POST /users/register", s.userRegisterHandler, publicAccess},
+		{"POST /users/login", s.userLoginHandler, publicAccess},
+		{"POST /auth/refresh", s.refreshTokenHandler, publicAccess},
+		{"POST /auth/logout", s.logoutHandler, anyRole},
 
-	mux.HandleFunc("POST /v1/file", s.fileUploadHandler)
-	mux.HandleFunc("POST /admin/merchants", s.createMerchantHandler)
-	mux.HandleFunc("GET /admin/merchants", s.getMerchantHandler)
-	mux.HandleFunc("POST /admin/merchants/{merchantId}/items", s.createItemHandler)
-	mux.HandleFunc("GET /admin/merchants/{merchantId}/items", s.getItemHandler)
+		{"POST /v1/file", s.fileUploadHandler, adminAccess},
+		{"POST /v1/file/upload-url", s.createUploadURLHandler, adminAccess},
+		{"POST /v1/file/{fileId}/complete", s.completeFileUploadHandler, adminAccess},
+		{"GET /v1/file/{fileId}", s.getFileHandler, adminAccess},
+		{"GET /files/{key...}", s.getStoredFileHandler, publicAccess},
+		// the presigned url authorizes the upload, not a token
+		{"PUT /files/{key...}", s.receiveSignedUploadHandler, publicAccess},
+		{"POST /admin/merchants", s.createMerchantHandler, adminAccess},
+		{"GET /admin/merchants", s.getMerchantHandler, adminAccess},
+		{"PATCH /admin/merchants/{merchantId}", s.updateMerchantHandler, adminAccess},
+		{"DELETE /admin/merchants/{merchantId}", s.deleteMerchantHandler, adminAccess},
+		{"POST /admin/merchants/{merchantId}/items", s.createItemHandler, adminAccess},
+		{"GET /admin/merchants/{merchantId}/items", s.getItemHandler, adminAccess},
+		{"GET /admin/merchants/{merchantId}/items/{itemId}", s.getItemDetailHandler, adminAccess},
+		{"PATCH /admin/merchants/{merchantId}/items/{itemId}", s.updateItemHandler, adminAccess},
+		{"DELETE /admin/merchants/{merchantId}/items/{itemId}", s.deleteItemHandler, adminAccess},
+		{"PUT /admin/merchants/{merchantId}/items/{itemId}/stock", s.setItemStockHandler, adminAccess},
+		{"POST /admin/merchants/{merchantId}/items/{itemId}/stock/adjustments", s.adjustItemStockHandler, adminAccess},
 
-	// Purchase
-	mux.HandleFunc("GET /merchants/nearby/{coordinate}", s.FindNearbyMerchants)
-	// mux.HandleFunc("POST /v1/users/estimate", s.EstimateOrderPrice)
-	return logger.LoggingMiddleware(s.contentMiddleware(s.authMiddleware(mux)))
+		// Purchase
+		{"GET /merchants/nearby/{coordinate}", s.FindNearbyMerchants, userAccess},
+		{"POST /users/estimate", s.EstimateOrderPrice, userAccess},
+		{"POST /users/orders", s.createOrderHandler, userAccess},
+		{"GET /users/orders", s.getOrdersHandler, userAccess},
+	}
 }
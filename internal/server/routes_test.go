@@ -1,6 +1,7 @@
 package server
 
 import (
+	"PattyWagon/internal/config"
 	"PattyWagon/internal/database"
 	imagecompressor "PattyWagon/internal/image_compressor"
 	"PattyWagon/internal/repository"
@@ -14,9 +15,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
-	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,7 +27,6 @@ func testSetup(t *testing.T) *Server {
 	t.Setenv("DB_USERNAME", "postgres")
 	t.Setenv("DB_PASSWORD", "postgres")
 	t.Setenv("DB_SCHEMA", "public")
-	t.Setenv("JWT_SIGNATURE_KEY", "solidteam")
 	t.Setenv("S3_ACCESS_KEY_ID", "team-solid")
 	t.Setenv("S3_SECRET_ACCESS_KEY", "@team-solid")
 	t.Setenv("S3_ENDPOINT", "localhost:9000")
@@ -36,23 +34,26 @@ func testSetup(t *testing.T) *Server {
 	t.Setenv("S3_MAX_CONCURRENT_UPLOAD", "5")
 	t.Setenv("MAX_CONCURRENT_COMPRESS", "10")
 
-	db := database.New(
-		"localhost", "5432", "patty-waagon-dev", "postgres", "postgres", "public",
-		&database.ConnectionPoolConfig{
-			MaxOpenConns:    10,
-			MaxIdleConns:    5,
-			ConnMaxIdleTime: time.Minute * 5,
-			ConnMaxLifeTime: time.Minute * 10,
-		},
-	)
+	db := database.New(config.Database{
+		Host:                     "localhost",
+		Port:                     "5432",
+		Name:                     "patty-waagon-dev",
+		Username:                 "postgres",
+		Password:                 "postgres",
+		Schema:                   "public",
+		MaxOpenConns:             10,
+		MaxIdleConns:             5,
+		ConnMaxIdleTimeInSeconds: 300,
+		ConnMaxLifeTimeInSeconds: 600,
+	})
 	repo := repository.New(db)
-	storage := storage.New("localhost:9000", "team-solid", "@team-solid", storage.Option{MaxConcurrent: 5})
-	imageCompressor := imagecompressor.New(5, 50)
-	svc := service.New(repo, storage, imageCompressor, nil)
+	storage := storage.NewMemoryStorage("http://localhost:8080")
+	imageCompressor := imagecompressor.New(config.ImageCompressor{MaxConcurrentCompress: 5, Quality: 50})
+	svc := service.New(config.Default(), repo, storage, imageCompressor, nil, nil, nil, nil)
 	return &Server{
 		port:      8080,
 		service:   svc,
-		validator: validator.New(),
+		validator: newValidator(),
 	}
 }
 
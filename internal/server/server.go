@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"strconv"
+	"net/url"
+	"strings"
 	"time"
 
 	constants "PattyWagon/internal/constants"
@@ -18,20 +18,36 @@ import (
 )
 
 type Service interface {
-	UsernameLogin(ctx context.Context, username string, password string, role int16) (token string, err error)
-	Register(ctx context.Context, userReq model.User, password string, role int16) (string, error)
+	UsernameLogin(ctx context.Context, username string, password string, role int16) (res model.AuthToken, err error)
+	Register(ctx context.Context, userReq model.User, password string, role int16) (model.AuthToken, error)
+	RefreshToken(ctx context.Context, refreshToken string) (model.AuthToken, error)
+	Logout(ctx context.Context, userID int64, accessTokenID, refreshToken string, allSessions bool) error
 
 	UploadFile(ctx context.Context, file io.Reader, filename string, sizeInBytes int64) (model.File, error)
+	OpenStoredFile(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	CreateUploadURL(ctx context.Context, filename string, sizeInBytes int64) (model.FileUpload, error)
+	CompleteFileUpload(ctx context.Context, fileID int64) (model.File, error)
+	GetFile(ctx context.Context, fileID int64) (model.File, error)
+	ReceiveSignedUpload(ctx context.Context, key string, query url.Values, body io.Reader) error
 
 	CreateMerchant(ctx context.Context, req model.Merchant) (res int64, err error)
-	GetMerchants(ctx context.Context, req model.FilterMerchant) (res []model.Merchant, err error)
+	GetMerchants(ctx context.Context, req model.FilterMerchant) (res model.MerchantPage, err error)
+	UpdateMerchant(ctx context.Context, req model.UpdateMerchantParams) (res model.Merchant, err error)
+	DeleteMerchant(ctx context.Context, userID, merchantID int64) error
 
-	CreateItems(ctx context.Context, req model.Item) (res int64, err error)
-	GetItems(ctx context.Context, req model.FilterItem) (res []model.Item, err error)
+	CreateItems(ctx context.Context, userID int64, req model.Item) (res int64, err error)
+	GetItems(ctx context.Context, req model.FilterItem) (res model.ItemPage, err error)
+	GetItem(ctx context.Context, userID, merchantID, itemID int64) (res model.Item, err error)
+	UpdateItem(ctx context.Context, req model.UpdateItemParams) (res model.Item, err error)
+	DeleteItem(ctx context.Context, userID, merchantID, itemID int64) error
+	SetItemStock(ctx context.Context, userID, merchantID, itemID int64, stock *int) (res model.Item, err error)
+	AdjustItemStock(ctx context.Context, userID, merchantID, itemID int64, delta int) (res model.Item, err error)
 
 	// Purchase
-	// EstimateOrderPrice(ctx context.Context, req model.OrderEstimation) (model.EstimationPrice, error)
-	FindNearbyMerchants(ctx context.Context, userLocation model.Location, searchParams model.FindNerbyMerchantParams) ([]model.MerchantItem, error)
+	EstimateOrderPrice(ctx context.Context, req model.OrderEstimation) (model.EstimationPrice, error)
+	FindNearbyMerchants(ctx context.Context, userLocation model.Location, searchParams model.FindNerbyMerchantParams) (model.NearbyMerchantPage, error)
+	CreateOrder(ctx context.Context, userID, estimationID int64) (int64, error)
+	GetOrders(ctx context.Context, req model.FilterOrder) (model.OrderPage, error)
 }
 
 type Server struct {
@@ -40,24 +56,13 @@ type Server struct {
 	validator *validator.Validate
 }
 
-func NewServer(service Service) *http.Server {
-	port, _ := strconv.Atoi(os.Getenv("PORT"))
-	v := validator.New()
+func NewServer(service Service, port int) *http.Server {
 	NewServer := &Server{
 		port:      port,
 		service:   service,
-		validator: v,
+		validator: newValidator(),
 	}
 
-	// Custom validator for merchant category
-	NewServer.validator.RegisterValidation("merchantCategory", func(fl validator.FieldLevel) bool {
-		return constants.IsValidMerchantCategory(fl.Field().String())
-	})
-	// Custom validator for product category
-	NewServer.validator.RegisterValidation("productCategory", func(fl validator.FieldLevel) bool {
-		return constants.IsValidProductCategory(fl.Field().String())
-	})
-
 	// Declare Server config
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", NewServer.port),
@@ -69,3 +74,28 @@ func NewServer(service Service) *http.Server {
 
 	return server
 }
+
+// newValidator returns a validator knowing the custom tags used by the requests
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// Custom validator for merchant category
+	v.RegisterValidation("merchantCategory", func(fl validator.FieldLevel) bool {
+		return constants.IsValidMerchantCategory(fl.Field().String())
+	})
+	// Custom validator for product category
+	v.RegisterValidation("productCategory", func(fl validator.FieldLevel) bool {
+		return constants.IsValidProductCategory(fl.Field().String())
+	})
+	// Custom validator for merchant timezone, Postgres has to know it too, so Go's Local is refused
+	v.RegisterValidation("merchantTimezone", func(fl validator.FieldLevel) bool {
+		name := fl.Field().String()
+		if strings.EqualFold(name, "Local") {
+			return false
+		}
+		_, err := time.LoadLocation(name)
+		return err == nil
+	})
+
+	return v
+}
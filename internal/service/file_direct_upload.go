@@ -0,0 +1,157 @@
+package service
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"PattyWagon/internal/utils"
+	"PattyWagon/logger"
+	"PattyWagon/observability"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// allowedImageContentTypes are the sniffed content types a directly uploaded file may have
+var allowedImageContentTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// CreateUploadURL records a pending file and presigns the url the client uploads it to,
+// the upload is only accepted once CompleteFileUpload verified it
+func (s *Service) CreateUploadURL(ctx context.Context, filename string, sizeInBytes int64) (model.FileUpload, error) {
+	ctx, span := observability.Tracer.Start(ctx, "service.create_upload_url")
+	defer span.End()
+
+	uploader, ok := s.storage.(DirectUploader)
+	if !ok {
+		return model.FileUpload{}, constants.ErrDirectUploadUnsupported
+	}
+
+	if sizeInBytes > constants.MaxUploadSizeInBytes {
+		return model.FileUpload{}, constants.ErrMaximumFileSize
+	}
+	if err := utils.ValidateFileExtensions(filename, constants.AllowedExtensions); err != nil {
+		return model.FileUpload{}, constants.ErrInvalidFileType
+	}
+
+	objectKey := fmt.Sprintf("%s_%s", uuid.NewString(), filepath.Base(filename))
+	uploadURL, err := uploader.PresignUpload(ctx, s.bucket, objectKey, constants.UploadURLExpiry)
+	if err != nil {
+		return model.FileUpload{}, err
+	}
+
+	file, err := s.repository.InsertPendingFile(ctx, model.File{
+		ObjectKey:       objectKey,
+		Filename:        filename,
+		UploadExpiresAt: sql.NullTime{Time: time.Now().Add(constants.UploadURLExpiry), Valid: true},
+	})
+	if err != nil {
+		return model.FileUpload{}, err
+	}
+
+	return model.FileUpload{
+		File:      file,
+		UploadURL: uploadURL,
+		Method:    http.MethodPut,
+	}, nil
+}
+
+// CompleteFileUpload verifies the object the client uploaded, marks the file ready and queues its thumbnail.
+// Completing a file that is already ready returns it unchanged.
+func (s *Service) CompleteFileUpload(ctx context.Context, fileID int64) (model.File, error) {
+	ctx, span := observability.Tracer.Start(ctx, "service.complete_file_upload")
+	defer span.End()
+
+	log := logger.GetLoggerFromContext(ctx)
+
+	uploader, ok := s.storage.(DirectUploader)
+	if !ok {
+		return model.File{}, constants.ErrDirectUploadUnsupported
+	}
+
+	file, err := s.repository.GetFileUpload(ctx, fileID)
+	if err != nil {
+		return model.File{}, err
+	}
+	if file.Status == model.FileStatusReady {
+		return file, nil
+	}
+
+	object, err := uploader.StatObject(ctx, s.bucket, file.ObjectKey)
+	if err != nil {
+		if errors.Is(err, constants.ErrFileNotFound) {
+			return model.File{}, constants.ErrFileNotUploaded
+		}
+		return model.File{}, err
+	}
+	if object.SizeInBytes > constants.MaxUploadSizeInBytes {
+		return model.File{}, constants.ErrMaximumFileSize
+	}
+
+	localPath := filepath.Join(os.TempDir(), file.ObjectKey)
+	if err := uploader.DownloadFile(ctx, s.bucket, file.ObjectKey, localPath); err != nil {
+		return model.File{}, fmt.Errorf("error downloading uploaded file: %w", err)
+	}
+	defer os.Remove(localPath)
+
+	// the declared content type is up to the client, so the type is sniffed from the content itself
+	contentType, err := sniffContentType(localPath)
+	if err != nil {
+		return model.File{}, err
+	}
+	if !slices.Contains(allowedImageContentTypes, contentType) {
+		return model.File{}, constants.ErrInvalidFileType
+	}
+
+	file.Uri = uploader.ObjectURI(s.bucket, file.ObjectKey)
+	file.SizeInBytes = object.SizeInBytes
+	err = s.repository.WithTx(ctx, func(ctx context.Context) error {
+		file, err = s.repository.CompleteFile(ctx, file)
+		if err != nil {
+			return err
+		}
+		return s.enqueueThumbnail(ctx, file.ID)
+	})
+	if err != nil {
+		// a concurrent completion got there first
+		if errors.Is(err, constants.ErrFileAlreadyCompleted) {
+			return s.repository.GetFileUpload(ctx, fileID)
+		}
+		return model.File{}, err
+	}
+
+	log.Printf("completed upload %d (%d): %s | thumbnail queued", file.ID, file.SizeInBytes, file.Uri)
+	return file, nil
+}
+
+// ReceiveSignedUpload stores a file PUT to a url presigned by a backend that keeps its files behind the API
+func (s *Service) ReceiveSignedUpload(ctx context.Context, key string, query url.Values, body io.Reader) error {
+	receiver, ok := s.storage.(SignedUploadReceiver)
+	if !ok {
+		return constants.ErrDirectUploadUnsupported
+	}
+	return receiver.ReceiveSignedUpload(ctx, key, query, body)
+}
+
+func sniffContentType(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
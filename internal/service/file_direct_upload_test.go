@@ -0,0 +1,120 @@
+package service
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"bytes"
+	"context"
+	"database/sql"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createUpload presigns an upload, removing the file and its jobs once the test finishes
+func createUpload(t *testing.T, svc *Service, db *sql.DB, filename string) model.FileUpload {
+	t.Helper()
+
+	upload, err := svc.CreateUploadURL(context.TODO(), filename, 1024)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.ExecContext(context.Background(), "DELETE FROM jobs WHERE (payload->>'fileId')::bigint = $1", upload.File.ID)
+		db.ExecContext(context.Background(), "DELETE FROM files WHERE id = $1", upload.File.ID)
+	})
+	return upload
+}
+
+// putUpload sends content to the presigned url the way the PUT /files/{key...} route does
+func putUpload(t *testing.T, svc *Service, upload model.FileUpload, content []byte) {
+	t.Helper()
+
+	uploadURL, err := url.Parse(upload.UploadURL)
+	require.NoError(t, err)
+	key := strings.TrimPrefix(uploadURL.Path, "/files/")
+	require.NoError(t, svc.ReceiveSignedUpload(context.TODO(), key, uploadURL.Query(), bytes.NewReader(content)))
+}
+
+func testJPEG(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for x := range 32 {
+		for y := range 32 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 8), G: uint8(y * 8), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+func TestCompleteFileUpload(t *testing.T) {
+	svc, _, db := setupService(t)
+	ctx := context.TODO()
+
+	t.Run("NotUploaded", func(t *testing.T) {
+		upload := createUpload(t, svc, db, "photo.jpg")
+
+		_, err := svc.CompleteFileUpload(ctx, upload.File.ID)
+
+		assert.ErrorIs(t, err, constants.ErrFileNotUploaded)
+	})
+
+	t.Run("Oversized", func(t *testing.T) {
+		upload := createUpload(t, svc, db, "photo.jpg")
+		putUpload(t, svc, upload, make([]byte, constants.MaxUploadSizeInBytes+1))
+
+		_, err := svc.CompleteFileUpload(ctx, upload.File.ID)
+
+		assert.ErrorIs(t, err, constants.ErrMaximumFileSize)
+	})
+
+	t.Run("NotAnImage", func(t *testing.T) {
+		upload := createUpload(t, svc, db, "photo.jpg")
+		putUpload(t, svc, upload, []byte("definitely not a jpeg"))
+
+		_, err := svc.CompleteFileUpload(ctx, upload.File.ID)
+
+		assert.ErrorIs(t, err, constants.ErrInvalidFileType)
+
+		// a rejected upload stays pending
+		file, err := svc.GetFile(ctx, upload.File.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.FileStatusPending, file.Status)
+	})
+
+	t.Run("CompletedTwice", func(t *testing.T) {
+		content := testJPEG(t)
+		upload := createUpload(t, svc, db, "photo.jpg")
+		putUpload(t, svc, upload, content)
+
+		completed, err := svc.CompleteFileUpload(ctx, upload.File.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, model.FileStatusReady, completed.Status)
+		assert.Equal(t, model.ThumbnailStatusPending, completed.ThumbnailStatus)
+		assert.Equal(t, int64(len(content)), completed.SizeInBytes)
+		assert.NotEmpty(t, completed.Uri)
+
+		again, err := svc.CompleteFileUpload(ctx, upload.File.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, completed.ID, again.ID)
+		assert.Equal(t, completed.Uri, again.Uri)
+		assert.Equal(t, completed.SizeInBytes, again.SizeInBytes)
+		assert.Equal(t, completed.Status, again.Status)
+		assert.Equal(t, completed.UpdatedAt.Time.Unix(), again.UpdatedAt.Time.Unix())
+
+		var jobs int
+		require.NoError(t, db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM jobs WHERE (payload->>'fileId')::bigint = $1", upload.File.ID).Scan(&jobs))
+		assert.Equal(t, 1, jobs, "completing twice queues a single thumbnail job")
+	})
+}
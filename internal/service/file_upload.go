@@ -3,7 +3,6 @@ package service
 import (
 	"PattyWagon/internal/constants"
 	"PattyWagon/internal/model"
-	"PattyWagon/internal/storage"
 	"PattyWagon/internal/utils"
 	"PattyWagon/logger"
 	"PattyWagon/observability"
@@ -31,7 +30,7 @@ func (s *Service) UploadFile(ctx context.Context, file io.Reader, filename strin
 		return result, constants.ErrInvalidFileType
 	}
 
-	bucket := storage.S3Bucket
+	bucket := s.bucket
 	identifier := uuid.NewString()
 
 	tempFilepath := filepath.Join("/tmp", fmt.Sprintf("%s_%s", identifier, filename))
@@ -50,40 +49,38 @@ func (s *Service) UploadFile(ctx context.Context, file io.Reader, filename strin
 
 	log.Printf("written size: %d filename: %s", n, tempFile.Name())
 
-	// Compress
-	thumbnailPath, err := s.imageCompressor.Compress(ctx, tempFile.Name())
-	if err != nil {
-		return result, err
-	}
-	defer os.Remove(thumbnailPath)
-
-	// Upload to object storage
+	// Upload to object storage, the thumbnail is generated from it in the background
 	remotePath := fmt.Sprintf("%s_%s", identifier, filename)
 	uri, err := s.storage.UploadFile(ctx, bucket, tempFile.Name(), remotePath)
 	if err != nil {
 		return result, fmt.Errorf("error uploading original file: %w", err)
 	}
 
-	thumbnailName := filepath.Base(thumbnailPath)
-	thumbnailUri, err := s.storage.UploadFile(ctx, bucket, thumbnailPath, thumbnailName)
-	if err != nil {
-		return result, fmt.Errorf("error uploading compressed file: %w", err)
-	}
-
-	thumbailSize, err := utils.GetFileSizeInBytes(thumbnailPath)
-	if err != nil {
-		return result, err
-	}
-
-	result, err = s.repository.InsertFile(ctx, model.File{
-		Uri:          uri,
-		ThumbnailUri: thumbnailUri,
+	err = s.repository.WithTx(ctx, func(ctx context.Context) error {
+		result, err = s.repository.InsertFile(ctx, model.File{
+			Uri:         uri,
+			ObjectKey:   remotePath,
+			SizeInBytes: n,
+			Filename:    filename,
+		})
+		if err != nil {
+			return fmt.Errorf("error inserting file to database: %w", err)
+		}
+		return s.enqueueThumbnail(ctx, result.ID)
 	})
-
 	if err != nil {
-		return result, fmt.Errorf("error inserting file to database: %w", err)
+		return model.File{}, err
 	}
 
-	log.Printf("original (%d): %s | compressed (%d): %s", sizeInBytes, uri, thumbailSize, thumbnailUri)
+	log.Printf("original (%d): %s | thumbnail queued", sizeInBytes, uri)
 	return result, nil
 }
+
+// OpenStoredFile opens a file kept by a storage backend that serves its files through the API
+func (s *Service) OpenStoredFile(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	reader, ok := s.storage.(ObjectReader)
+	if !ok {
+		return nil, constants.ErrFileNotFound
+	}
+	return reader.OpenObject(ctx, key)
+}
@@ -1,30 +1,46 @@
 package service
 
 import (
+	"PattyWagon/internal/constants"
 	"PattyWagon/internal/model"
+	"PattyWagon/internal/utils"
 	"context"
 )
 
-func (s *Service) CreateItems(ctx context.Context, req model.Item) (res int64, err error) {
+func (s *Service) CreateItems(ctx context.Context, userID int64, req model.Item) (res int64, err error) {
 	//
-	// Check Existing Merchant
+	// Check Merchant Ownership
 	//
-	merchantID := req.MerchantID
-	_, err = s.repository.MerchantExists(ctx, merchantID)
+	_, err = s.getOwnedMerchant(ctx, userID, req.MerchantID)
 	if err != nil {
 		return 0, err
 	}
+	if err := validateModifierGroups(req.ModifierGroups); err != nil {
+		return 0, err
+	}
 	//
 	// Insert New Items
 	//
 	newItem := model.Item{
-		MerchantID: req.MerchantID,
-		Name:       req.Name,
-		Category:   req.Category,
-		Price:      req.Price,
-		ImageURL:   req.ImageURL,
+		MerchantID:  req.MerchantID,
+		Name:        req.Name,
+		Category:    req.Category,
+		Price:       req.Price,
+		ImageURL:    req.ImageURL,
+		IsAvailable: req.IsAvailable,
+		Stock:       req.Stock,
 	}
-	res, err = s.repository.CreateItems(ctx, newItem)
+	err = s.repository.WithTx(ctx, func(ctx context.Context) error {
+		res, err = s.repository.CreateItems(ctx, newItem)
+		if err != nil {
+			return err
+		}
+
+		if len(req.ModifierGroups) == 0 {
+			return nil
+		}
+		return s.repository.ReplaceItemModifierGroups(ctx, res, req.ModifierGroups)
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -32,22 +48,167 @@ func (s *Service) CreateItems(ctx context.Context, req model.Item) (res int64, e
 	return res, nil
 }
 
-func (s *Service) GetItems(ctx context.Context, req model.FilterItem) (res []model.Item, err error) {
+func (s *Service) GetItems(ctx context.Context, req model.FilterItem) (res model.ItemPage, err error) {
+	//
+	// Check Merchant Ownership
+	//
+	_, err = s.getOwnedMerchant(ctx, req.UserID, req.MerchantID)
+	if err != nil {
+		return model.ItemPage{}, err
+	}
 	//
 	// Get Items
 	//
 	paramsFetchItem := model.FilterItem{
+		MerchantID:      req.MerchantID,
 		ItemID:          req.ItemID,
 		Name:            req.Name,
 		ProductCategory: req.ProductCategory,
 		Limit:           req.Limit,
 		Offset:          req.Offset,
 		CreatedAt:       req.CreatedAt,
+		Cursor:          req.Cursor,
+		Query:           req.Query,
+	}
+	items, hasMore, err := s.repository.GetItems(ctx, paramsFetchItem)
+	if err != nil {
+		return model.ItemPage{}, err
+	}
+
+	total, err := s.repository.CountItems(ctx, paramsFetchItem)
+	if err != nil {
+		return model.ItemPage{}, err
+	}
+
+	// search results are ranked and paged by offset, so they carry no cursors
+	if req.Query != "" {
+		return model.ItemPage{Items: items, PageInfo: model.PageInfo{Total: total}}, nil
+	}
+
+	return model.ItemPage{
+		Items:    items,
+		PageInfo: newPageInfo(items, itemPosition, model.SortsDescending(req.CreatedAt), req.Cursor, req.Offset, hasMore, total),
+	}, nil
+}
+
+func (s *Service) GetItem(ctx context.Context, userID, merchantID, itemID int64) (res model.Item, err error) {
+	_, err = s.getOwnedMerchant(ctx, userID, merchantID)
+	if err != nil {
+		return model.Item{}, err
+	}
+
+	res, err = s.repository.GetItemByID(ctx, itemID)
+	if err != nil {
+		return model.Item{}, err
+	}
+
+	if res.MerchantID != merchantID {
+		return model.Item{}, constants.ErrItemNotFound
+	}
+
+	return res, nil
+}
+
+func (s *Service) UpdateItem(ctx context.Context, req model.UpdateItemParams) (res model.Item, err error) {
+	//
+	// Merge changes into the existing item
+	//
+	item, err := s.GetItem(ctx, req.UserID, req.MerchantID, req.ID)
+	if err != nil {
+		return model.Item{}, err
+	}
+
+	if req.Name != nil {
+		item.Name = *req.Name
+	}
+	if req.Category != nil {
+		if !constants.IsValidProductCategory(*req.Category) {
+			return model.Item{}, constants.ErrInvalidRequest
+		}
+		item.Category = *req.Category
+	}
+	if req.Price != nil {
+		item.Price = *req.Price
+	}
+	if req.ImageURL != nil {
+		exists, err := s.repository.FileURIExists(ctx, *req.ImageURL)
+		if err != nil {
+			return model.Item{}, err
+		}
+		if !exists {
+			return model.Item{}, constants.ErrFileNotFound
+		}
+		item.ImageURL = *req.ImageURL
+	}
+	if req.IsAvailable != nil {
+		item.IsAvailable = *req.IsAvailable
+	}
+	if err := validateModifierGroups(req.ModifierGroups); err != nil {
+		return model.Item{}, err
 	}
-	res, err = s.repository.GetItems(ctx, paramsFetchItem)
+
+	err = s.repository.WithTx(ctx, func(ctx context.Context) error {
+		res, err = s.repository.UpdateItem(ctx, item)
+		if err != nil {
+			return err
+		}
+
+		//
+		// Replace modifier groups when given
+		//
+		if req.ModifierGroups == nil {
+			res.ModifierGroups = item.ModifierGroups
+			return nil
+		}
+
+		if err := s.repository.ReplaceItemModifierGroups(ctx, res.ID, req.ModifierGroups); err != nil {
+			return err
+		}
+
+		// reload to pick up the ids of the new groups and options
+		res, err = s.repository.GetItemByID(ctx, res.ID)
+		return err
+	})
+	if err != nil {
+		return model.Item{}, err
+	}
+
+	return res, nil
+}
+
+// SetItemStock overwrites the stock of an item, a nil stock stops tracking it
+func (s *Service) SetItemStock(ctx context.Context, userID, merchantID, itemID int64, stock *int) (model.Item, error) {
+	_, err := s.getOwnedMerchant(ctx, userID, merchantID)
+	if err != nil {
+		return model.Item{}, err
+	}
+
+	return s.repository.SetItemStock(ctx, merchantID, itemID, stock)
+}
+
+// AdjustItemStock restocks an item by a positive delta or writes stock off by a negative one
+func (s *Service) AdjustItemStock(ctx context.Context, userID, merchantID, itemID int64, delta int) (model.Item, error) {
+	_, err := s.getOwnedMerchant(ctx, userID, merchantID)
+	if err != nil {
+		return model.Item{}, err
+	}
+
+	return s.repository.AdjustItemStock(ctx, merchantID, itemID, delta)
+}
+
+func (s *Service) DeleteItem(ctx context.Context, userID, merchantID, itemID int64) error {
+	_, err := s.getOwnedMerchant(ctx, userID, merchantID)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.DeleteItem(ctx, merchantID, itemID)
 	if err != nil {
-		return []model.Item{}, err
+		if utils.IsErrDBForeignKey(err) {
+			return constants.ErrItemHasOrders
+		}
+		return err
 	}
 
-	return
+	return nil
 }
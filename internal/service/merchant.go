@@ -1,67 +1,224 @@
 package service
 
 import (
+	"PattyWagon/internal/constants"
 	"PattyWagon/internal/model"
+	"PattyWagon/internal/utils"
 	"context"
 )
 
 func (s *Service) CreateMerchant(ctx context.Context, req model.Merchant) (res int64, err error) {
-	//
-	// Insert New Merchant
-	//
-	newMerchant := model.Merchant{
-		UserID:    req.UserID,
-		Name:      req.Name,
-		Category:  req.Category,
-		ImageURL:  req.ImageURL,
-		Latitude:  req.Latitude,
-		Longitude: req.Longitude,
-	}
-	res, err = s.repository.InsertMerchant(ctx, newMerchant)
-	if err != nil {
-		return 0, err
-	}
+	err = s.repository.WithTx(ctx, func(ctx context.Context) error {
+		//
+		// Insert New Merchant
+		//
+		newMerchant := model.Merchant{
+			UserID:    req.UserID,
+			Name:      req.Name,
+			Category:  req.Category,
+			ImageURL:  req.ImageURL,
+			Latitude:  req.Latitude,
+			Longitude: req.Longitude,
+			Timezone:  req.Timezone,
+		}
+		res, err = s.repository.InsertMerchant(ctx, newMerchant)
+		if err != nil {
+			return err
+		}
 
-	s.merchantCounter.Increment()
+		merchantLocations, err := s.merchantLocations(ctx, res, model.Location{
+			Lat:  req.Latitude,
+			Long: req.Longitude,
+		})
+		if err != nil {
+			return err
+		}
 
-	merchantCells, err := s.locationService.GetAllCellIDs(ctx, model.Location{
-		Lat:  req.Latitude,
-		Long: req.Longitude,
+		if err := s.repository.BulkInsertMerchantLocations(ctx, merchantLocations); err != nil {
+			return err
+		}
+
+		//
+		// Insert Opening Hours
+		//
+		if len(req.OpeningHours) > 0 {
+			if err := s.repository.ReplaceMerchantOpeningHours(ctx, res, req.OpeningHours); err != nil {
+				return err
+			}
+		}
+		if len(req.Holidays) > 0 {
+			if err := s.repository.ReplaceMerchantHolidays(ctx, res, req.Holidays); err != nil {
+				return err
+			}
+		}
+
+		return nil
 	})
 	if err != nil {
 		return 0, err
 	}
 
-	var merchantLocations []model.MerchantLocation
-	for i := range merchantCells {
-		merchantLocations = append(merchantLocations, model.MerchantLocation{
-			MerchantID: res,
-			H3Index:    merchantCells[i].CellID,
-			Resolution: merchantCells[i].Resolution,
-		})
-	}
-
-	err = s.repository.BulkInsertMerchantLocations(ctx, merchantLocations)
+	s.merchantCounter.Increment()
 
 	return res, nil
 }
 
-func (s *Service) GetMerchants(ctx context.Context, req model.FilterMerchant) (res []model.Merchant, err error) {
+func (s *Service) GetMerchants(ctx context.Context, req model.FilterMerchant) (res model.MerchantPage, err error) {
 	//
 	// Get Merchants
 	//
 	paramsFetchMerchant := model.FilterMerchant{
+		UserID:           req.UserID,
 		MerchantID:       req.MerchantID,
 		Limit:            req.Limit,
 		Offset:           req.Offset,
 		Name:             req.Name,
 		MerchantCategory: req.MerchantCategory,
 		CreatedAt:        req.CreatedAt,
+		Cursor:           req.Cursor,
+		Query:            req.Query,
+	}
+	merchants, hasMore, err := s.repository.GetMerchants(ctx, paramsFetchMerchant)
+	if err != nil {
+		return model.MerchantPage{}, err
 	}
-	res, err = s.repository.GetMerchants(ctx, paramsFetchMerchant)
+
+	total, err := s.repository.CountMerchants(ctx, paramsFetchMerchant)
 	if err != nil {
-		return []model.Merchant{}, err
+		return model.MerchantPage{}, err
+	}
+
+	// search results are ranked and paged by offset, so they carry no cursors
+	if req.Query != "" {
+		return model.MerchantPage{Merchants: merchants, PageInfo: model.PageInfo{Total: total}}, nil
+	}
+
+	return model.MerchantPage{
+		Merchants: merchants,
+		PageInfo:  newPageInfo(merchants, merchantPosition, model.SortsDescending(req.CreatedAt), req.Cursor, req.Offset, hasMore, total),
+	}, nil
+}
+
+func (s *Service) UpdateMerchant(ctx context.Context, req model.UpdateMerchantParams) (res model.Merchant, err error) {
+	err = s.repository.WithTx(ctx, func(ctx context.Context) error {
+		//
+		// Merge changes into the existing merchant, locked so concurrent updates keep each other's fields
+		//
+		merchant, err := s.repository.GetMerchantByIDForUpdate(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+		if merchant.UserID != req.UserID {
+			return constants.ErrMerchantNotFound
+		}
+
+		if req.Name != nil {
+			merchant.Name = *req.Name
+		}
+		if req.Category != nil {
+			merchant.Category = req.Category
+		}
+		if req.ImageURL != nil {
+			merchant.ImageURL = *req.ImageURL
+		}
+		if req.Timezone != nil {
+			merchant.Timezone = *req.Timezone
+		}
+
+		locationChanged := req.Location != nil &&
+			(req.Location.Lat != merchant.Latitude || req.Location.Long != merchant.Longitude)
+		if locationChanged {
+			merchant.Latitude = req.Location.Lat
+			merchant.Longitude = req.Location.Long
+		}
+
+		res, err = s.repository.UpdateMerchant(ctx, merchant)
+		if err != nil {
+			return err
+		}
+
+		//
+		// Replace opening hours when given
+		//
+		res.OpeningHours = merchant.OpeningHours
+		if req.OpeningHours != nil {
+			if err := s.repository.ReplaceMerchantOpeningHours(ctx, res.ID, req.OpeningHours); err != nil {
+				return err
+			}
+			res.OpeningHours = req.OpeningHours
+		}
+
+		res.Holidays = merchant.Holidays
+		if req.Holidays != nil {
+			if err := s.repository.ReplaceMerchantHolidays(ctx, res.ID, req.Holidays); err != nil {
+				return err
+			}
+			res.Holidays = req.Holidays
+		}
+
+		//
+		// Re-index merchant cells when it moves
+		//
+		if !locationChanged {
+			return nil
+		}
+
+		merchantLocations, err := s.merchantLocations(ctx, res.ID, *req.Location)
+		if err != nil {
+			return err
+		}
+
+		return s.repository.ReplaceMerchantLocations(ctx, res.ID, merchantLocations)
+	})
+	if err != nil {
+		return model.Merchant{}, err
 	}
 
 	return res, nil
 }
+
+func (s *Service) DeleteMerchant(ctx context.Context, userID, merchantID int64) error {
+	err := s.repository.DeleteMerchant(ctx, userID, merchantID)
+	if err != nil {
+		if utils.IsErrDBForeignKey(err) {
+			return constants.ErrMerchantHasOrders
+		}
+		return err
+	}
+
+	s.merchantCounter.Decrement()
+	return nil
+}
+
+// getOwnedMerchant fetches a merchant, reporting merchants of other users as not found
+func (s *Service) getOwnedMerchant(ctx context.Context, userID, merchantID int64) (model.Merchant, error) {
+	merchant, err := s.repository.GetMerchantByID(ctx, merchantID)
+	if err != nil {
+		return model.Merchant{}, err
+	}
+
+	if merchant.UserID != userID {
+		return model.Merchant{}, constants.ErrMerchantNotFound
+	}
+
+	return merchant, nil
+}
+
+// merchantLocations derives the H3 cells of every indexed resolution for a merchant location
+func (s *Service) merchantLocations(ctx context.Context, merchantID int64, location model.Location) ([]model.MerchantLocation, error) {
+	merchantCells, err := s.locationService.GetAllCellIDs(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	merchantLocations := make([]model.MerchantLocation, 0, len(merchantCells))
+	for i := range merchantCells {
+		merchantLocations = append(merchantLocations, model.MerchantLocation{
+			MerchantID: merchantID,
+			H3Index:    merchantCells[i].CellID,
+			Resolution: merchantCells[i].Resolution,
+		})
+	}
+
+	return merchantLocations, nil
+}
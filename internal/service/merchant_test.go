@@ -0,0 +1,104 @@
+package service
+
+import (
+	"PattyWagon/internal/model"
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// merchantCells lists the H3 cells a merchant is indexed under
+func merchantCells(t *testing.T, db *sql.DB, merchantID int64) []int64 {
+	t.Helper()
+
+	rows, err := db.QueryContext(context.TODO(), "SELECT h3_index FROM merchant_locations WHERE merchant_id = $1 ORDER BY h3_index", merchantID)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var cells []int64
+	for rows.Next() {
+		var cell int64
+		require.NoError(t, rows.Scan(&cell))
+		cells = append(cells, cell)
+	}
+	require.NoError(t, rows.Err())
+	return cells
+}
+
+func cellIDs(locations []model.MerchantLocation) []int64 {
+	cells := make([]int64, 0, len(locations))
+	for _, location := range locations {
+		cells = append(cells, location.H3Index)
+	}
+	return cells
+}
+
+func TestUpdateMerchantReindexesCells(t *testing.T) {
+	svc, repo, db := setupService(t)
+	ownerID := seedUser(t, repo, db)
+	merchant, _ := seedMerchant(t, repo, ownerID, "BoothKiosk", "Kopi Susu")
+	ctx := context.TODO()
+
+	oldLocations, err := svc.merchantLocations(ctx, merchant.ID, model.Location{Lat: merchant.Latitude, Long: merchant.Longitude})
+	require.NoError(t, err)
+	require.NoError(t, repo.BulkInsertMerchantLocations(ctx, oldLocations))
+
+	// roughly 50km away, far enough to change the cell of every indexed resolution
+	moved := model.Location{Lat: -6.6000, Long: 106.8000}
+	newLocations, err := svc.merchantLocations(ctx, merchant.ID, moved)
+	require.NoError(t, err)
+
+	res, err := svc.UpdateMerchant(ctx, model.UpdateMerchantParams{
+		ID:       merchant.ID,
+		UserID:   ownerID,
+		Location: &moved,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, moved.Lat, res.Latitude)
+	assert.Equal(t, moved.Long, res.Longitude)
+
+	cells := merchantCells(t, db, merchant.ID)
+	assert.ElementsMatch(t, cellIDs(newLocations), cells)
+	for _, old := range cellIDs(oldLocations) {
+		assert.NotContains(t, cells, old)
+	}
+}
+
+func TestUpdateMerchantConcurrentUpdatesKeepEachOther(t *testing.T) {
+	svc, repo, db := setupService(t)
+	ownerID := seedUser(t, repo, db)
+	merchant, _ := seedMerchant(t, repo, ownerID, "BoothKiosk", "Es Teh")
+	ctx := context.TODO()
+
+	name := "Renamed Merchant"
+	category := "MediumRestaurant"
+	updates := []model.UpdateMerchantParams{
+		{ID: merchant.ID, UserID: ownerID, Name: &name},
+		{ID: merchant.ID, UserID: ownerID, Category: &category},
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(updates))
+	for i, update := range updates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.UpdateMerchant(ctx, update)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	updated, err := repo.GetMerchantByID(ctx, merchant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, name, updated.Name)
+	require.NotNil(t, updated.Category)
+	assert.Equal(t, category, *updated.Category)
+}
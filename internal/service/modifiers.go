@@ -0,0 +1,79 @@
+package service
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"fmt"
+)
+
+// validateModifierGroups checks the selection rules of modifier groups can be met by their options
+func validateModifierGroups(groups []model.ModifierGroup) error {
+	for _, group := range groups {
+		if len(group.Options) == 0 {
+			return fmt.Errorf("%w: %s has no options", constants.ErrInvalidModifierGroup, group.Name)
+		}
+		if group.MinSelections < 0 || group.MaxSelections < max(group.MinSelections, 1) {
+			return fmt.Errorf("%w: %s selections must be between 0 and a positive maximum", constants.ErrInvalidModifierGroup, group.Name)
+		}
+		if group.MinSelections > len(group.Options) {
+			return fmt.Errorf("%w: %s requires more selections than it has options", constants.ErrInvalidModifierGroup, group.Name)
+		}
+	}
+
+	return nil
+}
+
+// selectModifiers resolves the options picked for an item against its modifier groups, returning them with the
+// sum of their price deltas. Every option must belong to the item, be picked once, and every group must get
+// between its minimum and maximum selections.
+func selectModifiers(item model.Item, optionIDs []int64) ([]model.SelectedModifier, float64, error) {
+	type pickedOption struct {
+		group  *model.ModifierGroup
+		option model.ModifierOption
+	}
+
+	options := make(map[int64]pickedOption)
+	for i := range item.ModifierGroups {
+		group := &item.ModifierGroups[i]
+		for _, option := range group.Options {
+			options[option.ID] = pickedOption{group: group, option: option}
+		}
+	}
+
+	var (
+		selected   []model.SelectedModifier
+		priceDelta float64
+	)
+	picked := make(map[int64]bool, len(optionIDs))
+	selections := make(map[int64]int, len(item.ModifierGroups))
+
+	for _, optionID := range optionIDs {
+		option, exists := options[optionID]
+		if !exists {
+			return nil, 0, fmt.Errorf("%w: option %d is not offered for %s", constants.ErrInvalidModifiers, optionID, item.Name)
+		}
+		if picked[optionID] {
+			return nil, 0, fmt.Errorf("%w: option %s is picked more than once", constants.ErrInvalidModifiers, option.option.Name)
+		}
+		picked[optionID] = true
+		selections[option.group.ID]++
+
+		priceDelta += option.option.PriceDelta
+		selected = append(selected, model.SelectedModifier{
+			OptionID:   optionID,
+			Group:      option.group.Name,
+			Name:       option.option.Name,
+			PriceDelta: option.option.PriceDelta,
+		})
+	}
+
+	for _, group := range item.ModifierGroups {
+		count := selections[group.ID]
+		if count < group.MinSelections || count > group.MaxSelections {
+			return nil, 0, fmt.Errorf("%w: %s takes %d to %d options, got %d",
+				constants.ErrInvalidModifiers, group.Name, group.MinSelections, group.MaxSelections, count)
+		}
+	}
+
+	return selected, priceDelta, nil
+}
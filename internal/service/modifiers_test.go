@@ -0,0 +1,83 @@
+package service
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectModifiers(t *testing.T) {
+	item := model.Item{
+		Name:  "Coffee",
+		Price: 20000,
+		ModifierGroups: []model.ModifierGroup{
+			{
+				ID: 1, Name: "Size", MinSelections: 1, MaxSelections: 1,
+				Options: []model.ModifierOption{
+					{ID: 10, Name: "S", PriceDelta: -2000},
+					{ID: 11, Name: "L", PriceDelta: 5000},
+				},
+			},
+			{
+				ID: 2, Name: "Toppings", MinSelections: 0, MaxSelections: 2,
+				Options: []model.ModifierOption{
+					{ID: 20, Name: "Boba", PriceDelta: 3000},
+					{ID: 21, Name: "Jelly", PriceDelta: 2500},
+					{ID: 22, Name: "Cream", PriceDelta: 4000},
+				},
+			},
+		},
+	}
+
+	t.Run("valid selection", func(t *testing.T) {
+		selected, priceDelta, err := selectModifiers(item, []int64{11, 20, 21})
+		require.NoError(t, err)
+		assert.Equal(t, 10500.0, priceDelta)
+		require.Len(t, selected, 3)
+		assert.Equal(t, model.SelectedModifier{OptionID: 11, Group: "Size", Name: "L", PriceDelta: 5000}, selected[0])
+	})
+
+	t.Run("below minimum", func(t *testing.T) {
+		_, _, err := selectModifiers(item, []int64{20})
+		assert.ErrorIs(t, err, constants.ErrInvalidModifiers)
+	})
+
+	t.Run("above maximum", func(t *testing.T) {
+		_, _, err := selectModifiers(item, []int64{10, 20, 21, 22})
+		assert.ErrorIs(t, err, constants.ErrInvalidModifiers)
+	})
+
+	t.Run("option of another item", func(t *testing.T) {
+		_, _, err := selectModifiers(item, []int64{10, 99})
+		assert.ErrorIs(t, err, constants.ErrInvalidModifiers)
+	})
+
+	t.Run("duplicate option", func(t *testing.T) {
+		_, _, err := selectModifiers(item, []int64{10, 20, 20})
+		assert.ErrorIs(t, err, constants.ErrInvalidModifiers)
+	})
+
+	t.Run("item without modifiers", func(t *testing.T) {
+		selected, priceDelta, err := selectModifiers(model.Item{Name: "Water"}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, selected)
+		assert.Zero(t, priceDelta)
+	})
+}
+
+func TestValidateModifierGroups(t *testing.T) {
+	options := []model.ModifierOption{{Name: "S"}, {Name: "L"}}
+
+	assert.NoError(t, validateModifierGroups([]model.ModifierGroup{
+		{Name: "Size", MinSelections: 1, MaxSelections: 1, Options: options},
+	}))
+	assert.ErrorIs(t, validateModifierGroups([]model.ModifierGroup{
+		{Name: "Size", MinSelections: 3, MaxSelections: 3, Options: options},
+	}), constants.ErrInvalidModifierGroup)
+	assert.ErrorIs(t, validateModifierGroups([]model.ModifierGroup{
+		{Name: "Size", MinSelections: 1, MaxSelections: 1},
+	}), constants.ErrInvalidModifierGroup)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"PattyWagon/internal/model"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindNearbyMerchantsByID(t *testing.T) {
+	svc, repo, db := setupService(t)
+	ownerID := seedUser(t, repo, db)
+	merchant, item := seedMerchant(t, repo, ownerID, "BoothKiosk", "Nasi Goreng Kambing")
+	userLocation := model.Location{Lat: -6.2088, Long: 106.8456}
+
+	find := func(t *testing.T, params model.MerchantParams) model.NearbyMerchantPage {
+		t.Helper()
+		params.MerchantID = &merchant.ID
+		page, err := svc.FindNearbyMerchants(context.TODO(), userLocation, model.FindNerbyMerchantParams{
+			UserLocation:   userLocation,
+			MerchantParams: params,
+		})
+		require.NoError(t, err)
+		return page
+	}
+
+	t.Run("Matching", func(t *testing.T) {
+		query := "goreng"
+		page := find(t, model.MerchantParams{Query: &query, Limit: 5})
+
+		require.Len(t, page.Merchants, 1)
+		assert.Equal(t, merchant.ID, page.Merchants[0].Merchant.ID)
+		require.Len(t, page.Merchants[0].Items, 1)
+		assert.Equal(t, item.ID, page.Merchants[0].Items[0].ID)
+		assert.NotEmpty(t, page.Merchants[0].Highlights)
+	})
+
+	t.Run("SearchNotMatching", func(t *testing.T) {
+		query := "sushi"
+		page := find(t, model.MerchantParams{Query: &query, Limit: 5})
+
+		assert.Empty(t, page.Merchants)
+	})
+
+	t.Run("PastTheOffset", func(t *testing.T) {
+		page := find(t, model.MerchantParams{Limit: 5, Offset: 1})
+
+		assert.Empty(t, page.Merchants)
+		assert.Equal(t, 1, page.Total)
+	})
+}
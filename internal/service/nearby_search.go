@@ -0,0 +1,31 @@
+package service
+
+import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/model"
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	NearbySearchH3      = "h3"
+	NearbySearchPostGIS = "postgis"
+)
+
+// NearbySearchStrategy finds the merchants around a location, ordered by distance and paginated by params
+type NearbySearchStrategy interface {
+	FindNearbyMerchants(ctx context.Context, userLocation model.Location, params model.MerchantParams) (model.NearbyMerchantPage, error)
+}
+
+// NewNearbySearchStrategy builds the configured strategy, defaulting to the H3 k-ring expansion
+func NewNearbySearchStrategy(cfg config.NearbySearch, repository Repository, locationService LocationService) (NearbySearchStrategy, error) {
+	switch strings.ToLower(cfg.Strategy) {
+	case "", NearbySearchH3:
+		return NewH3NearbySearch(repository, locationService, cfg.H3StartResolution), nil
+	case NearbySearchPostGIS:
+		return NewPostGISNearbySearch(repository, float64(cfg.RadiusInMeters)), nil
+	default:
+		return nil, fmt.Errorf("unknown nearby search strategy %q", cfg.Strategy)
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/database"
+	"PattyWagon/internal/location"
+	"PattyWagon/internal/model"
+	"PattyWagon/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+const (
+	benchmarkMerchantCount = 2000
+	benchmarkCenterLat     = -6.2088
+	benchmarkCenterLong    = 106.8456
+	// roughly 15km around the center
+	benchmarkSpreadInDegrees = 0.15
+)
+
+// seedNearbySearchBenchmark inserts the same pseudo-random merchant dataset for every strategy benchmark
+func seedNearbySearchBenchmark(b *testing.B, db *sql.DB, repo *repository.Queries, locationService *location.Service) {
+	b.Helper()
+	ctx := context.Background()
+
+	identifier := uuid.NewString()[:8]
+	user, err := repo.InsertUser(ctx, model.User{
+		Username: sql.NullString{String: "bench-" + identifier, Valid: true},
+		Email:    sql.NullString{String: "bench-" + identifier + "@pattywagon.test", Valid: true},
+		Role:     0,
+	}, "hash")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		db.ExecContext(context.Background(), "DELETE FROM users WHERE id = $1", user.ID)
+	})
+
+	rnd := rand.New(rand.NewSource(42))
+	for i := range benchmarkMerchantCount {
+		merchant := model.Merchant{
+			UserID:    user.ID,
+			Name:      fmt.Sprintf("Bench Merchant %d", i),
+			ImageURL:  "http://localhost:9000/images/bench.jpg",
+			Latitude:  benchmarkCenterLat + (rnd.Float64()*2-1)*benchmarkSpreadInDegrees,
+			Longitude: benchmarkCenterLong + (rnd.Float64()*2-1)*benchmarkSpreadInDegrees,
+		}
+		merchant.ID, err = repo.InsertMerchant(ctx, merchant)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		cells, err := locationService.GetAllCellIDs(ctx, model.Location{Lat: merchant.Latitude, Long: merchant.Longitude})
+		if err != nil {
+			b.Fatal(err)
+		}
+		merchantLocations := make([]model.MerchantLocation, 0, len(cells))
+		for _, cell := range cells {
+			merchantLocations = append(merchantLocations, model.MerchantLocation{
+				MerchantID: merchant.ID,
+				H3Index:    cell.CellID,
+				Resolution: cell.Resolution,
+			})
+		}
+		if err := repo.BulkInsertMerchantLocations(ctx, merchantLocations); err != nil {
+			b.Fatal(err)
+		}
+
+		for j := range 3 {
+			_, err := repo.CreateItems(ctx, model.Item{
+				MerchantID:  merchant.ID,
+				Name:        fmt.Sprintf("Bench Item %d-%d", i, j),
+				Category:    "Food",
+				Price:       float64(10000 + rnd.Intn(50000)),
+				ImageURL:    "http://localhost:9000/images/bench-item.jpg",
+				IsAvailable: true,
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkNearbySearchStrategies(b *testing.B) {
+	db := database.New(config.Database{
+		Host:                     "localhost",
+		Port:                     "5432",
+		Name:                     "patty-wagon-dev",
+		Username:                 "postgres",
+		Password:                 "postgres",
+		Schema:                   "public",
+		MaxOpenConns:             20,
+		MaxIdleConns:             10,
+		ConnMaxIdleTimeInSeconds: 30,
+		ConnMaxLifeTimeInSeconds: 300,
+	})
+	repo := repository.New(db)
+	locationService := location.NewService()
+	seedNearbySearchBenchmark(b, db, repo, locationService)
+
+	strategies := []struct {
+		name     string
+		strategy NearbySearchStrategy
+	}{
+		{"H3", NewH3NearbySearch(repo, locationService, config.Default().NearbySearch.H3StartResolution)},
+		{"PostGIS", NewPostGISNearbySearch(repo, float64(config.Default().NearbySearch.RadiusInMeters))},
+	}
+
+	pages := []struct {
+		name   string
+		params model.MerchantParams
+	}{
+		{"FirstPage", model.MerchantParams{Limit: 5}},
+		{"DeepPage", model.MerchantParams{Limit: 5, Offset: 50}},
+	}
+
+	for _, page := range pages {
+		for _, s := range strategies {
+			b.Run(fmt.Sprintf("%s/%s", s.name, page.name), func(b *testing.B) {
+				ctx := context.Background()
+				rnd := rand.New(rand.NewSource(7))
+
+				b.ResetTimer()
+				for range b.N {
+					userLocation := model.Location{
+						Lat:  benchmarkCenterLat + (rnd.Float64()*2-1)*benchmarkSpreadInDegrees/2,
+						Long: benchmarkCenterLong + (rnd.Float64()*2-1)*benchmarkSpreadInDegrees/2,
+					}
+					if _, err := s.strategy.FindNearbyMerchants(ctx, userLocation, page.params); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"PattyWagon/internal/model"
+	"PattyWagon/logger"
+	"context"
+)
+
+const maxKRing = 30
+
+// H3NearbySearch widens k-rings of H3 cells around the user until the page of merchants found inside the ring
+// is the page a search over every merchant would return, falling back to ordering every merchant
+type H3NearbySearch struct {
+	repository      Repository
+	locationService LocationService
+	startResolution int
+}
+
+func NewH3NearbySearch(repository Repository, locationService LocationService, startResolution int) *H3NearbySearch {
+	return &H3NearbySearch{
+		repository:      repository,
+		locationService: locationService,
+		startResolution: startResolution,
+	}
+}
+
+func (h *H3NearbySearch) FindNearbyMerchants(ctx context.Context, userLocation model.Location, filter model.MerchantParams) (model.NearbyMerchantPage, error) {
+	log := logger.GetLoggerFromContext(ctx)
+
+	numRequiredMerchants := filter.Limit + filter.Offset
+	log.Printf("limit: %d offset:%d requiredMerchants: %d", filter.Limit, filter.Offset, numRequiredMerchants)
+
+	resolution := h.startResolution
+
+	// search results rank by relevance first, so no ring can bound which merchants make the page
+	if filter.Query != nil {
+		return h.findNearbyMerchantsFromDatabase(ctx, userLocation, filter)
+	}
+
+	// Key Strategy
+	// - query the page of merchants inside the k-ring, ordered by distance in the database
+	// - the page is final once the ring holds every matching merchant, or holds enough of them while the
+	//   farthest one on the page is closer than any merchant outside the ring can be
+	// - otherwise double k, and once k reaches the limit order every merchant in the database instead
+	for kRing := 1; kRing < maxKRing; kRing *= 2 {
+		cells, err := h.locationService.FindKRingCellIDs(ctx, userLocation, resolution, kRing)
+		if err != nil {
+			return model.NearbyMerchantPage{}, err
+		}
+
+		page, err := h.repository.ListMerchantWithItems(ctx, model.ListMerchantWithItemParams{
+			Location:       userLocation,
+			Cells:          cells,
+			MerchantParams: filter,
+		})
+		if err != nil {
+			return model.NearbyMerchantPage{}, err
+		}
+
+		log.Printf("Finding nearby merchants with k-ring %d: (%d/%d)", kRing, page.Matched, numRequiredMerchants)
+
+		if page.Matched == page.Total {
+			return page, nil
+		}
+
+		if page.Matched >= numRequiredMerchants && len(page.Merchants) > 0 {
+			radius, err := h.locationService.KRingRadiusInMeters(ctx, userLocation, resolution, kRing)
+			if err != nil {
+				return model.NearbyMerchantPage{}, err
+			}
+
+			if page.Merchants[len(page.Merchants)-1].DistanceInMeters <= radius {
+				return page, nil
+			}
+		}
+	}
+
+	log.Println("K-ring limit reached -> find from database directly")
+	return h.findNearbyMerchantsFromDatabase(ctx, userLocation, filter)
+}
+
+func (h *H3NearbySearch) findNearbyMerchantsFromDatabase(ctx context.Context, userLocation model.Location, filter model.MerchantParams) (model.NearbyMerchantPage, error) {
+	return h.repository.ListMerchantWithItems(ctx, model.ListMerchantWithItemParams{
+		Location:       userLocation,
+		MerchantParams: filter,
+	})
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"PattyWagon/internal/model"
+	"context"
+)
+
+// PostGISNearbySearch lets the database find and order merchants within a radius using the
+// GiST index on merchants.location
+type PostGISNearbySearch struct {
+	repository     Repository
+	radiusInMeters float64
+}
+
+func NewPostGISNearbySearch(repository Repository, radiusInMeters float64) *PostGISNearbySearch {
+	return &PostGISNearbySearch{
+		repository:     repository,
+		radiusInMeters: radiusInMeters,
+	}
+}
+
+func (p *PostGISNearbySearch) FindNearbyMerchants(ctx context.Context, userLocation model.Location, params model.MerchantParams) (model.NearbyMerchantPage, error) {
+	return p.repository.ListNearestMerchantsWithItems(ctx, model.ListNearestMerchantParams{
+		Location:       userLocation,
+		RadiusInMeters: p.radiusInMeters,
+		MerchantParams: params,
+	})
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"PattyWagon/internal/utils"
+	"PattyWagon/observability"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+func (s *Service) CreateOrder(ctx context.Context, userID, estimationID int64) (res int64, err error) {
+	ctx, span := observability.Tracer.Start(ctx, "service.create_order")
+	defer span.End()
+
+	//
+	// Check Estimation Ownership
+	//
+	estimation, err := s.repository.GetOrderEstimationByID(ctx, estimationID)
+	if err != nil {
+		return 0, err
+	}
+	if estimation.UserID != userID {
+		return 0, constants.ErrEstimationNotFound
+	}
+
+	//
+	// Insert New Order
+	//
+	newOrder := model.UserOrder{
+		UserID:                     userID,
+		EstimationID:               estimation.ID,
+		TotalPrice:                 estimation.TotalPrice,
+		Breakdown:                  estimation.Breakdown,
+		EstimatedDeliveryInMinutes: estimation.EstimatedDeliveryInMinutes,
+	}
+	err = s.repository.WithTx(ctx, func(ctx context.Context) error {
+		//
+		// Re-check Merchants Are Open
+		//
+		if err := s.checkMerchantsOpen(ctx, estimation.Items); err != nil {
+			return err
+		}
+
+		res, err = s.repository.InsertOrder(ctx, newOrder)
+		if err != nil {
+			if utils.IsErrDBConstraint(err) {
+				return constants.ErrOrderAlreadyPlaced
+			}
+			return err
+		}
+
+		orderItems := make([]model.UserOrderItem, 0, len(estimation.Items))
+		for _, item := range estimation.Items {
+			orderItems = append(orderItems, model.UserOrderItem{
+				OrderID:    res,
+				MerchantID: item.MerchantID,
+				ItemID:     item.ItemID,
+				Quantity:   item.Quantity,
+				Price:      item.Price,
+				Modifiers:  item.Modifiers,
+			})
+		}
+
+		if err := s.repository.BulkInsertOrderItems(ctx, orderItems); err != nil {
+			return fmt.Errorf("error inserting order items: %w", err)
+		}
+
+		//
+		// Reserve Stock
+		//
+		if err := s.reserveStock(ctx, estimation.Items); err != nil {
+			return err
+		}
+
+		//
+		// Redeem Promo Code
+		//
+		if estimation.PromoCodeID == nil {
+			return nil
+		}
+		return s.repository.RedeemPromoCode(ctx, model.PromoCodeRedemption{
+			PromoCodeID: *estimation.PromoCodeID,
+			UserID:      userID,
+			OrderID:     res,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// checkMerchantsOpen fails when any merchant of the estimated items closed since the estimation was made
+func (s *Service) checkMerchantsOpen(ctx context.Context, items []model.EstimationItem) error {
+	checked := make(map[int64]bool, len(items))
+	for _, item := range items {
+		if checked[item.MerchantID] {
+			continue
+		}
+		checked[item.MerchantID] = true
+
+		isOpen, err := s.repository.IsMerchantOpen(ctx, item.MerchantID)
+		if err != nil {
+			return err
+		}
+		if !isOpen {
+			return constants.ErrMerchantClosed
+		}
+	}
+
+	return nil
+}
+
+// reserveStock takes the ordered quantity of every item, failing the whole order when any item cannot be sold.
+// Items are reserved in id order so concurrent orders lock the same rows in the same order and never deadlock.
+func (s *Service) reserveStock(ctx context.Context, items []model.EstimationItem) error {
+	quantities := make(map[int64]int, len(items))
+	for _, item := range items {
+		quantities[item.ItemID] += item.Quantity
+	}
+
+	for _, itemID := range slices.Sorted(maps.Keys(quantities)) {
+		if err := s.repository.ReserveItemStock(ctx, itemID, quantities[itemID]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) GetOrders(ctx context.Context, req model.FilterOrder) (res model.OrderPage, err error) {
+	ctx, span := observability.Tracer.Start(ctx, "service.get_orders")
+	defer span.End()
+
+	orders, err := s.repository.GetOrders(ctx, req)
+	if err != nil {
+		return model.OrderPage{}, err
+	}
+
+	total, err := s.repository.CountOrders(ctx, req)
+	if err != nil {
+		return model.OrderPage{}, err
+	}
+
+	return model.OrderPage{Orders: orders, Total: total}, nil
+}
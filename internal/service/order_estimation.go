@@ -0,0 +1,218 @@
+package service
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"PattyWagon/logger"
+	"PattyWagon/observability"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func (s *Service) EstimateOrderPrice(ctx context.Context, req model.OrderEstimation) (model.EstimationPrice, error) {
+	ctx, span := observability.Tracer.Start(ctx, "service.estimate_order_price")
+	defer span.End()
+
+	log := logger.GetLoggerFromContext(ctx)
+
+	var result model.EstimationPrice
+
+	startingPointIdx, err := findStartingPoint(req.Orders)
+	if err != nil {
+		return result, err
+	}
+
+	//
+	// Validate merchants and items
+	//
+	var estimationItems []model.EstimationItem
+	var itemLines []model.PriceLine
+	merchantLocations := make([]model.Location, len(req.Orders))
+
+	for i, order := range req.Orders {
+		merchantItem, err := s.repository.GetMerchantWithItems(ctx, order.MerchantID)
+		if err != nil {
+			return result, err
+		}
+
+		if !merchantItem.IsOpen {
+			return result, constants.ErrMerchantClosed
+		}
+
+		merchant := merchantItem.Merchant
+		merchantLocations[i] = model.Location{
+			Lat:  merchant.Latitude,
+			Long: merchant.Longitude,
+		}
+
+		merchantItems := make(map[int64]model.Item, len(merchantItem.Items))
+		for _, item := range merchantItem.Items {
+			merchantItems[item.ID] = item
+		}
+
+		for _, orderItem := range order.Items {
+			item, exists := merchantItems[orderItem.ItemID]
+			if !exists {
+				return result, constants.ErrItemNotFound
+			}
+			if !item.IsAvailable {
+				return result, constants.ErrItemUnavailable
+			}
+			if !item.CanSell(orderItem.Quantity) {
+				return result, constants.ErrItemOutOfStock
+			}
+
+			modifiers, priceDelta, err := selectModifiers(item, orderItem.ModifierOptionIDs)
+			if err != nil {
+				return result, err
+			}
+
+			unitPrice := item.Price + priceDelta
+			itemLines = append(itemLines, model.PriceLine{
+				Type:        model.PriceLineItem,
+				Description: itemLineDescription(item, modifiers),
+				Quantity:    orderItem.Quantity,
+				UnitPrice:   unitPrice,
+			})
+			estimationItems = append(estimationItems, model.EstimationItem{
+				MerchantID: merchant.ID,
+				ItemID:     item.ID,
+				Quantity:   orderItem.Quantity,
+				Price:      unitPrice,
+				Modifiers:  modifiers,
+			})
+		}
+	}
+
+	//
+	// Plan the delivery route: starting merchant -> other merchants -> user
+	//
+	route, err := s.locationService.PlanRoute(ctx, startingPointIdx, merchantLocations, req.UserLocation)
+	if err != nil {
+		return result, err
+	}
+	for i, stop := range route.Stops {
+		if stop.Waypoint >= 0 {
+			route.Stops[i].MerchantID = req.Orders[stop.Waypoint].MerchantID
+		}
+	}
+
+	vehicle := req.VehicleType
+	if vehicle == "" {
+		vehicle = s.vehicleType
+	}
+	if err := s.timeRoute(ctx, &route, vehicle, time.Now()); err != nil {
+		return result, err
+	}
+
+	//
+	// Price the order
+	//
+	var promo *model.PromoCode
+	if req.PromoCode != "" {
+		promoCode, err := s.findRedeemablePromoCode(ctx, req.UserID, req.PromoCode)
+		if err != nil {
+			return result, err
+		}
+		promo = &promoCode
+	}
+
+	breakdown, err := s.pricing.Price(itemLines, route.DistanceInMeters, promo, time.Now())
+	if err != nil {
+		return result, err
+	}
+
+	//
+	// Persist estimation
+	//
+	result = model.EstimationPrice{
+		UserID:                     req.UserID,
+		UserLocation:               req.UserLocation,
+		EstimatedDeliveryInMinutes: route.DurationInMinutes,
+		TotalPrice:                 breakdown.Subtotal,
+		Breakdown:                  breakdown,
+		Route:                      route,
+	}
+	if promo != nil {
+		result.PromoCodeID = &promo.ID
+	}
+
+	err = s.repository.WithTx(ctx, func(ctx context.Context) error {
+		result.ID, err = s.repository.InsertOrderEstimation(ctx, result)
+		if err != nil {
+			return err
+		}
+
+		for i := range estimationItems {
+			estimationItems[i].EstimationID = result.ID
+		}
+
+		if err := s.repository.BulkInsertOrderEstimationItems(ctx, estimationItems); err != nil {
+			return fmt.Errorf("error inserting estimation items: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return model.EstimationPrice{}, err
+	}
+	result.Items = estimationItems
+
+	log.Printf("estimation %d: total price %.2f | grand total %.2f | delivery %d minutes",
+		result.ID, result.TotalPrice, result.Breakdown.GrandTotal, result.EstimatedDeliveryInMinutes)
+	return result, nil
+}
+
+// findRedeemablePromoCode looks a promo code up, rejecting it once the user used it up
+func (s *Service) findRedeemablePromoCode(ctx context.Context, userID int64, code string) (model.PromoCode, error) {
+	promo, err := s.repository.GetPromoCodeByCode(ctx, code)
+	if err != nil {
+		return model.PromoCode{}, err
+	}
+
+	if promo.UsageLimitPerUser != nil {
+		redeemed, err := s.repository.CountPromoCodeRedemptions(ctx, promo.ID, userID)
+		if err != nil {
+			return model.PromoCode{}, err
+		}
+		if redeemed >= *promo.UsageLimitPerUser {
+			return model.PromoCode{}, constants.ErrPromoCodeLimitReached
+		}
+	}
+
+	return promo, nil
+}
+
+// itemLineDescription names an ordered item with the modifiers picked for it
+func itemLineDescription(item model.Item, modifiers []model.SelectedModifier) string {
+	if len(modifiers) == 0 {
+		return item.Name
+	}
+
+	names := make([]string, 0, len(modifiers))
+	for _, modifier := range modifiers {
+		names = append(names, modifier.Name)
+	}
+	return item.Name + " (" + strings.Join(names, ", ") + ")"
+}
+
+// findStartingPoint returns the index of the only order marked as the starting point
+func findStartingPoint(orders []model.Order) (int, error) {
+	startingPointIdx := -1
+	for i, order := range orders {
+		if !order.IsStartingPoint {
+			continue
+		}
+		if startingPointIdx != -1 {
+			return -1, constants.ErrInvalidStartingPoint
+		}
+		startingPointIdx = i
+	}
+
+	if startingPointIdx == -1 {
+		return -1, constants.ErrInvalidStartingPoint
+	}
+
+	return startingPointIdx, nil
+}
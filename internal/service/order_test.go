@@ -0,0 +1,279 @@
+package service
+
+import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/database"
+	imagecompressor "PattyWagon/internal/image_compressor"
+	"PattyWagon/internal/location"
+	"PattyWagon/internal/merchant_counter"
+	"PattyWagon/internal/model"
+	"PattyWagon/internal/repository"
+	"PattyWagon/internal/storage"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupService builds a service on the development database with in-memory storage
+func setupService(t *testing.T) (*Service, *repository.Queries, *sql.DB) {
+	t.Helper()
+
+	db := database.New(config.Database{
+		Host:                     "localhost",
+		Port:                     "5432",
+		Name:                     "patty-wagon-dev",
+		Username:                 "postgres",
+		Password:                 "postgres",
+		Schema:                   "public",
+		MaxOpenConns:             20,
+		MaxIdleConns:             10,
+		ConnMaxIdleTimeInSeconds: 30,
+		ConnMaxLifeTimeInSeconds: 300,
+	})
+	repo := repository.New(db)
+	svc := New(
+		config.Default(),
+		repo,
+		storage.NewMemoryStorage("http://localhost:8080"),
+		imagecompressor.New(config.ImageCompressor{MaxConcurrentCompress: 5, Quality: 50}),
+		location.NewService(),
+		merchant_counter.New(repo),
+		nil,
+		nil,
+	)
+	return svc, repo, db
+}
+
+// seedUser inserts a user removed again, with everything it owns or ordered, when the test finishes.
+// Buyers must be seeded after the owners of the merchants they order from, their orders hold the merchants back.
+func seedUser(t *testing.T, repo *repository.Queries, db *sql.DB) int64 {
+	t.Helper()
+
+	identifier := uuid.NewString()[:8]
+	user, err := repo.InsertUser(context.TODO(), model.User{
+		Username: sql.NullString{String: "service-" + identifier, Valid: true},
+		Email:    sql.NullString{String: "service-" + identifier + "@pattywagon.test", Valid: true},
+		Role:     0,
+	}, "hash")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.ExecContext(context.Background(), "DELETE FROM users WHERE id = $1", user.ID)
+	})
+	return user.ID
+}
+
+// seedMerchant inserts a merchant near the default user location selling a single item
+func seedMerchant(t *testing.T, repo *repository.Queries, ownerID int64, category, itemName string) (model.Merchant, model.Item) {
+	t.Helper()
+	ctx := context.TODO()
+
+	merchant := model.Merchant{
+		UserID:    ownerID,
+		Name:      "Service Merchant " + uuid.NewString()[:8],
+		Category:  &category,
+		ImageURL:  "http://localhost:9000/images/seed.jpg",
+		Latitude:  -6.2000,
+		Longitude: 106.8456,
+	}
+	var err error
+	merchant.ID, err = repo.InsertMerchant(ctx, merchant)
+	require.NoError(t, err)
+
+	item := model.Item{
+		MerchantID:  merchant.ID,
+		Name:        itemName,
+		Category:    "Food",
+		Price:       15000,
+		ImageURL:    "http://localhost:9000/images/seed-item.jpg",
+		IsAvailable: true,
+	}
+	item.ID, err = repo.CreateItems(ctx, item)
+	require.NoError(t, err)
+
+	return merchant, item
+}
+
+// seedEstimation estimates buying two of the item for the user
+func seedEstimation(t *testing.T, svc *Service, userID int64, merchant model.Merchant, item model.Item) model.EstimationPrice {
+	t.Helper()
+
+	estimation, err := svc.EstimateOrderPrice(context.TODO(), model.OrderEstimation{
+		UserID:       userID,
+		UserLocation: model.Location{Lat: -6.2088, Long: 106.8456},
+		Orders: []model.Order{{
+			MerchantID:      merchant.ID,
+			IsStartingPoint: true,
+			Items:           []model.OrderItem{{ItemID: item.ID, Quantity: 2}},
+		}},
+	})
+	require.NoError(t, err)
+	return estimation
+}
+
+func TestCreateOrder(t *testing.T) {
+	svc, repo, db := setupService(t)
+	ownerID := seedUser(t, repo, db)
+	userID := seedUser(t, repo, db)
+	otherUserID := seedUser(t, repo, db)
+	merchant, item := seedMerchant(t, repo, ownerID, "BoothKiosk", "Nasi Goreng")
+	ctx := context.TODO()
+
+	t.Run("Valid", func(t *testing.T) {
+		estimation := seedEstimation(t, svc, userID, merchant, item)
+
+		orderID, err := svc.CreateOrder(ctx, userID, estimation.ID)
+
+		require.NoError(t, err)
+		assert.Positive(t, orderID)
+
+		page, err := svc.GetOrders(ctx, model.FilterOrder{UserID: userID, Limit: 5})
+		require.NoError(t, err)
+		require.Len(t, page.Orders, 1)
+		assert.Equal(t, orderID, page.Orders[0].ID)
+		require.Len(t, page.Orders[0].Merchants, 1)
+		assert.Equal(t, merchant.ID, page.Orders[0].Merchants[0].Merchant.ID)
+		require.Len(t, page.Orders[0].Merchants[0].Items, 1)
+		assert.Equal(t, item.ID, page.Orders[0].Merchants[0].Items[0].ID)
+		assert.Equal(t, 2, page.Orders[0].Merchants[0].Items[0].Quantity)
+		assert.Equal(t, estimation.TotalPrice, page.Orders[0].TotalPrice)
+		assert.Equal(t, estimation.Breakdown.DeliveryFee, page.Orders[0].Breakdown.DeliveryFee)
+		assert.Equal(t, estimation.Breakdown.Tax, page.Orders[0].Breakdown.Tax)
+		assert.Equal(t, estimation.Breakdown.GrandTotal, page.Orders[0].Breakdown.GrandTotal)
+	})
+
+	t.Run("AlreadyPlaced", func(t *testing.T) {
+		estimation := seedEstimation(t, svc, userID, merchant, item)
+		_, err := svc.CreateOrder(ctx, userID, estimation.ID)
+		require.NoError(t, err)
+
+		_, err = svc.CreateOrder(ctx, userID, estimation.ID)
+
+		assert.ErrorIs(t, err, constants.ErrOrderAlreadyPlaced)
+	})
+
+	t.Run("EstimationOfAnotherUser", func(t *testing.T) {
+		estimation := seedEstimation(t, svc, otherUserID, merchant, item)
+
+		_, err := svc.CreateOrder(ctx, userID, estimation.ID)
+
+		assert.ErrorIs(t, err, constants.ErrEstimationNotFound)
+	})
+
+	t.Run("EstimationNotFound", func(t *testing.T) {
+		_, err := svc.CreateOrder(ctx, userID, -1)
+
+		assert.ErrorIs(t, err, constants.ErrEstimationNotFound)
+	})
+
+	t.Run("MerchantClosedSinceEstimation", func(t *testing.T) {
+		closingMerchant, closingItem := seedMerchant(t, repo, ownerID, "BoothKiosk", "Mie Ayam")
+		estimation := seedEstimation(t, svc, userID, closingMerchant, closingItem)
+		err := repo.ReplaceMerchantHolidays(ctx, closingMerchant.ID, []model.HolidayOverride{
+			{Date: time.Now().UTC().Format(time.DateOnly)},
+		})
+		require.NoError(t, err)
+
+		_, err = svc.CreateOrder(ctx, userID, estimation.ID)
+
+		assert.ErrorIs(t, err, constants.ErrMerchantClosed)
+	})
+
+	t.Run("PromoCodeExpiredSinceEstimation", func(t *testing.T) {
+		code := "EXPIRING-" + uuid.NewString()[:8]
+		var promoCodeID int64
+		err := db.QueryRowContext(ctx, `
+			INSERT INTO promo_codes (code, discount_type, discount_value)
+			VALUES ($1, 'percent', 10)
+			RETURNING id
+		`, code).Scan(&promoCodeID)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			db.ExecContext(context.Background(), "DELETE FROM promo_codes WHERE id = $1", promoCodeID)
+		})
+
+		estimation, err := svc.EstimateOrderPrice(ctx, model.OrderEstimation{
+			UserID:       userID,
+			UserLocation: model.Location{Lat: -6.2088, Long: 106.8456},
+			PromoCode:    code,
+			Orders: []model.Order{{
+				MerchantID:      merchant.ID,
+				IsStartingPoint: true,
+				Items:           []model.OrderItem{{ItemID: item.ID, Quantity: 2}},
+			}},
+		})
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, "UPDATE promo_codes SET expires_at = NOW() WHERE id = $1", promoCodeID)
+		require.NoError(t, err)
+
+		_, err = svc.CreateOrder(ctx, userID, estimation.ID)
+
+		assert.ErrorIs(t, err, constants.ErrPromoCodeExpired)
+	})
+}
+
+func TestGetOrders(t *testing.T) {
+	svc, repo, db := setupService(t)
+	ownerID := seedUser(t, repo, db)
+	userID := seedUser(t, repo, db)
+	ctx := context.TODO()
+
+	kiosk, kioskItem := seedMerchant(t, repo, ownerID, "BoothKiosk", "Es Teh Manis")
+	restaurant, restaurantItem := seedMerchant(t, repo, ownerID, "SmallRestaurant", "Rendang Sapi")
+
+	placeOrder := func(merchant model.Merchant, item model.Item) int64 {
+		estimation := seedEstimation(t, svc, userID, merchant, item)
+		orderID, err := svc.CreateOrder(ctx, userID, estimation.ID)
+		require.NoError(t, err)
+		return orderID
+	}
+	kioskOrder := placeOrder(kiosk, kioskItem)
+	restaurantOrder := placeOrder(restaurant, restaurantItem)
+	secondKioskOrder := placeOrder(kiosk, kioskItem)
+
+	orderIDs := func(orders []model.OrderHistory) []int64 {
+		ids := make([]int64, 0, len(orders))
+		for _, order := range orders {
+			ids = append(ids, order.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name   string
+		filter model.FilterOrder
+		want   []int64
+	}{
+		{"All", model.FilterOrder{}, []int64{secondKioskOrder, restaurantOrder, kioskOrder}},
+		{"MerchantName", model.FilterOrder{Name: restaurant.Name}, []int64{restaurantOrder}},
+		{"ItemName", model.FilterOrder{Name: "teh manis"}, []int64{secondKioskOrder, kioskOrder}},
+		{"MerchantCategory", model.FilterOrder{MerchantCategory: "SmallRestaurant"}, []int64{restaurantOrder}},
+		{"NameAndCategory", model.FilterOrder{Name: "rendang", MerchantCategory: "BoothKiosk"}, []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.UserID = userID
+			tt.filter.Limit = 10
+
+			page, err := svc.GetOrders(ctx, tt.filter)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, orderIDs(page.Orders))
+			assert.Equal(t, len(tt.want), page.Total)
+		})
+	}
+
+	t.Run("Pagination", func(t *testing.T) {
+		page, err := svc.GetOrders(ctx, model.FilterOrder{UserID: userID, Limit: 2, Offset: 1})
+
+		require.NoError(t, err)
+		assert.Equal(t, []int64{restaurantOrder, kioskOrder}, orderIDs(page.Orders))
+		// the total counts every matching order, not the page
+		assert.Equal(t, 3, page.Total)
+	})
+}
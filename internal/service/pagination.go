@@ -0,0 +1,40 @@
+package service
+
+import "PattyWagon/internal/model"
+
+// newPageInfo derives the cursors around a keyset page from its first and last row. Paging forward, a next page
+// exists when the repository saw more rows and a previous one whenever the page did not start the listing;
+// paging backward mirrors that. Both cursors carry the sort direction of the listing.
+func newPageInfo[T any](rows []T, position func(T) model.Cursor, descending bool, cursor *model.Cursor, offset int, hasMore bool, total int) model.PageInfo {
+	info := model.PageInfo{Total: total}
+	if len(rows) == 0 {
+		return info
+	}
+
+	hasNext, hasPrev := hasMore, cursor != nil || offset > 0
+	if cursor != nil && cursor.Backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	if hasNext {
+		next := position(rows[len(rows)-1])
+		next.Descending = descending
+		info.NextCursor = &next
+	}
+	if hasPrev {
+		prev := position(rows[0])
+		prev.Backward = true
+		prev.Descending = descending
+		info.PrevCursor = &prev
+	}
+
+	return info
+}
+
+func merchantPosition(merchant model.Merchant) model.Cursor {
+	return model.Cursor{CreatedAt: merchant.CreatedAt, ID: merchant.ID}
+}
+
+func itemPosition(item model.Item) model.Cursor {
+	return model.Cursor{CreatedAt: item.CreatedAt, ID: item.ID}
+}
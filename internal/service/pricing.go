@@ -0,0 +1,102 @@
+package service
+
+import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"math"
+	"time"
+)
+
+// PricingEngine prices an order from its item lines: a delivery fee growing with the route distance, a flat
+// service fee, a promo code discount on the item subtotal, and tax on the discounted subtotal
+type PricingEngine struct {
+	config config.Pricing
+}
+
+func NewPricingEngine(cfg config.Pricing) *PricingEngine {
+	return &PricingEngine{config: cfg}
+}
+
+// Price itemizes an order priced at now. A nil promo code gives no discount, while a promo code that is not
+// active at now or whose minimum spend the subtotal misses fails the whole price.
+func (p *PricingEngine) Price(items []model.PriceLine, distanceInMeters float64, promo *model.PromoCode, now time.Time) (model.PriceBreakdown, error) {
+	breakdown := model.PriceBreakdown{
+		DistanceInMeters: roundCurrency(distanceInMeters),
+		Lines:            make([]model.PriceLine, 0, len(items)+4),
+	}
+
+	for _, item := range items {
+		item.Amount = roundCurrency(item.UnitPrice * float64(item.Quantity))
+		breakdown.Subtotal += item.Amount
+		breakdown.Lines = append(breakdown.Lines, item)
+	}
+	breakdown.Subtotal = roundCurrency(breakdown.Subtotal)
+
+	breakdown.DeliveryFee = roundCurrency(p.config.DeliveryBaseFee + p.config.DeliveryFeePerKm*distanceInMeters/1000)
+	breakdown.Lines = append(breakdown.Lines, model.PriceLine{
+		Type:        model.PriceLineDeliveryFee,
+		Description: "Delivery fee",
+		Amount:      breakdown.DeliveryFee,
+	})
+
+	breakdown.ServiceFee = roundCurrency(p.config.ServiceFee)
+	breakdown.Lines = append(breakdown.Lines, model.PriceLine{
+		Type:        model.PriceLineServiceFee,
+		Description: "Service fee",
+		Amount:      breakdown.ServiceFee,
+	})
+
+	if promo != nil {
+		discount, err := promoDiscount(*promo, breakdown.Subtotal, now)
+		if err != nil {
+			return model.PriceBreakdown{}, err
+		}
+
+		breakdown.Discount = discount
+		breakdown.Lines = append(breakdown.Lines, model.PriceLine{
+			Type:        model.PriceLineDiscount,
+			Description: "Promo " + promo.Code,
+			Amount:      -discount,
+		})
+	}
+
+	breakdown.Tax = roundCurrency((breakdown.Subtotal - breakdown.Discount) * p.config.TaxRatePercent / 100)
+	breakdown.Lines = append(breakdown.Lines, model.PriceLine{
+		Type:        model.PriceLineTax,
+		Description: "Tax",
+		Amount:      breakdown.Tax,
+	})
+
+	breakdown.GrandTotal = roundCurrency(
+		breakdown.Subtotal + breakdown.DeliveryFee + breakdown.ServiceFee - breakdown.Discount + breakdown.Tax)
+
+	return breakdown, nil
+}
+
+// promoDiscount computes the discount of a promo code on an item subtotal, never more than the subtotal itself
+func promoDiscount(promo model.PromoCode, subtotal float64, now time.Time) (float64, error) {
+	if (promo.StartsAt != nil && now.Before(*promo.StartsAt)) || (promo.ExpiresAt != nil && !now.Before(*promo.ExpiresAt)) {
+		return 0, constants.ErrPromoCodeExpired
+	}
+	if subtotal < promo.MinSpend {
+		return 0, constants.ErrPromoCodeMinSpend
+	}
+
+	var discount float64
+	switch promo.DiscountType {
+	case model.PromoDiscountPercent:
+		discount = subtotal * promo.DiscountValue / 100
+		if promo.MaxDiscount != nil {
+			discount = min(discount, *promo.MaxDiscount)
+		}
+	case model.PromoDiscountFixed:
+		discount = promo.DiscountValue
+	}
+
+	return roundCurrency(min(discount, subtotal)), nil
+}
+
+func roundCurrency(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPricingEngine_Price(t *testing.T) {
+	engine := NewPricingEngine(config.Pricing{
+		DeliveryBaseFee:  5000,
+		DeliveryFeePerKm: 2000,
+		ServiceFee:       1000,
+		TaxRatePercent:   10,
+	})
+	now := time.Date(2025, 10, 12, 12, 0, 0, 0, time.UTC)
+	items := []model.PriceLine{
+		{Type: model.PriceLineItem, Description: "Coffee (L)", Quantity: 2, UnitPrice: 25000},
+		{Type: model.PriceLineItem, Description: "Bread", Quantity: 1, UnitPrice: 10000},
+	}
+
+	t.Run("without promo code", func(t *testing.T) {
+		breakdown, err := engine.Price(items, 2500, nil, now)
+		require.NoError(t, err)
+
+		assert.Equal(t, 60000.0, breakdown.Subtotal)
+		assert.Equal(t, 10000.0, breakdown.DeliveryFee)
+		assert.Equal(t, 1000.0, breakdown.ServiceFee)
+		assert.Equal(t, 6000.0, breakdown.Tax)
+		assert.Equal(t, 77000.0, breakdown.GrandTotal)
+
+		require.Len(t, breakdown.Lines, 5)
+		assert.Equal(t, 50000.0, breakdown.Lines[0].Amount)
+		assert.Equal(t, model.PriceLineTax, breakdown.Lines[4].Type)
+	})
+
+	t.Run("percent promo code capped", func(t *testing.T) {
+		maxDiscount := 5000.0
+		promo := &model.PromoCode{
+			Code:          "HEMAT",
+			DiscountType:  model.PromoDiscountPercent,
+			DiscountValue: 20,
+			MaxDiscount:   &maxDiscount,
+		}
+
+		breakdown, err := engine.Price(items, 0, promo, now)
+		require.NoError(t, err)
+		assert.Equal(t, 5000.0, breakdown.Discount)
+		assert.Equal(t, 5500.0, breakdown.Tax)
+		assert.Equal(t, 60000.0+5000+1000-5000+5500, breakdown.GrandTotal)
+	})
+
+	t.Run("fixed promo code never exceeds subtotal", func(t *testing.T) {
+		promo := &model.PromoCode{Code: "FREE", DiscountType: model.PromoDiscountFixed, DiscountValue: 100000}
+
+		breakdown, err := engine.Price(items, 0, promo, now)
+		require.NoError(t, err)
+		assert.Equal(t, 60000.0, breakdown.Discount)
+		assert.Zero(t, breakdown.Tax)
+	})
+
+	t.Run("expired promo code", func(t *testing.T) {
+		expiresAt := now.Add(-time.Hour)
+		promo := &model.PromoCode{DiscountType: model.PromoDiscountFixed, DiscountValue: 1000, ExpiresAt: &expiresAt}
+
+		_, err := engine.Price(items, 0, promo, now)
+		assert.ErrorIs(t, err, constants.ErrPromoCodeExpired)
+	})
+
+	t.Run("minimum spend not reached", func(t *testing.T) {
+		promo := &model.PromoCode{DiscountType: model.PromoDiscountFixed, DiscountValue: 1000, MinSpend: 100000}
+
+		_, err := engine.Price(items, 0, promo, now)
+		assert.ErrorIs(t, err, constants.ErrPromoCodeMinSpend)
+	})
+}
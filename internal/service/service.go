@@ -1,8 +1,13 @@
 package service
 
 import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/location"
 	"PattyWagon/internal/model"
 	"context"
+	"io"
+	"net/url"
+	"time"
 )
 
 type Service struct {
@@ -11,66 +16,165 @@ type Service struct {
 	imageCompressor ImageCompressor
 	locationService LocationService
 	merchantCounter MerchantCounter
+	nearbySearch    NearbySearchStrategy
+	travelTime      TravelTimeModel
+	pricing         *PricingEngine
+	bucket          string
+	vehicleType     model.VehicleType
 }
 
 // note: not ideal, might need adapter layer because return type is defined in the repository package
 type Repository interface {
+	// Transaction
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+
 	// User
 	// User Repository
 	InsertUser(ctx context.Context, user model.User, passwordHash string) (model.User, error)
 	SelectUserCredentialsByUsernameAndRole(ctx context.Context, username string, role int16) (res model.User, err error)
 
+	// Session Repository
+	InsertRefreshToken(ctx context.Context, token model.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (model.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, id int64, reason string) error
+	RevokeRefreshTokenOfAccessToken(ctx context.Context, accessTokenID string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int64) ([]string, error)
+	RevokeAccessTokens(ctx context.Context, tokenIDs []string, expiresAt time.Time) error
+
 	// File
 	GetFileUpload(ctx context.Context, id int64) (model.File, error)
+	InsertPendingFile(ctx context.Context, file model.File) (model.File, error)
+	CompleteFile(ctx context.Context, file model.File) (model.File, error)
+	SetFileRenditions(ctx context.Context, id int64, thumbnailUri string, renditions map[string]model.Rendition) error
+	MarkThumbnailFailed(ctx context.Context, id int64) error
+
+	// Job
+	EnqueueJob(ctx context.Context, jobType string, payload any) (int64, error)
 
 	// File Repository
 	InsertFile(ctx context.Context, file model.File) (model.File, error)
 	GetFileByFileID(ctx context.Context, fileID string) (res model.File, err error)
 	FileExists(ctx context.Context, fileID string) (bool, error)
+	FileURIExists(ctx context.Context, uri string) (bool, error)
 
 	// Merchant Repository
 	InsertMerchant(ctx context.Context, data model.Merchant) (res int64, err error)
-	GetMerchants(ctx context.Context, filter model.FilterMerchant) (res []model.Merchant, err error)
-	MerchantExists(ctx context.Context, merchantID int64) (res bool, err error)
+	GetMerchants(ctx context.Context, filter model.FilterMerchant) (res []model.Merchant, hasMore bool, err error)
+	CountMerchants(ctx context.Context, filter model.FilterMerchant) (int, error)
 	BulkInsertMerchantLocations(ctx context.Context, locations []model.MerchantLocation) error
+	UpdateMerchant(ctx context.Context, data model.Merchant) (model.Merchant, error)
+	DeleteMerchant(ctx context.Context, userID, merchantID int64) error
+	ReplaceMerchantLocations(ctx context.Context, merchantID int64, locations []model.MerchantLocation) error
+	ReplaceMerchantOpeningHours(ctx context.Context, merchantID int64, openingHours []model.OpeningHours) error
+	ReplaceMerchantHolidays(ctx context.Context, merchantID int64, holidays []model.HolidayOverride) error
 
 	CreateItems(ctx context.Context, item model.Item) (int64, error)
-	GetItems(ctx context.Context, filter model.FilterItem) (res []model.Item, err error)
+	GetItems(ctx context.Context, filter model.FilterItem) (res []model.Item, hasMore bool, err error)
+	CountItems(ctx context.Context, filter model.FilterItem) (int, error)
+	UpdateItem(ctx context.Context, item model.Item) (model.Item, error)
+	DeleteItem(ctx context.Context, merchantID, itemID int64) error
+	ReplaceItemModifierGroups(ctx context.Context, itemID int64, groups []model.ModifierGroup) error
+	SetItemStock(ctx context.Context, merchantID, itemID int64, stock *int) (model.Item, error)
+	AdjustItemStock(ctx context.Context, merchantID, itemID int64, delta int) (model.Item, error)
+	ReserveItemStock(ctx context.Context, itemID int64, quantity int) error
 	// Merchant Repository
 	GetMerchantByID(ctx context.Context, id int64) (model.Merchant, error)
+	GetMerchantByIDForUpdate(ctx context.Context, id int64) (model.Merchant, error)
 	GetMerchantByCellID(ctx context.Context, cellID int64) (model.Merchant, error)
-	ListMerchantWithItems(ctx context.Context, params model.ListMerchantWithItemParams) ([]model.MerchantItem, error)
+	IsMerchantOpen(ctx context.Context, merchantID int64) (bool, error)
+	ListMerchantWithItems(ctx context.Context, params model.ListMerchantWithItemParams) (model.NearbyMerchantPage, error)
+	ListNearestMerchantsWithItems(ctx context.Context, params model.ListNearestMerchantParams) (model.NearbyMerchantPage, error)
 	// Item
 	GetItemByID(ctx context.Context, id int64) (model.Item, error)
 
 	GetMerchantWithItems(ctx context.Context, merchantID int64) (model.MerchantItem, error)
+
+	// Order Estimation Repository
+	InsertOrderEstimation(ctx context.Context, data model.EstimationPrice) (int64, error)
+	BulkInsertOrderEstimationItems(ctx context.Context, items []model.EstimationItem) error
+	GetOrderEstimationByID(ctx context.Context, id int64) (model.EstimationPrice, error)
+
+	// Promo Code Repository
+	GetPromoCodeByCode(ctx context.Context, code string) (model.PromoCode, error)
+	CountPromoCodeRedemptions(ctx context.Context, promoCodeID, userID int64) (int, error)
+	RedeemPromoCode(ctx context.Context, redemption model.PromoCodeRedemption) error
+
+	// Order Repository
+	InsertOrder(ctx context.Context, data model.UserOrder) (int64, error)
+	BulkInsertOrderItems(ctx context.Context, items []model.UserOrderItem) error
+	GetOrders(ctx context.Context, filter model.FilterOrder) ([]model.OrderHistory, error)
+	CountOrders(ctx context.Context, filter model.FilterOrder) (int, error)
 }
 
 type Storage interface {
 	UploadFile(ctx context.Context, bucket, localPath, remotePath string) (string, error)
 }
 
+// ObjectReader is implemented by storage backends whose files are downloaded through the API
+type ObjectReader interface {
+	OpenObject(ctx context.Context, key string) (io.ReadSeekCloser, error)
+}
+
+// ObjectDownloader is implemented by storage backends files can be copied back from, thumbnails need it
+type ObjectDownloader interface {
+	DownloadFile(ctx context.Context, bucket, remotePath, localPath string) error
+}
+
+// DirectUploader is implemented by storage backends clients can upload to without going through the API
+type DirectUploader interface {
+	ObjectDownloader
+	PresignUpload(ctx context.Context, bucket, remotePath string, expiry time.Duration) (string, error)
+	StatObject(ctx context.Context, bucket, remotePath string) (model.StoredObject, error)
+	ObjectURI(bucket, remotePath string) string
+}
+
+// SignedUploadReceiver is implemented by storage backends whose presigned uploads are sent to the API
+type SignedUploadReceiver interface {
+	ReceiveSignedUpload(ctx context.Context, key string, query url.Values, body io.Reader) error
+}
+
 type ImageCompressor interface {
-	Compress(ctx context.Context, src string) (string, error)
+	Compress(ctx context.Context, src string) (map[string]model.Rendition, error)
 }
 
 type LocationService interface {
 	GetAllCellIDs(ctx context.Context, location model.Location) ([]model.Cell, error)
-	FindCellIDByResolution(ctx context.Context, location model.Location, resolution int) (model.Cell, error)
 	FindKRingCellIDs(ctx context.Context, location model.Location, resolution, k int) ([]model.Cell, error)
+	KRingRadiusInMeters(ctx context.Context, location model.Location, resolution, k int) (float64, error)
+	PlanRoute(ctx context.Context, start int, waypoints []model.Location, destination model.Location) (model.Route, error)
+}
+
+// TravelTimeModel estimates how long a vehicle departing at departAt takes to travel between two locations
+type TravelTimeModel interface {
+	TravelTimeInMinutes(ctx context.Context, from, to model.Location, vehicle model.VehicleType, departAt time.Time) (float64, error)
 }
 
 type MerchantCounter interface {
 	Increment()
+	Decrement()
 	Get() int64
 }
 
-func New(repository Repository, storage Storage, imageCompressor ImageCompressor, locationService LocationService, merchantCounter MerchantCounter) *Service {
+// New builds the service. A nil nearbySearch falls back to the H3 k-ring strategy
+// and a nil travelTime to the default speed profiles.
+func New(cfg config.Config, repository Repository, storage Storage, imageCompressor ImageCompressor, locationService LocationService, merchantCounter MerchantCounter, nearbySearch NearbySearchStrategy, travelTime TravelTimeModel) *Service {
+	if nearbySearch == nil {
+		nearbySearch = NewH3NearbySearch(repository, locationService, cfg.NearbySearch.H3StartResolution)
+	}
+	if travelTime == nil {
+		travelTime = location.NewSpeedProfileModel(location.DefaultSpeedProfiles())
+	}
+
 	return &Service{
 		repository:      repository,
 		storage:         storage,
 		imageCompressor: imageCompressor,
 		locationService: locationService,
 		merchantCounter: merchantCounter,
+		nearbySearch:    nearbySearch,
+		travelTime:      travelTime,
+		pricing:         NewPricingEngine(cfg.Pricing),
+		bucket:          cfg.Storage.Bucket,
+		vehicleType:     cfg.TravelTime.VehicleType,
 	}
 }
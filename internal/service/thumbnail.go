@@ -0,0 +1,122 @@
+package service
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/jobqueue"
+	"PattyWagon/internal/model"
+	"PattyWagon/logger"
+	"PattyWagon/observability"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// GetFile returns a file with the readiness of its original and thumbnail
+func (s *Service) GetFile(ctx context.Context, fileID int64) (model.File, error) {
+	return s.repository.GetFileUpload(ctx, fileID)
+}
+
+// enqueueThumbnail queues the thumbnail of a file, in the same transaction as the file when ctx carries one
+func (s *Service) enqueueThumbnail(ctx context.Context, fileID int64) error {
+	if _, err := s.repository.EnqueueJob(ctx, model.JobGenerateThumbnail, model.ThumbnailJob{FileID: fileID}); err != nil {
+		return fmt.Errorf("error queueing thumbnail: %w", err)
+	}
+	return nil
+}
+
+// ThumbnailJobHandler runs the generate_thumbnail jobs, which store every rendition of a file.
+// Renditions that keep failing mark the thumbnail of the file failed.
+func (s *Service) ThumbnailJobHandler() jobqueue.Handler {
+	return jobqueue.Handler{
+		Handle: func(ctx context.Context, job model.Job) error {
+			var payload model.ThumbnailJob
+			if err := json.Unmarshal(job.Payload, &payload); err != nil {
+				return jobqueue.Permanent(fmt.Errorf("invalid thumbnail job payload: %w", err))
+			}
+			return s.GenerateRenditions(ctx, payload.FileID)
+		},
+		Dead: func(ctx context.Context, job model.Job, err error) error {
+			var payload model.ThumbnailJob
+			if json.Unmarshal(job.Payload, &payload) != nil {
+				return nil
+			}
+			logger.GetLoggerFromContext(ctx).Error().Err(err).Int64("fileId", payload.FileID).Msg("thumbnail generation failed")
+			return s.repository.MarkThumbnailFailed(ctx, payload.FileID)
+		},
+	}
+}
+
+// GenerateRenditions resizes the stored original of a file to every rendition and stores them
+func (s *Service) GenerateRenditions(ctx context.Context, fileID int64) error {
+	ctx, span := observability.Tracer.Start(ctx, "service.generate_renditions")
+	defer span.End()
+
+	downloader, ok := s.storage.(ObjectDownloader)
+	if !ok {
+		return jobqueue.Permanent(constants.ErrDirectUploadUnsupported)
+	}
+
+	file, err := s.repository.GetFileUpload(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, constants.ErrFileNotFound) {
+			return jobqueue.Permanent(err)
+		}
+		return err
+	}
+	if file.ThumbnailStatus == model.ThumbnailStatusReady {
+		return nil
+	}
+	if file.ObjectKey == "" {
+		return jobqueue.Permanent(fmt.Errorf("file %d has no stored original", fileID))
+	}
+
+	// the file id keeps two workers retrying the same file from sharing a path
+	localPath := filepath.Join(os.TempDir(), strconv.FormatInt(file.ID, 10)+"_"+file.ObjectKey)
+	if err := downloader.DownloadFile(ctx, s.bucket, file.ObjectKey, localPath); err != nil {
+		return fmt.Errorf("error downloading original file: %w", err)
+	}
+	defer os.Remove(localPath)
+
+	renditions, err := s.imageCompressor.Compress(ctx, localPath)
+	if err != nil {
+		return err
+	}
+	for _, rendition := range renditions {
+		defer os.Remove(rendition.Path)
+	}
+
+	for name, rendition := range renditions {
+		rendition.Uri, err = s.storage.UploadFile(ctx, s.bucket, rendition.Path, filepath.Base(rendition.Path))
+		if err != nil {
+			return fmt.Errorf("error uploading %s rendition: %w", name, err)
+		}
+		renditions[name] = rendition
+	}
+
+	thumbnailUri := thumbnailRendition(renditions).Uri
+	if err := s.repository.SetFileRenditions(ctx, file.ID, thumbnailUri, renditions); err != nil {
+		return err
+	}
+
+	logger.GetLoggerFromContext(ctx).Printf("%d renditions of file %d, thumbnail: %s", len(renditions), file.ID, thumbnailUri)
+	return nil
+}
+
+// thumbnailRendition is the rendition named thumbnail, or else the smallest one
+func thumbnailRendition(renditions map[string]model.Rendition) model.Rendition {
+	if rendition, ok := renditions["thumbnail"]; ok {
+		return rendition
+	}
+
+	var smallest model.Rendition
+	for _, rendition := range renditions {
+		if smallest.Uri == "" || rendition.Width*rendition.Height < smallest.Width*smallest.Height {
+			smallest = rendition
+		}
+	}
+	return smallest
+}
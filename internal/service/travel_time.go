@@ -0,0 +1,65 @@
+package service
+
+import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/location"
+	"PattyWagon/internal/model"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	TravelTimeSpeedProfile = "speed_profile"
+	TravelTimeRoadGraph    = "road_graph"
+)
+
+// NewTravelTimeModel builds the configured model, defaulting to straight line speed profiles.
+// Without a profile file the default speed profiles are used, the road graph model requires its graph file.
+func NewTravelTimeModel(cfg config.TravelTime) (TravelTimeModel, error) {
+	profiles := location.DefaultSpeedProfiles()
+	if cfg.ProfileFile != "" {
+		var err error
+		profiles, err = location.LoadSpeedProfiles(cfg.ProfileFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch strings.ToLower(cfg.Model) {
+	case "", TravelTimeSpeedProfile:
+		return location.NewSpeedProfileModel(profiles), nil
+	case TravelTimeRoadGraph:
+		if cfg.RoadGraphFile == "" {
+			return nil, fmt.Errorf("travel time model %q needs ROAD_GRAPH_FILE", cfg.Model)
+		}
+		graph, err := location.LoadRoadGraph(cfg.RoadGraphFile)
+		if err != nil {
+			return nil, err
+		}
+		return location.NewRoadGraphModel(graph, profiles)
+	default:
+		return nil, fmt.Errorf("unknown travel time model %q", cfg.Model)
+	}
+}
+
+// timeRoute fills in the duration and ETA of every leg, departing the starting point at departAt
+func (s *Service) timeRoute(ctx context.Context, route *model.Route, vehicle model.VehicleType, departAt time.Time) error {
+	var elapsed float64
+	for i := 1; i < len(route.Stops); i++ {
+		leg, err := s.travelTime.TravelTimeInMinutes(ctx, route.Stops[i-1].Location, route.Stops[i].Location, vehicle,
+			departAt.Add(time.Duration(elapsed*float64(time.Minute))))
+		if err != nil {
+			return err
+		}
+
+		elapsed += leg
+		route.Stops[i].LegDurationInMinutes = int64(math.Ceil(leg))
+		route.Stops[i].ETAInMinutes = int64(math.Ceil(elapsed))
+	}
+
+	route.DurationInMinutes = int64(math.Ceil(elapsed))
+	return nil
+}
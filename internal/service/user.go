@@ -5,50 +5,170 @@ import (
 	"PattyWagon/internal/model"
 	"PattyWagon/internal/utils"
 	"context"
+	"errors"
 	"strings"
+	"time"
 )
 
-func (s *Service) UsernameLogin(ctx context.Context, username string, password string, role int16) (token string, err error) {
+func (s *Service) UsernameLogin(ctx context.Context, username string, password string, role int16) (res model.AuthToken, err error) {
 	user, err := s.repository.SelectUserCredentialsByUsernameAndRole(ctx, username, role)
 	if err != nil {
 		if strings.Contains(err.Error(), "sql: no rows in result set") {
-			return "", constants.ErrUserNotFound
+			return model.AuthToken{}, constants.ErrUserNotFound
 		}
-		return "", err
+		return model.AuthToken{}, err
 	}
 	if user.ID == 0 { // user not found
-		return "", constants.ErrUserNotFound
+		return model.AuthToken{}, constants.ErrUserNotFound
 	}
 
 	if !utils.VerifyPassword(password, user.PasswordHash) {
-		return "", constants.ErrUserWrongPassword
+		return model.AuthToken{}, constants.ErrUserWrongPassword
 	}
 
-	token, err = utils.GenerateToken(user.ID, role)
-	if err != nil {
-		return "", err
-	}
-
-	return token, nil
+	return s.issueAuthToken(ctx, user.ID, role)
 }
 
-func (s *Service) Register(ctx context.Context, userReq model.User, password string, role int16) (string, error) {
+func (s *Service) Register(ctx context.Context, userReq model.User, password string, role int16) (model.AuthToken, error) {
 	passwordHash, err := utils.HashPassword(password)
 	if err != nil {
-		return "", err
+		return model.AuthToken{}, err
 	}
 
 	user, err := s.repository.InsertUser(ctx, userReq, passwordHash)
 	if err != nil {
 		if utils.IsErrDBConstraint(err) {
-			return "", constants.ErrDuplicate
+			return model.AuthToken{}, constants.ErrDuplicate
 		}
-		return "", err
+		return model.AuthToken{}, err
 	}
 
-	token, err := utils.GenerateToken(user.ID, role)
+	return s.issueAuthToken(ctx, user.ID, role)
+}
+
+// RefreshToken rotates a refresh token, handing out a new token pair. Presenting a token that was
+// already rotated is treated as theft and ends every session of its user, while a token ended by
+// logging out is only refused.
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (res model.AuthToken, err error) {
+	token, err := s.repository.GetRefreshTokenByHash(ctx, utils.HashRefreshToken(refreshToken))
 	if err != nil {
-		return "", err
+		return model.AuthToken{}, err
+	}
+
+	if token.RevokedAt != nil {
+		if token.RevokedReason != nil && *token.RevokedReason == model.RefreshTokenRotated {
+			if err := s.revokeUserSessions(ctx, token.UserID, nil); err != nil {
+				return model.AuthToken{}, err
+			}
+		}
+		return model.AuthToken{}, constants.ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return model.AuthToken{}, constants.ErrInvalidRefreshToken
+	}
+
+	err = s.repository.WithTx(ctx, func(ctx context.Context) error {
+		err := s.repository.RevokeRefreshToken(ctx, token.ID, model.RefreshTokenRotated)
+		if err != nil {
+			return err
+		}
+
+		// the access token of the rotated pair is superseded as well
+		err = s.repository.RevokeAccessTokens(ctx,
+			[]string{token.AccessTokenID},
+			time.Now().Add(constants.AccessTokenTTL),
+		)
+		if err != nil {
+			return err
+		}
+
+		res, err = s.issueAuthToken(ctx, token.UserID, token.Role)
+		return err
+	})
+	if err != nil {
+		return model.AuthToken{}, err
+	}
+
+	return res, nil
+}
+
+// Logout revokes the session of the given refresh token, or every session of the user when allSessions is set.
+// Without a refresh token the session the access token was issued with is revoked. The access token used for
+// the call is revoked as well.
+func (s *Service) Logout(ctx context.Context, userID int64, accessTokenID, refreshToken string, allSessions bool) error {
+	if allSessions {
+		return s.revokeUserSessions(ctx, userID, []string{accessTokenID})
+	}
+
+	if refreshToken == "" {
+		return s.repository.WithTx(ctx, func(ctx context.Context) error {
+			if err := s.repository.RevokeRefreshTokenOfAccessToken(ctx, accessTokenID); err != nil {
+				return err
+			}
+			return s.repository.RevokeAccessTokens(ctx, []string{accessTokenID}, time.Now().Add(constants.AccessTokenTTL))
+		})
 	}
-	return token, nil
+
+	token, err := s.repository.GetRefreshTokenByHash(ctx, utils.HashRefreshToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if token.UserID != userID {
+		return constants.ErrInvalidRefreshToken
+	}
+
+	return s.repository.WithTx(ctx, func(ctx context.Context) error {
+		err := s.repository.RevokeRefreshToken(ctx, token.ID, model.RefreshTokenLogout)
+		if err != nil && !errors.Is(err, constants.ErrInvalidRefreshToken) {
+			return err
+		}
+
+		return s.repository.RevokeAccessTokens(ctx,
+			[]string{accessTokenID, token.AccessTokenID},
+			time.Now().Add(constants.AccessTokenTTL),
+		)
+	})
+}
+
+func (s *Service) issueAuthToken(ctx context.Context, userID int64, role int16) (model.AuthToken, error) {
+	accessToken, accessTokenID, err := utils.GenerateToken(userID, role)
+	if err != nil {
+		return model.AuthToken{}, err
+	}
+
+	refreshToken, refreshTokenHash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return model.AuthToken{}, err
+	}
+
+	err = s.repository.InsertRefreshToken(ctx, model.RefreshToken{
+		UserID:        userID,
+		TokenHash:     refreshTokenHash,
+		AccessTokenID: accessTokenID,
+		ExpiresAt:     time.Now().Add(constants.RefreshTokenTTL),
+	})
+	if err != nil {
+		return model.AuthToken{}, err
+	}
+
+	return model.AuthToken{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// revokeUserSessions revokes every refresh token of a user together with the access tokens issued alongside them
+func (s *Service) revokeUserSessions(ctx context.Context, userID int64, accessTokenIDs []string) error {
+	return s.repository.WithTx(ctx, func(ctx context.Context) error {
+		tokenIDs, err := s.repository.RevokeUserRefreshTokens(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return s.repository.RevokeAccessTokens(ctx,
+			append(accessTokenIDs, tokenIDs...),
+			time.Now().Add(constants.AccessTokenTTL),
+		)
+	})
 }
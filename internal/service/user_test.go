@@ -0,0 +1,66 @@
+package service
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"PattyWagon/internal/utils"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenRevocation(t *testing.T) {
+	svc, repo, db := setupService(t)
+	ctx := context.TODO()
+
+	_, signingKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keys, err := utils.NewJWTKeys(signingKey)
+	require.NoError(t, err)
+	utils.SetJWTKeys(keys)
+	t.Cleanup(func() { utils.SetJWTKeys(nil) })
+
+	login := func(t *testing.T, userID int64) (model.AuthToken, string) {
+		t.Helper()
+		token, err := svc.issueAuthToken(ctx, userID, constants.RoleUser)
+		require.NoError(t, err)
+
+		session, err := repo.GetRefreshTokenByHash(ctx, utils.HashRefreshToken(token.RefreshToken))
+		require.NoError(t, err)
+		return token, session.AccessTokenID
+	}
+
+	t.Run("LoggedOutTokenIsOnlyRefused", func(t *testing.T) {
+		userID := seedUser(t, repo, db)
+		loggedOut, accessTokenID := login(t, userID)
+		other, _ := login(t, userID)
+
+		require.NoError(t, svc.Logout(ctx, userID, accessTokenID, loggedOut.RefreshToken, false))
+
+		_, err := svc.RefreshToken(ctx, loggedOut.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrInvalidRefreshToken)
+
+		// the other session lives on
+		_, err = svc.RefreshToken(ctx, other.RefreshToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("ReusedRotatedTokenEndsEverySession", func(t *testing.T) {
+		userID := seedUser(t, repo, db)
+		stolen, _ := login(t, userID)
+		other, _ := login(t, userID)
+
+		_, err := svc.RefreshToken(ctx, stolen.RefreshToken)
+		require.NoError(t, err)
+
+		_, err = svc.RefreshToken(ctx, stolen.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrInvalidRefreshToken)
+
+		_, err = svc.RefreshToken(ctx, other.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrInvalidRefreshToken)
+	})
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"PattyWagon/observability"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalStorage keeps files on the local disk under dir, one directory per bucket.
+// They are downloaded through the API's GET /files/{key} route, prefixed by publicBaseURL.
+type LocalStorage struct {
+	dir           string
+	publicBaseURL string
+	signer        uploadSigner
+}
+
+func NewLocalStorage(dir, publicBaseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating storage directory: %w", err)
+	}
+
+	return &LocalStorage{
+		dir:           dir,
+		publicBaseURL: strings.TrimSuffix(publicBaseURL, "/"),
+		signer:        newUploadSigner(),
+	}, nil
+}
+
+func (s *LocalStorage) UploadFile(ctx context.Context, bucket, localPath, remotePath string) (string, error) {
+	_, span := observability.Tracer.Start(ctx, "storage.local_upload")
+	defer span.End()
+
+	key := objectKey(bucket, remotePath)
+	target, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+
+	src, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	if err := writeFile(target, src); err != nil {
+		return "", err
+	}
+	return fileURI(s.publicBaseURL, key), nil
+}
+
+func (s *LocalStorage) PresignUpload(ctx context.Context, bucket, remotePath string, expiry time.Duration) (string, error) {
+	key := objectKey(bucket, remotePath)
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	return s.signer.presign(s.publicBaseURL, key, time.Now().Add(expiry)), nil
+}
+
+// ReceiveSignedUpload stores the body of a PUT to a url presigned by PresignUpload
+func (s *LocalStorage) ReceiveSignedUpload(ctx context.Context, key string, query url.Values, body io.Reader) error {
+	if err := s.signer.verify(key, query, time.Now()); err != nil {
+		return err
+	}
+
+	target, err := s.path(key)
+	if err != nil {
+		return constants.ErrInvalidUploadSignature
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return writeFile(target, body)
+}
+
+func (s *LocalStorage) StatObject(ctx context.Context, bucket, remotePath string) (model.StoredObject, error) {
+	file, err := s.OpenObject(ctx, objectKey(bucket, remotePath))
+	if err != nil {
+		return model.StoredObject{}, err
+	}
+	defer file.Close()
+
+	return statObject(file)
+}
+
+func (s *LocalStorage) DownloadFile(ctx context.Context, bucket, remotePath, localPath string) error {
+	file, err := s.OpenObject(ctx, objectKey(bucket, remotePath))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return writeFile(localPath, file)
+}
+
+func (s *LocalStorage) ObjectURI(bucket, remotePath string) string {
+	return fileURI(s.publicBaseURL, objectKey(bucket, remotePath))
+}
+
+// OpenObject opens a stored file by the key its URI ends with
+func (s *LocalStorage) OpenObject(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, constants.ErrFileNotFound
+	}
+
+	file, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, constants.ErrFileNotFound
+		}
+		return nil, err
+	}
+
+	if info, err := file.Stat(); err != nil || info.IsDir() {
+		file.Close()
+		return nil, constants.ErrFileNotFound
+	}
+	return file, nil
+}
+
+// path resolves a key inside dir, refusing keys that would escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// writeFile writes next to the target and renames, so a reader never sees half a file
+func writeFile(target string, src io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// statObject sizes an object and sniffs its content type from the first bytes
+func statObject(object io.ReadSeeker) (model.StoredObject, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(object, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return model.StoredObject{}, err
+	}
+
+	size, err := object.Seek(0, io.SeekEnd)
+	if err != nil {
+		return model.StoredObject{}, err
+	}
+
+	return model.StoredObject{
+		SizeInBytes: size,
+		ContentType: http.DetectContentType(head[:n]),
+	}, nil
+}
+
+func objectKey(bucket, remotePath string) string {
+	return path.Join(bucket, remotePath)
+}
+
+// fileURI is where the API serves an object of the local and in-memory backends
+func fileURI(publicBaseURL, key string) string {
+	return fmt.Sprintf("%s/files/%s", publicBaseURL, key)
+}
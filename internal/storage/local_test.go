@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"PattyWagon/internal/constants"
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.TODO()
+	s, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/")
+	require.NoError(t, err)
+
+	t.Run("Upload_FromFile", func(t *testing.T) {
+		uri, err := s.UploadFile(ctx, "images", "testdata/sample.jpg", "sample.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:8080/files/images/sample.jpg", uri)
+
+		file, err := s.OpenObject(ctx, "images/sample.jpg")
+		require.NoError(t, err)
+		defer file.Close()
+
+		stored, err := io.ReadAll(file)
+		require.NoError(t, err)
+		original, err := os.ReadFile("testdata/sample.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, original, stored)
+	})
+
+	t.Run("Open_Missing", func(t *testing.T) {
+		_, err := s.OpenObject(ctx, "images/missing.jpg")
+		assert.ErrorIs(t, err, constants.ErrFileNotFound)
+	})
+
+	t.Run("Key_OutsideDirectory", func(t *testing.T) {
+		_, err := s.UploadFile(ctx, "images", "testdata/sample.jpg", "../../escaped.jpg")
+		assert.Error(t, err)
+
+		_, err = s.OpenObject(ctx, "../storage.go")
+		assert.ErrorIs(t, err, constants.ErrFileNotFound)
+	})
+}
+
+func TestMemoryStorage(t *testing.T) {
+	ctx := context.TODO()
+	s := NewMemoryStorage("http://localhost:8080")
+
+	uri, err := s.UploadFile(ctx, "images", "testdata/sample.jpg", "sample.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/files/images/sample.jpg", uri)
+
+	stored, ok := s.Object("images/sample.jpg")
+	require.True(t, ok)
+	original, err := os.ReadFile("testdata/sample.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, original, stored)
+
+	_, err = s.OpenObject(ctx, "images/missing.jpg")
+	assert.ErrorIs(t, err, constants.ErrFileNotFound)
+}
+
+func TestLocalStorage_PresignedUpload(t *testing.T) {
+	ctx := context.TODO()
+	s, err := NewLocalStorage(t.TempDir(), "http://localhost:8080")
+	require.NoError(t, err)
+
+	presigned, err := s.PresignUpload(ctx, "images", "direct.jpg", time.Minute)
+	require.NoError(t, err)
+	uploadURL, err := url.Parse(presigned)
+	require.NoError(t, err)
+	assert.Equal(t, "/files/images/direct.jpg", uploadURL.Path)
+
+	original, err := os.ReadFile("testdata/sample.jpg")
+	require.NoError(t, err)
+
+	t.Run("Tampered_Signature", func(t *testing.T) {
+		query := uploadURL.Query()
+		query.Set("expires", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+
+		err := s.ReceiveSignedUpload(ctx, "images/direct.jpg", query, bytes.NewReader(original))
+		assert.ErrorIs(t, err, constants.ErrInvalidUploadSignature)
+	})
+
+	t.Run("Other_Key", func(t *testing.T) {
+		err := s.ReceiveSignedUpload(ctx, "images/other.jpg", uploadURL.Query(), bytes.NewReader(original))
+		assert.ErrorIs(t, err, constants.ErrInvalidUploadSignature)
+	})
+
+	t.Run("Upload_ThenStat", func(t *testing.T) {
+		_, err := s.StatObject(ctx, "images", "direct.jpg")
+		assert.ErrorIs(t, err, constants.ErrFileNotFound)
+
+		err = s.ReceiveSignedUpload(ctx, "images/direct.jpg", uploadURL.Query(), bytes.NewReader(original))
+		require.NoError(t, err)
+
+		object, err := s.StatObject(ctx, "images", "direct.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(original)), object.SizeInBytes)
+		assert.Equal(t, "image/jpeg", object.ContentType)
+
+		localPath := filepath.Join(t.TempDir(), "downloaded.jpg")
+		require.NoError(t, s.DownloadFile(ctx, "images", "direct.jpg", localPath))
+		downloaded, err := os.ReadFile(localPath)
+		require.NoError(t, err)
+		assert.Equal(t, original, downloaded)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		expired, err := s.PresignUpload(ctx, "images", "late.jpg", -time.Minute)
+		require.NoError(t, err)
+		expiredURL, err := url.Parse(expired)
+		require.NoError(t, err)
+
+		err = s.ReceiveSignedUpload(ctx, "images/late.jpg", expiredURL.Query(), bytes.NewReader(original))
+		assert.ErrorIs(t, err, constants.ErrInvalidUploadSignature)
+	})
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStorage keeps files in memory, for tests and local runs that need no object storage.
+// Its files are served through the API's GET /files/{key} route like the local disk backend.
+type MemoryStorage struct {
+	mu            sync.RWMutex
+	objects       map[string][]byte
+	publicBaseURL string
+	signer        uploadSigner
+}
+
+func NewMemoryStorage(publicBaseURL string) *MemoryStorage {
+	return &MemoryStorage{
+		objects:       make(map[string][]byte),
+		publicBaseURL: strings.TrimSuffix(publicBaseURL, "/"),
+		signer:        newUploadSigner(),
+	}
+}
+
+func (s *MemoryStorage) UploadFile(ctx context.Context, bucket, localPath, remotePath string) (string, error) {
+	content, err := os.ReadFile(localPath)
+	if err != nil {
+		return "", err
+	}
+
+	key := objectKey(bucket, remotePath)
+	s.mu.Lock()
+	s.objects[key] = content
+	s.mu.Unlock()
+
+	return fileURI(s.publicBaseURL, key), nil
+}
+
+func (s *MemoryStorage) OpenObject(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	content, ok := s.Object(key)
+	if !ok {
+		return nil, constants.ErrFileNotFound
+	}
+	return nopCloser{bytes.NewReader(content)}, nil
+}
+
+func (s *MemoryStorage) PresignUpload(ctx context.Context, bucket, remotePath string, expiry time.Duration) (string, error) {
+	return s.signer.presign(s.publicBaseURL, objectKey(bucket, remotePath), time.Now().Add(expiry)), nil
+}
+
+// ReceiveSignedUpload stores the body of a PUT to a url presigned by PresignUpload
+func (s *MemoryStorage) ReceiveSignedUpload(ctx context.Context, key string, query url.Values, body io.Reader) error {
+	if err := s.signer.verify(key, query, time.Now()); err != nil {
+		return err
+	}
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.objects[key] = content
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStorage) StatObject(ctx context.Context, bucket, remotePath string) (model.StoredObject, error) {
+	object, err := s.OpenObject(ctx, objectKey(bucket, remotePath))
+	if err != nil {
+		return model.StoredObject{}, err
+	}
+	return statObject(object)
+}
+
+func (s *MemoryStorage) DownloadFile(ctx context.Context, bucket, remotePath, localPath string) error {
+	content, ok := s.Object(objectKey(bucket, remotePath))
+	if !ok {
+		return constants.ErrFileNotFound
+	}
+	return os.WriteFile(localPath, content, 0o644)
+}
+
+func (s *MemoryStorage) ObjectURI(bucket, remotePath string) string {
+	return fileURI(s.publicBaseURL, objectKey(bucket, remotePath))
+}
+
+// Object returns the content stored under key
+func (s *MemoryStorage) Object(key string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	content, ok := s.objects[key]
+	return content, ok
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
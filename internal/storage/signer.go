@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"PattyWagon/internal/constants"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// uploadSigner presigns PUT /files/{key} urls for the backends that keep their files behind the API.
+// The key is random per process, which is fine as long as such a backend only runs in one process.
+type uploadSigner struct {
+	secret []byte
+}
+
+func newUploadSigner() uploadSigner {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("error generating upload signing key: %v", err))
+	}
+	return uploadSigner{secret: secret}
+}
+
+func (s uploadSigner) presign(publicBaseURL, key string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.signature(key, expires)},
+	}
+	return fileURI(publicBaseURL, key) + "?" + query.Encode()
+}
+
+func (s uploadSigner) verify(key string, query url.Values, now time.Time) error {
+	expires := query.Get("expires")
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return constants.ErrInvalidUploadSignature
+	}
+
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil {
+		return constants.ErrInvalidUploadSignature
+	}
+	expected, _ := hex.DecodeString(s.signature(key, expires))
+	if !hmac.Equal(signature, expected) {
+		return constants.ErrInvalidUploadSignature
+	}
+	return nil
+}
+
+func (s uploadSigner) signature(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,25 +1,37 @@
 package storage
 
 import (
+	"PattyWagon/internal/config"
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
 	"PattyWagon/observability"
 	"context"
 	"fmt"
-	"log"
-	"os"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
-var (
-	S3AccessKeyID            = os.Getenv("S3_ACCESS_KEY_ID")
-	S3SecretAccessKey        = os.Getenv("S3_SECRET_ACCESS_KEY")
-	S3Endpoint               = os.Getenv("S3_ENDPOINT")
-	S3Bucket                 = os.Getenv("S3_BUCKET")
-	S3MaxConcurrentUpload, _ = strconv.Atoi(os.Getenv("S3_MAX_CONCURRENT_UPLOAD"))
-)
+// Backend stores uploaded files and returns the URI clients download them from
+type Backend interface {
+	UploadFile(ctx context.Context, bucket, localPath, remotePath string) (string, error)
+}
+
+// New builds the configured backend, defaulting to MinIO
+func New(cfg config.Storage) (Backend, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", config.StorageMinio:
+		return NewMinioStorage(cfg)
+	case config.StorageLocal:
+		return NewLocalStorage(cfg.LocalDir, cfg.PublicBaseURL)
+	case config.StorageMemory:
+		return NewMemoryStorage(cfg.PublicBaseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
 
 type MinioStorage struct {
 	semaphore         chan struct{}
@@ -28,33 +40,33 @@ type MinioStorage struct {
 	s3AccessKeyID     string
 	s3SecretAccessKey string
 	secure            bool
+	publicBaseURL     string
 }
 
-type Option struct {
-	MaxConcurrent int64
-}
-
-func New(
-	s3Endpoint, s3AccessKeyID, s3SecretAccessKey string, option Option,
-) *MinioStorage {
-
-	mc, err := minio.New(s3Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(s3AccessKeyID, s3SecretAccessKey, ""),
+func NewMinioStorage(cfg config.Storage) (*MinioStorage, error) {
+	mc, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
 		Secure: false,
 	})
-
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("error creating minio client: %w", err)
+	}
+
+	// objects are downloaded from the endpoint itself unless a public URL fronts it
+	publicBaseURL := strings.TrimSuffix(cfg.PublicBaseURL, "/")
+	if publicBaseURL == "" {
+		publicBaseURL = mc.EndpointURL().String()
 	}
 
 	return &MinioStorage{
-		semaphore:         make(chan struct{}, option.MaxConcurrent),
+		semaphore:         make(chan struct{}, cfg.MaxConcurrentUpload),
 		client:            mc,
-		s3Endpoint:        s3Endpoint,
-		s3AccessKeyID:     s3AccessKeyID,
-		s3SecretAccessKey: s3SecretAccessKey,
+		s3Endpoint:        cfg.Endpoint,
+		s3AccessKeyID:     cfg.AccessKeyID,
+		s3SecretAccessKey: cfg.SecretAccessKey,
 		secure:            false,
-	}
+		publicBaseURL:     publicBaseURL,
+	}, nil
 }
 
 func (s *MinioStorage) UploadFile(ctx context.Context, bucket, localPath, remotePath string) (string, error) {
@@ -75,6 +87,39 @@ func (s *MinioStorage) UploadFile(ctx context.Context, bucket, localPath, remote
 		return "", err
 	}
 
-	uri := fmt.Sprintf("http://%s/%s/%s", s.s3Endpoint, bucket, remotePath)
-	return uri, nil
+	return s.ObjectURI(bucket, remotePath), nil
+}
+
+func (s *MinioStorage) PresignUpload(ctx context.Context, bucket, remotePath string, expiry time.Duration) (string, error) {
+	presigned, err := s.client.PresignedPutObject(ctx, bucket, remotePath, expiry)
+	if err != nil {
+		return "", fmt.Errorf("error presigning upload: %w", err)
+	}
+	return presigned.String(), nil
+}
+
+func (s *MinioStorage) StatObject(ctx context.Context, bucket, remotePath string) (model.StoredObject, error) {
+	info, err := s.client.StatObject(ctx, bucket, remotePath, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return model.StoredObject{}, constants.ErrFileNotFound
+		}
+		return model.StoredObject{}, err
+	}
+
+	return model.StoredObject{
+		SizeInBytes: info.Size,
+		ContentType: info.ContentType,
+	}, nil
+}
+
+func (s *MinioStorage) DownloadFile(ctx context.Context, bucket, remotePath, localPath string) error {
+	ctx, span := observability.Tracer.Start(ctx, "storage.s3_download")
+	defer span.End()
+
+	return s.client.FGetObject(ctx, bucket, remotePath, localPath, minio.GetObjectOptions{})
+}
+
+func (s *MinioStorage) ObjectURI(bucket, remotePath string) string {
+	return fmt.Sprintf("%s/%s/%s", s.publicBaseURL, bucket, remotePath)
 }
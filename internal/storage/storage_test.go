@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"PattyWagon/internal/config"
 	"context"
 	"testing"
 
@@ -9,12 +10,17 @@ import (
 
 func setupTestMinioStorage(t *testing.T) *MinioStorage {
 	t.Helper()
-	s3Endpoint := "localhost:9000"
-	s3AccessKeyID := "team-solid"
-	s3SecretAccessKey := "@team-solid"
-	return New(s3Endpoint, s3AccessKeyID, s3SecretAccessKey, Option{
-		MaxConcurrent: 5,
+	s, err := NewMinioStorage(config.Storage{
+		Endpoint:            "localhost:9000",
+		AccessKeyID:         "team-solid",
+		SecretAccessKey:     "@team-solid",
+		Bucket:              "images",
+		MaxConcurrentUpload: 5,
 	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
 }
 
 func TestStorage(t *testing.T) {
@@ -4,17 +4,28 @@ import (
 	"PattyWagon/internal/constants"
 	"PattyWagon/internal/model"
 	"context"
-	"os"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtSignatureKey string
+// TokenDenylist reports access tokens that were revoked before they expired
+type TokenDenylist interface {
+	IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+var tokenDenylist TokenDenylist
 
-func init() {
-	jwtSignatureKey = os.Getenv("JWT_SIGNATURE_KEY")
+// SetTokenDenylist registers the denylist consulted by ParseUserIDandRoleFromToken
+func SetTokenDenylist(denylist TokenDenylist) {
+	tokenDenylist = denylist
 }
 
 // GetUserIDFromCtx get user ID from ctx
@@ -23,6 +34,12 @@ func GetUserIDFromCtx(ctx context.Context) (int64, bool) {
 	return userID, ok
 }
 
+// GetTokenIDFromCtx get the access token ID (jti) from ctx
+func GetTokenIDFromCtx(ctx context.Context) (string, bool) {
+	tokenID, ok := ctx.Value(constants.TokenIDCtxKey).(string)
+	return tokenID, ok
+}
+
 // HashPassword generates a bcrypt hash for the given password.
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 4)
@@ -35,35 +52,71 @@ func VerifyPassword(password, hash string) bool {
 	return err == nil
 }
 
-// GenerateToken generate jwt token with userID information
-func GenerateToken(userID int64, role int16) (string, error) {
+// GenerateToken generate a short-lived jwt token with userID information, returning it with its ID (jti)
+func GenerateToken(userID int64, role int16) (string, string, error) {
+	tokenID := uuid.NewString()
 	claims := model.Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24)),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(constants.AccessTokenTTL)),
 			Issuer:    "PattyWagon",
 		},
 		UserID: userID,
 		Role:   role,
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	ss, err := token.SignedString([]byte(jwtSignatureKey))
+	if jwtKeys == nil {
+		return "", "", ErrJWTKeysNotConfigured
+	}
+
+	ss, err := jwtKeys.sign(claims)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	return ss, nil
+	return ss, tokenID, nil
 }
 
-// ParseUserIDFromToken verify jwt token and get user ID information
-func ParseUserIDandRoleFromToken(tokenString string) (int64, int16, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &model.Claims{}, func(token *jwt.Token) (any, error) {
-		return []byte(jwtSignatureKey), nil
-	})
+// GenerateRefreshToken generate an opaque refresh token, returning it with the hash to persist
+func GenerateRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken hash a refresh token for storage and lookup
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseUserIDandRoleFromToken verify jwt token against the denylist and get user ID, role and token ID information
+func ParseUserIDandRoleFromToken(ctx context.Context, tokenString string) (int64, int16, string, error) {
+	if jwtKeys == nil {
+		return 0, 9, "", ErrJWTKeysNotConfigured
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &model.Claims{}, jwtKeys.verificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
 	if err != nil {
-		return 0, 9, err
+		return 0, 9, "", err
 	}
 	claims, ok := token.Claims.(*model.Claims)
-	if !ok || claims.UserID == 0 {
-		return 0, 9, err
+	if !ok || claims.UserID == 0 || claims.ID == "" {
+		return 0, 9, "", errors.New("invalid token claims")
+	}
+
+	if tokenDenylist != nil {
+		revoked, err := tokenDenylist.IsAccessTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return 0, 9, "", err
+		}
+		if revoked {
+			return 0, 9, "", constants.ErrTokenRevoked
+		}
 	}
-	return claims.UserID, claims.Role, nil
+
+	return claims.UserID, claims.Role, claims.ID, nil
 }
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"PattyWagon/internal/constants"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubDenylist struct {
+	revoked map[string]bool
+	err     error
+}
+
+func (d stubDenylist) IsAccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return d.revoked[tokenID], d.err
+}
+
+func TestParseUserIDandRoleFromToken(t *testing.T) {
+	setTestJWTKeys(t)
+	token, tokenID, err := GenerateToken(42, constants.RoleUser)
+	require.NoError(t, err)
+	t.Cleanup(func() { SetTokenDenylist(nil) })
+
+	t.Run("Valid", func(t *testing.T) {
+		SetTokenDenylist(stubDenylist{})
+		userID, role, parsedTokenID, err := ParseUserIDandRoleFromToken(context.TODO(), token)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(42), userID)
+		assert.Equal(t, constants.RoleUser, role)
+		assert.Equal(t, tokenID, parsedTokenID)
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		SetTokenDenylist(stubDenylist{revoked: map[string]bool{tokenID: true}})
+		_, _, _, err := ParseUserIDandRoleFromToken(context.TODO(), token)
+		assert.ErrorIs(t, err, constants.ErrTokenRevoked)
+	})
+
+	t.Run("DenylistUnavailable", func(t *testing.T) {
+		SetTokenDenylist(stubDenylist{err: errors.New("connection refused")})
+		_, _, _, err := ParseUserIDandRoleFromToken(context.TODO(), token)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		SetTokenDenylist(stubDenylist{})
+		_, _, _, err := ParseUserIDandRoleFromToken(context.TODO(), token+"x")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGenerateRefreshToken(t *testing.T) {
+	token, hash, err := GenerateRefreshToken()
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, HashRefreshToken(token), hash)
+	assert.NotEqual(t, token, hash)
+
+	other, _, err := GenerateRefreshToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
@@ -34,3 +34,10 @@ func String2Int32(input string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func String2Float64(input string, defaultValue float64) float64 {
+	if parsed, err := strconv.ParseFloat(input, 64); err == nil {
+		return parsed
+	}
+	return defaultValue
+}
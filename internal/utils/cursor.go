@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+type cursorPayload struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"i"`
+	Backward  bool      `json:"b,omitempty"`
+	// Ascending is left out for the default newest first order, so cursors issued before it existed stay valid
+	Ascending bool `json:"a,omitempty"`
+}
+
+// EncodeCursor turns a listing position into the opaque token handed to clients
+func EncodeCursor(cursor model.Cursor) string {
+	payload, _ := json.Marshal(cursorPayload{
+		CreatedAt: cursor.CreatedAt.UTC(),
+		ID:        cursor.ID,
+		Backward:  cursor.Backward,
+		Ascending: !cursor.Descending,
+	})
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// DecodeCursor reads a token back, rejecting one taken from a listing sorted in the other direction
+func DecodeCursor(token string, descending bool) (model.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return model.Cursor{}, constants.ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.ID <= 0 {
+		return model.Cursor{}, constants.ErrInvalidCursor
+	}
+	if payload.Ascending == descending {
+		return model.Cursor{}, constants.ErrInvalidCursor
+	}
+
+	return model.Cursor{
+		CreatedAt:  payload.CreatedAt.UTC(),
+		ID:         payload.ID,
+		Backward:   payload.Backward,
+		Descending: descending,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"PattyWagon/internal/constants"
+	"PattyWagon/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		for _, descending := range []bool{true, false} {
+			cursor := model.Cursor{
+				CreatedAt:  time.Date(2025, 10, 7, 8, 30, 0, 123456000, time.UTC),
+				ID:         42,
+				Backward:   true,
+				Descending: descending,
+			}
+
+			decoded, err := DecodeCursor(EncodeCursor(cursor), descending)
+			require.NoError(t, err)
+			assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+			assert.Equal(t, cursor.ID, decoded.ID)
+			assert.Equal(t, cursor.Backward, decoded.Backward)
+			assert.Equal(t, descending, decoded.Descending)
+		}
+	})
+
+	t.Run("OtherDirection", func(t *testing.T) {
+		token := EncodeCursor(model.Cursor{CreatedAt: time.Now(), ID: 42, Descending: true})
+
+		_, err := DecodeCursor(token, false)
+
+		assert.ErrorIs(t, err, constants.ErrInvalidCursor)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, token := range []string{"", "not base64!", "bm90IGpzb24", "e30"} {
+			_, err := DecodeCursor(token, true)
+			assert.ErrorIs(t, err, constants.ErrInvalidCursor, "token %q", token)
+		}
+	})
+}
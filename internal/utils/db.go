@@ -14,6 +14,10 @@ func IsErrDBConstraint(err error) bool {
 	return strings.Contains(err.Error(), "unique constraint")
 }
 
+func IsErrDBForeignKey(err error) bool {
+	return strings.Contains(err.Error(), "foreign key constraint")
+}
+
 func IsDBError(err error) bool {
 	if err == nil {
 		return false
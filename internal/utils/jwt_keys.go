@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"PattyWagon/internal/model"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrJWTKeysNotConfigured = errors.New("jwt signing key is not configured")
+
+// JWTKeys holds the key new tokens are signed with and every key a token may be verified with, indexed by kid.
+// Keeping retired public keys in the verification set lets tokens signed before a rotation stay valid.
+type JWTKeys struct {
+	signingKey       crypto.Signer
+	signingKeyID     string
+	verificationKeys map[string]jwtVerificationKey
+}
+
+type jwtVerificationKey struct {
+	key    crypto.PublicKey
+	method jwt.SigningMethod
+	jwk    model.JWK
+}
+
+var jwtKeys *JWTKeys
+
+// SetJWTKeys registers the keys used by GenerateToken and ParseUserIDandRoleFromToken
+func SetJWTKeys(keys *JWTKeys) {
+	jwtKeys = keys
+}
+
+// PublicJWKS returns the registered verification keys as a JSON Web Key Set
+func PublicJWKS() model.JWKS {
+	jwks := model.JWKS{Keys: []model.JWK{}}
+	if jwtKeys == nil {
+		return jwks
+	}
+
+	for _, key := range jwtKeys.verificationKeys {
+		jwks.Keys = append(jwks.Keys, key.jwk)
+	}
+	slices.SortFunc(jwks.Keys, func(a, b model.JWK) int {
+		return strings.Compare(a.Kid, b.Kid)
+	})
+	return jwks
+}
+
+// LoadJWTKeys reads a PKCS#8 RSA or Ed25519 private key to sign with and any number of PKIX public keys
+// that are accepted on top of the signing key's own public half
+func LoadJWTKeys(signingKeyFile string, verificationKeyFiles []string) (*JWTKeys, error) {
+	if signingKeyFile == "" {
+		return nil, ErrJWTKeysNotConfigured
+	}
+
+	block, err := readPEMFile(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse signing key %s: %w", signingKeyFile, err)
+	}
+	signingKey, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not a private key", signingKeyFile)
+	}
+
+	verificationKeys := make([]crypto.PublicKey, 0, len(verificationKeyFiles))
+	for _, file := range verificationKeyFiles {
+		block, err := readPEMFile(file)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse verification key %s: %w", file, err)
+		}
+		verificationKeys = append(verificationKeys, publicKey)
+	}
+
+	return NewJWTKeys(signingKey, verificationKeys...)
+}
+
+// NewJWTKeys builds a key set from an RSA or Ed25519 signing key and additional verification keys
+func NewJWTKeys(signingKey crypto.Signer, verificationKeys ...crypto.PublicKey) (*JWTKeys, error) {
+	keys := &JWTKeys{
+		signingKey:       signingKey,
+		verificationKeys: make(map[string]jwtVerificationKey, len(verificationKeys)+1),
+	}
+
+	signingKeyID, err := keys.addVerificationKey(signingKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	keys.signingKeyID = signingKeyID
+
+	for _, key := range verificationKeys {
+		if _, err := keys.addVerificationKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+func (k *JWTKeys) addVerificationKey(key crypto.PublicKey) (string, error) {
+	var (
+		method jwt.SigningMethod
+		jwk    model.JWK
+	)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return "", errors.New("rsa keys must be at least 2048 bits")
+		}
+		method = jwt.SigningMethodRS256
+		jwk = model.JWK{
+			Kty: "RSA",
+			Alg: method.Alg(),
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+		jwk.Kid = jwkThumbprint(fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N))
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+		jwk = model.JWK{
+			Kty: "OKP",
+			Alg: method.Alg(),
+			Use: "sig",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+		jwk.Kid = jwkThumbprint(fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, jwk.X))
+	default:
+		return "", fmt.Errorf("unsupported jwt key type %T", key)
+	}
+
+	k.verificationKeys[jwk.Kid] = jwtVerificationKey{
+		key:    key,
+		method: method,
+		jwk:    jwk,
+	}
+
+	return jwk.Kid, nil
+}
+
+func (k *JWTKeys) sign(claims jwt.Claims) (string, error) {
+	key := k.verificationKeys[k.signingKeyID]
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = k.signingKeyID
+	return token.SignedString(k.signingKey)
+}
+
+// verificationKey resolves the key a token names in its kid header, rejecting algorithm mismatches
+func (k *JWTKeys) verificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.verificationKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+	}
+
+	return key.key, nil
+}
+
+// jwkThumbprint computes the RFC 7638 thumbprint of a key's canonical JWK members
+func jwkThumbprint(canonicalJWK string) string {
+	sum := sha256.Sum256([]byte(canonicalJWK))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func readPEMFile(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read jwt key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"PattyWagon/internal/constants"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTestJWTKeys registers a fresh Ed25519 key set for the duration of the test
+func setTestJWTKeys(t *testing.T) *JWTKeys {
+	t.Helper()
+
+	_, signingKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keys, err := NewJWTKeys(signingKey)
+	require.NoError(t, err)
+
+	SetJWTKeys(keys)
+	t.Cleanup(func() { SetJWTKeys(nil) })
+	return keys
+}
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600)
+	require.NoError(t, err)
+	return path
+}
+
+func TestLoadJWTKeys(t *testing.T) {
+	t.Run("MissingSigningKey", func(t *testing.T) {
+		_, err := LoadJWTKeys("", nil)
+		assert.ErrorIs(t, err, ErrJWTKeysNotConfigured)
+	})
+
+	t.Run("RS256", func(t *testing.T) {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		require.NoError(t, err)
+
+		keys, err := LoadJWTKeys(writePEM(t, "PRIVATE KEY", der), nil)
+		require.NoError(t, err)
+		SetJWTKeys(keys)
+		t.Cleanup(func() { SetJWTKeys(nil) })
+
+		token, _, err := GenerateToken(7, constants.RoleAdmin)
+		require.NoError(t, err)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "RS256", parsed.Method.Alg())
+		assert.Equal(t, keys.signingKeyID, parsed.Header["kid"])
+
+		userID, role, _, err := ParseUserIDandRoleFromToken(context.TODO(), token)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(7), userID)
+		assert.Equal(t, constants.RoleAdmin, role)
+
+		jwks := PublicJWKS()
+		require.Len(t, jwks.Keys, 1)
+		assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+		assert.Equal(t, "AQAB", jwks.Keys[0].E)
+	})
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	oldPublicKey, oldSigningKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	oldKeys, err := NewJWTKeys(oldSigningKey)
+	require.NoError(t, err)
+
+	SetJWTKeys(oldKeys)
+	t.Cleanup(func() { SetJWTKeys(nil) })
+	oldToken, _, err := GenerateToken(1, constants.RoleUser)
+	require.NoError(t, err)
+
+	_, newSigningKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("RetiredKeyStillVerifies", func(t *testing.T) {
+		keys, err := NewJWTKeys(newSigningKey, oldPublicKey)
+		require.NoError(t, err)
+		SetJWTKeys(keys)
+
+		_, _, _, err = ParseUserIDandRoleFromToken(context.TODO(), oldToken)
+		assert.Nil(t, err)
+		assert.Len(t, PublicJWKS().Keys, 2)
+	})
+
+	t.Run("DroppedKeyIsRejected", func(t *testing.T) {
+		keys, err := NewJWTKeys(newSigningKey)
+		require.NoError(t, err)
+		SetJWTKeys(keys)
+
+		_, _, _, err = ParseUserIDandRoleFromToken(context.TODO(), oldToken)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("SymmetricTokenIsRejected", func(t *testing.T) {
+		keys, err := NewJWTKeys(newSigningKey)
+		require.NoError(t, err)
+		SetJWTKeys(keys)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"userID": 1, "jti": "x"})
+		token.Header["kid"] = keys.signingKeyID
+		hsToken, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, _, _, err = ParseUserIDandRoleFromToken(context.TODO(), hsToken)
+		assert.NotNil(t, err)
+	})
+}
@@ -16,7 +16,6 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 )
 
-var OtlpEndpoint = os.Getenv("OTLP_ENDPOINT")
 var Tracer = otel.Tracer("patty-wagon")
 
 func NewOTLPTraceExporter(ctx context.Context, otlpEndpoint string) *otlptrace.Exporter {
@@ -56,7 +55,7 @@ func SetupTracer(ctx context.Context, otlpEndpoint string) {
 		defer cancel()
 
 		if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
-			log.Fatalf("Error shutting down tracer: %w", err)
+			log.Fatalf("Error shutting down tracer: %v", err)
 		}
 	}()
 }
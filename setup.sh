@@ -2,6 +2,9 @@ export GOOSE_DRIVER=postgres
 export GOOSE_MIGRATION_DIR=db/sql/migrations
 export GOOSE_DBSTRING="user=postgres password=postgres dbname=patty-wagon-dev host=localhost port=5432 sslmode=disable"
 
+# Optional yaml or json config file, the variables below override whatever it sets
+export CONFIG_FILE=
+
 export PORT=8080
 export APP_ENV=local
 export DB_HOST=localhost
@@ -16,7 +19,15 @@ export DB_MAX_IDLE_CONNS=5
 export DB_CONN_MAX_IDLE_TIME_IN_SECONDS=60
 export DB_CONN_MAX_LIFE_TIME_IN_SECONDS=300
 
-export JWT_SIGNATURE_KEY=solidteam
+# Generate with `make jwt-keys`; list retired public keys in JWT_VERIFICATION_KEY_FILES (comma separated) while their tokens live
+export JWT_SIGNING_KEY_FILE=keys/jwt-signing.pem
+export JWT_VERIFICATION_KEY_FILES=
+
+# Storage: minio, local (files under STORAGE_LOCAL_DIR served by GET /files/{key}) or memory (lost on restart)
+# STORAGE_PUBLIC_BASE_URL prefixes file URIs; local and memory need the API URL, minio defaults to its endpoint
+export STORAGE_BACKEND=minio
+export STORAGE_LOCAL_DIR=data/files
+export STORAGE_PUBLIC_BASE_URL=
 
 export S3_ACCESS_KEY_ID=team-solid
 export S3_SECRET_ACCESS_KEY=@team-solid
@@ -24,7 +35,37 @@ export S3_ENDPOINT=localhost:9000
 export S3_BUCKET=images
 export S3_MAX_CONCURRENT_UPLOAD=5
 
+# Nearby search: h3 (k-ring expansion) or postgis (GiST index, radius in meters)
+export NEARBY_SEARCH_STRATEGY=h3
+export NEARBY_SEARCH_RADIUS_IN_METERS=20000
+export H3_START_RESOLUTION=8
+
+# Pricing: delivery fee is the base fee plus the fee per km of route, tax is a percent of the discounted subtotal
+export DELIVERY_BASE_FEE=5000
+export DELIVERY_FEE_PER_KM=2500
+export SERVICE_FEE=2000
+export TAX_RATE_PERCENT=11
+
+# Travel time: speed_profile (straight line) or road_graph (A* over ROAD_GRAPH_FILE), both read the optional
+# speed profiles (yaml or json) for vehicle speeds and hour of day congestion; vehicle is bike, motorbike or car
+export TRAVEL_TIME_MODEL=speed_profile
+export TRAVEL_TIME_PROFILE_FILE=
+export ROAD_GRAPH_FILE=
+export DELIVERY_VEHICLE_TYPE=motorbike
+
 # Image Compression
 export MAX_CONCURRENT_COMPRESS=10
+export COMPRESSION_QUALITY=50
+# Renditions every uploaded image is resized to, as name:size in pixels:format[:quality] with format jpeg, png,
+# webp or avif; quality defaults to COMPRESSION_QUALITY and the thumbnail rendition (or the smallest) is the thumbnail
+export IMAGE_RENDITIONS=thumbnail:150:jpeg,medium:480:webp:75,large:1080:webp:80
+
+# Background jobs (thumbnails): failed jobs retry after the backoff, doubled each time, and are dead lettered
+# after JOB_MAX_ATTEMPTS runs; JOB_WORKERS=0 leaves the queue to another process
+export JOB_WORKERS=2
+export JOB_POLL_INTERVAL_IN_MS=1000
+export JOB_MAX_ATTEMPTS=5
+export JOB_BACKOFF_IN_SECONDS=5
+export JOB_LOCK_TIMEOUT_IN_SECONDS=300
 
 export OTLP_ENDPOINT=localhost:4317